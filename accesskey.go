@@ -0,0 +1,42 @@
+package communicationidentity
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+)
+
+func decodeAccessKey(acsAccessKey string) ([]byte, error) {
+	decoded, err := base64.StdEncoding.DecodeString(acsAccessKey)
+	if err != nil {
+		return nil, fmt.Errorf("ACS access key is not valid base64: %w", err)
+	}
+	return decoded, nil
+}
+
+// DecodeAccessKeyFromEnv reads the base64 encoded ACS access key from the environment variable
+// envVar and returns the decoded key bytes.
+func DecodeAccessKeyFromEnv(envVar string) ([]byte, error) {
+	acsAccessKey, isSet := os.LookupEnv(envVar)
+	if !isSet {
+		return nil, fmt.Errorf("environment variable %q is not set", envVar)
+	}
+	if acsAccessKey == "" {
+		return nil, fmt.Errorf("environment variable %q is empty", envVar)
+	}
+	decoded, err := decodeAccessKey(acsAccessKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode environment variable %q: %w", envVar, err)
+	}
+	return decoded, nil
+}
+
+// MustDecodeAccessKeyFromEnv is like [DecodeAccessKeyFromEnv] but panics on failure,
+// intended for initialization of package level variables.
+func MustDecodeAccessKeyFromEnv(envVar string) []byte {
+	decoded, err := DecodeAccessKeyFromEnv(envVar)
+	if err != nil {
+		panic(err)
+	}
+	return decoded
+}
@@ -0,0 +1,49 @@
+package communicationidentity_test
+
+import (
+	"bytes"
+	"testing"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+)
+
+func TestDecodeAccessKeyFromEnv(t *testing.T) {
+	const envVar = "TEST_ACS_ACCESS_KEY"
+
+	t.Run("valid", func(t *testing.T) {
+		t.Setenv(envVar, "c2VjcmV0")
+		key, err := ci.DecodeAccessKeyFromEnv(envVar)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(key, []byte("secret")) {
+			t.Errorf("unexpected decoded key: %q", key)
+		}
+	})
+	t.Run("unset", func(t *testing.T) {
+		if _, err := ci.DecodeAccessKeyFromEnv("TEST_ACS_ACCESS_KEY_UNSET"); err == nil {
+			t.Error("expected error for unset variable")
+		}
+	})
+	t.Run("empty", func(t *testing.T) {
+		t.Setenv(envVar, "")
+		if _, err := ci.DecodeAccessKeyFromEnv(envVar); err == nil {
+			t.Error("expected error for empty variable")
+		}
+	})
+	t.Run("not base64", func(t *testing.T) {
+		t.Setenv(envVar, "not base64!")
+		if _, err := ci.DecodeAccessKeyFromEnv(envVar); err == nil {
+			t.Error("expected error for invalid base64")
+		}
+	})
+}
+
+func TestMustDecodeAccessKeyFromEnvPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for unset variable")
+		}
+	}()
+	ci.MustDecodeAccessKeyFromEnv("TEST_ACS_ACCESS_KEY_UNSET")
+}
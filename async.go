@@ -0,0 +1,53 @@
+package communicationidentity
+
+import "context"
+
+type futureResult[T any] struct {
+	value T
+	err   error
+}
+
+// Future is the pending result of an asynchronous client call
+type Future[T any] struct {
+	result chan futureResult[T]
+}
+
+func newFuture[T any](fn func() (T, error)) *Future[T] {
+	future := &Future[T]{result: make(chan futureResult[T], 1)}
+	go func() {
+		value, err := fn()
+		future.result <- futureResult[T]{value, err}
+	}()
+	return future
+}
+
+// Get waits for the result of the call, an error is either the error of the call or the error of
+// ctx if it is done first. Get can be called multiple times and returns the same result each time.
+func (future *Future[T]) Get(ctx context.Context) (T, error) {
+	select {
+	case result := <-future.result:
+		// put it back for later calls, the capacity of 1 keeps this from blocking
+		future.result <- result
+		return result.value, result.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// AsyncCreateCommunicationIdentity starts [CommunicationIdentityClient.CreateCommunicationIdentity]
+// in a goroutine and returns immediately. If ctx is done before the call starts, no request is
+// sent and the future returns the context error.
+func (client CommunicationIdentityClient) AsyncCreateCommunicationIdentity(
+	ctx context.Context,
+	scopes []Scope,
+	expiry *int32,
+	opts ...CallOption,
+) *Future[CommunicationIdentityAccessTokenResult] {
+	return newFuture(func() (CommunicationIdentityAccessTokenResult, error) {
+		if err := ctx.Err(); err != nil {
+			return CommunicationIdentityAccessTokenResult{}, err
+		}
+		return client.CreateCommunicationIdentity(ctx, scopeStrings(scopes), expiry, opts...)
+	})
+}
@@ -0,0 +1,48 @@
+package communicationidentity_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+)
+
+func TestAsyncCreateCommunicationIdentity(t *testing.T) {
+	var calls atomic.Int32
+	client := newTestClient(t, createIdentityHandler(&calls))
+
+	future := client.AsyncCreateCommunicationIdentity(
+		context.Background(),
+		[]ci.Scope{ci.ScopeChat},
+		nil,
+	)
+	for range 2 {
+		result, err := future.Get(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Identity.ID != "identity-1" {
+			t.Errorf("unexpected result: %+v", result)
+		}
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected a single request, got %d", calls.Load())
+	}
+}
+
+func TestAsyncCreateCommunicationIdentityCancelled(t *testing.T) {
+	var calls atomic.Int32
+	client := newTestClient(t, createIdentityHandler(&calls))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	future := client.AsyncCreateCommunicationIdentity(ctx, []ci.Scope{ci.ScopeChat}, nil)
+	if _, err := future.Get(context.Background()); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if calls.Load() != 0 {
+		t.Errorf("expected no request, got %d", calls.Load())
+	}
+}
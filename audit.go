@@ -0,0 +1,58 @@
+package communicationidentity
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Audit record of a single request sent to ACS
+type OutgoingRequestEvent struct {
+	// time the request was sent
+	Timestamp time.Time `json:"timestamp"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	// value of the `x-ms-request-id` response header, empty if no response was received
+	RequestID  string `json:"requestId,omitempty"`
+	StatusCode int    `json:"statusCode,omitempty"`
+	// time until the response headers were received
+	Latency time.Duration `json:"latencyNs"`
+	// transport error, empty if a response was received
+	Error string `json:"error,omitempty"`
+}
+
+// Receives an [OutgoingRequestEvent] for every request sent to ACS, in the order the requests
+// completed. RecordEvent is called synchronously and must be safe for concurrent use.
+type AuditSink interface {
+	RecordEvent(event OutgoingRequestEvent)
+}
+
+// WithAuditSink records every request sent to ACS to s
+func WithAuditSink(s AuditSink) ClientOption {
+	return func(options *clientOptions) error {
+		if s == nil {
+			return fmt.Errorf("audit sink can not be nil")
+		}
+		options.auditSink = s
+		return nil
+	}
+}
+
+type jsonLineAuditSink struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+}
+
+// JSONLineAuditSink writes one JSON object per line and event to w, e.g. for log aggregation.
+// Write errors are dropped, w must handle its own failures.
+func JSONLineAuditSink(w io.Writer) AuditSink {
+	return &jsonLineAuditSink{encoder: json.NewEncoder(w)}
+}
+
+func (sink *jsonLineAuditSink) RecordEvent(event OutgoingRequestEvent) {
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	_ = sink.encoder.Encode(event)
+}
@@ -0,0 +1,42 @@
+package communicationidentity_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+)
+
+func TestJSONLineAuditSink(t *testing.T) {
+	var out bytes.Buffer
+	client := newTestClient(
+		t,
+		errorHandler(http.StatusForbidden, "request-7", `{"error":{"code":"Forbidden"}}`),
+		ci.WithAuditSink(ci.JSONLineAuditSink(&out)),
+	)
+
+	ctx := context.Background()
+	_, _ = client.CreateCommunicationIdentity(ctx, []string{"chat"}, nil)
+	_, _ = client.TokenForTeamsUser(ctx, "oid", "token")
+
+	lines := bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatalf("expected one line per request, got %q", out.String())
+	}
+	var event ci.OutgoingRequestEvent
+	if err := json.Unmarshal(lines[0], &event); err != nil {
+		t.Fatal(err)
+	}
+	if event.Method != http.MethodPost || event.Path != "/identities" {
+		t.Errorf("unexpected request in event: %+v", event)
+	}
+	if event.StatusCode != http.StatusForbidden || event.RequestID != "request-7" {
+		t.Errorf("unexpected outcome in event: %+v", event)
+	}
+	if event.Timestamp.IsZero() || event.Latency <= 0 {
+		t.Errorf("expected timing information in event: %+v", event)
+	}
+}
@@ -0,0 +1,146 @@
+package communicationidentity
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RefreshFunc reissues the token of identityID for [WithAutoRefresh], e.g. by calling
+// [CommunicationIdentityClient.IssueAccessToken] with the scopes the identity is used for
+type RefreshFunc func(ctx context.Context, identityID string) (
+	CommunicationIdentityAccessTokenResult,
+	error,
+)
+
+type autoRefreshPolicy struct {
+	threshold time.Duration
+	refresh   RefreshFunc
+}
+
+// WithAutoRefresh refreshes tokens in the cache of [WithTokenCache] in the background before they
+// expire. Every threshold/2 the tokens the client has cached are checked, refresh is called for
+// those expiring within threshold and its result replaces the cached token. Failed refreshes are
+// logged and retried on the next check while the cached token is valid.
+//
+// Tokens set on the cache by the application are not refreshed. The background refresh stops
+// once the client is closed, see [CommunicationIdentityClient.Close].
+func WithAutoRefresh(threshold time.Duration, refresh RefreshFunc) ClientOption {
+	return func(options *clientOptions) error {
+		if threshold <= 0 || threshold/2 <= 0 {
+			return fmt.Errorf("auto refresh threshold must be positive, got %v", threshold)
+		}
+		if refresh == nil {
+			return fmt.Errorf("auto refresh function can not be nil")
+		}
+		options.autoRefresh = &autoRefreshPolicy{threshold: threshold, refresh: refresh}
+		return nil
+	}
+}
+
+// Keys the client has set on its token cache, checked by the background refresh
+type tokenRefresher struct {
+	policy autoRefreshPolicy
+	cache  TokenCache
+
+	mu     sync.Mutex
+	tokens map[string]refreshedToken
+	// incremented per track, so a key tracked again during a check is not forgotten
+	generation uint64
+}
+
+type refreshedToken struct {
+	identityID string
+	generation uint64
+}
+
+func newTokenRefresher(policy *autoRefreshPolicy, cache TokenCache) (*tokenRefresher, error) {
+	if policy == nil {
+		return nil, nil
+	}
+	if cache == nil {
+		return nil, fmt.Errorf("auto refresh requires a token cache, see WithTokenCache")
+	}
+	return &tokenRefresher{policy: *policy, cache: cache, tokens: map[string]refreshedToken{}}, nil
+}
+
+func (refresher *tokenRefresher) track(key string, identityID string) {
+	refresher.mu.Lock()
+	defer refresher.mu.Unlock()
+	refresher.generation++
+	refresher.tokens[key] = refreshedToken{identityID, refresher.generation}
+}
+
+// forget stops checking key unless it was tracked again since token was read
+func (refresher *tokenRefresher) forget(key string, token refreshedToken) {
+	refresher.mu.Lock()
+	defer refresher.mu.Unlock()
+	if refresher.tokens[key] == token {
+		delete(refresher.tokens, key)
+	}
+}
+
+func (refresher *tokenRefresher) snapshot() map[string]refreshedToken {
+	refresher.mu.Lock()
+	defer refresher.mu.Unlock()
+	tokens := make(map[string]refreshedToken, len(refresher.tokens))
+	for key, token := range refresher.tokens {
+		tokens[key] = token
+	}
+	return tokens
+}
+
+// startAutoRefresh runs the background refresh of WithAutoRefresh until the client is closed
+func (client CommunicationIdentityClient) startAutoRefresh() {
+	if client.state.refresher == nil {
+		return
+	}
+	client.goBackground(func(ctx context.Context) {
+		interval := client.state.refresher.policy.threshold / 2
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-client.timeSource().After(interval):
+			}
+			client.refreshExpiringTokens(ctx)
+		}
+	})
+}
+
+// refreshExpiringTokens refreshes the tracked tokens expiring within the threshold, tokens no
+// longer in the cache are not tracked anymore
+func (client CommunicationIdentityClient) refreshExpiringTokens(ctx context.Context) {
+	refresher := client.state.refresher
+	for key, token := range refresher.snapshot() {
+		if ctx.Err() != nil {
+			return
+		}
+		cached, found := refresher.cache.Get(key)
+		if !found {
+			refresher.forget(key, token)
+			continue
+		}
+		remaining := cached.AccessToken.ExpiresOn.Sub(client.timeSource().Now())
+		if remaining > refresher.policy.threshold {
+			continue
+		}
+		refreshed, err := refresher.policy.refresh(ctx, token.identityID)
+		if err != nil {
+			if ctx.Err() == nil {
+				client.logger().Warn(
+					"failed to refresh cached ACS token",
+					"identity_id", token.identityID,
+					"expires_on", cached.AccessToken.ExpiresOn,
+					"error", err,
+				)
+			}
+			continue
+		}
+		if refreshed.Identity.ID == "" {
+			refreshed.Identity = cached.Identity
+		}
+		refresher.cache.Set(key, refreshed)
+	}
+}
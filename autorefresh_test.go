@@ -0,0 +1,138 @@
+package communicationidentity_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+	"github.com/jls-ch/azure-communication-identity-go/communicationidentitytest"
+)
+
+func TestAutoRefreshReplacesExpiringTokens(t *testing.T) {
+	clock := communicationidentitytest.NewMockClock(time.Now())
+	cache := ci.NewInMemoryTokenCache(0)
+	var refreshes atomic.Int32
+	refreshed := make(chan string, 10)
+	client := newTestClient(
+		t,
+		createIdentityHandler(new(atomic.Int32)),
+		ci.WithTimeSource(clock),
+		ci.WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		ci.WithTokenCache(cache),
+		ci.WithAutoRefresh(10*time.Minute, func(
+			ctx context.Context,
+			identityID string,
+		) (ci.CommunicationIdentityAccessTokenResult, error) {
+			if refreshes.Add(1) == 1 {
+				return ci.CommunicationIdentityAccessTokenResult{}, errors.New("unavailable")
+			}
+			defer func() { refreshed <- identityID }()
+			return ci.CommunicationIdentityAccessTokenResult{
+				AccessToken: ci.CommunicationIdentityAccessToken{
+					Token:     "refreshed-token",
+					ExpiresOn: clock.Now().Add(2 * time.Hour),
+				},
+			}, nil
+		}),
+	)
+	t.Cleanup(func() { _ = client.Close() })
+
+	_, err := client.CreateCommunicationIdentity(
+		context.Background(), []string{"chat"}, nil, ci.WithTokenCacheKey("user-1"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(30 * time.Minute)
+	time.Sleep(10 * time.Millisecond)
+	if refreshes.Load() != 0 {
+		t.Fatalf("expected no refresh of a token valid beyond the threshold")
+	}
+
+	deadline := time.After(time.Second)
+	for done := false; !done; {
+		clock.Advance(5 * time.Minute)
+		select {
+		case identityID := <-refreshed:
+			if identityID != "identity-1" {
+				t.Errorf("expected a refresh of identity-1, got %q", identityID)
+			}
+			done = true
+		case <-time.After(time.Millisecond):
+		case <-deadline:
+			t.Fatal("token was not refreshed")
+		}
+	}
+	if refreshes.Load() < 2 {
+		t.Errorf("expected the failed refresh to be retried, got %d refreshes", refreshes.Load())
+	}
+
+	result, found := cache.Get("user-1")
+	for !found || result.AccessToken.Token != "refreshed-token" {
+		select {
+		case <-deadline:
+			t.Fatalf("expected the refreshed token to be cached, got %+v", result)
+		case <-time.After(time.Millisecond):
+		}
+		result, found = cache.Get("user-1")
+	}
+	if result.Identity.ID != "identity-1" {
+		t.Errorf("expected the identity of the cached token, got %+v", result.Identity)
+	}
+}
+
+func TestCloseStopsAutoRefresh(t *testing.T) {
+	clock := communicationidentitytest.NewMockClock(time.Now())
+	var refreshes atomic.Int32
+	client := newTestClient(
+		t,
+		createIdentityHandler(new(atomic.Int32)),
+		ci.WithTimeSource(clock),
+		ci.WithTokenCache(ci.NewInMemoryTokenCache(0)),
+		ci.WithAutoRefresh(2*time.Hour, func(
+			ctx context.Context,
+			identityID string,
+		) (ci.CommunicationIdentityAccessTokenResult, error) {
+			refreshes.Add(1)
+			return ci.CommunicationIdentityAccessTokenResult{}, ctx.Err()
+		}),
+	)
+	_, err := client.CreateCommunicationIdentity(context.Background(), []string{"chat"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := client.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Close(); err != nil {
+		t.Errorf("expected closing again to succeed, got %v", err)
+	}
+	clock.Advance(time.Hour)
+	time.Sleep(10 * time.Millisecond)
+	if refreshes.Load() != 0 {
+		t.Errorf("expected no refresh after Close, got %d", refreshes.Load())
+	}
+}
+
+func TestWithAutoRefreshValidation(t *testing.T) {
+	refresh := func(context.Context, string) (ci.CommunicationIdentityAccessTokenResult, error) {
+		return ci.CommunicationIdentityAccessTokenResult{}, nil
+	}
+	cache := ci.WithTokenCache(ci.NewInMemoryTokenCache(0))
+	for name, opts := range map[string][]ci.ClientOption{
+		"no cache":           {ci.WithAutoRefresh(time.Minute, refresh)},
+		"zero threshold":     {cache, ci.WithAutoRefresh(0, refresh)},
+		"negative threshold": {cache, ci.WithAutoRefresh(-time.Minute, refresh)},
+		"nil refresh":        {cache, ci.WithAutoRefresh(time.Minute, nil)},
+	} {
+		if _, err := ci.New(nil, testAccessKey, "", opts...); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
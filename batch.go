@@ -0,0 +1,148 @@
+package communicationidentity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// upper bound of concurrent ACS requests of a single batch call
+const maxBatchConcurrency = 4
+
+// A single token of an IssueAccessTokenBatch call
+type TokenRequest struct {
+	Scopes []Scope
+	// nil uses the default expiry
+	ExpireInMinutes *int32
+}
+
+// IssueAccessTokenBatch issues one token per request for the same identity, e.g. one for chat and
+// one for VoIP. The returned tokens are aligned with requests, tokens of failed requests are zero.
+// If any request fails, a *MultiError with the errors of the failed requests is returned, each
+// cause names the index of its request. If all requests fail no tokens are returned.
+func (client CommunicationIdentityClient) IssueAccessTokenBatch(
+	ctx context.Context,
+	identityID string,
+	requests []TokenRequest,
+	opts ...CallOption,
+) ([]CommunicationIdentityAccessToken, error) {
+	callOpts := applyCallOptions(opts)
+	if err := client.validateIdentityID(identityID); err != nil {
+		return nil, err
+	}
+	for i, request := range requests {
+		if err := validateScopes(fmt.Sprintf("requests[%d].Scopes", i), request.Scopes); err != nil {
+			return nil, err
+		}
+	}
+
+	tokens := make([]CommunicationIdentityAccessToken, len(requests))
+	errs := make([]error, len(requests))
+	semaphore := make(chan struct{}, maxBatchConcurrency)
+	var wg sync.WaitGroup
+	for i, request := range requests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case semaphore <- struct{}{}:
+				defer func() { <-semaphore }()
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				return
+			}
+			token, err := client.issueAccessToken(
+				ctx,
+				identityID,
+				scopeStrings(request.Scopes),
+				request.ExpireInMinutes,
+				callOpts,
+			)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			tokens[i] = client.secureToken(token)
+		}()
+	}
+	wg.Wait()
+
+	multiErr := &MultiError{}
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		multiErr.Causes = append(multiErr.Causes, fmt.Errorf("requests[%d]: %w", i, err))
+		var identityErr *CommunicationIdentityError
+		if errors.As(err, &identityErr) && identityErr.ACSError != nil {
+			multiErr.Errors = append(multiErr.Errors, identityErr.ACSError)
+		}
+	}
+	switch len(multiErr.Causes) {
+	case 0:
+		return tokens, nil
+	case len(requests):
+		return nil, multiErr
+	default:
+		return tokens, multiErr
+	}
+}
+
+// A single exchange of a TokenForTeamsUserBatch call
+type TeamsUserExchangeRequest struct {
+	UserOID   string
+	MSALToken string
+}
+
+// The outcome of a single exchange of a TokenForTeamsUserBatch call, either Token or Err is nil
+type TeamsUserTokenExchangeResult struct {
+	Token *CommunicationIdentityAccessToken
+	Err   error
+}
+
+// TokenForTeamsUserBatch exchanges the MSAL tokens of many Teams users with up to concurrency
+// exchanges in flight, values below 1 use a single worker. The results are aligned with requests.
+// Once ctx is done no further exchanges are started, the remaining results carry the context error.
+func (client CommunicationIdentityClient) TokenForTeamsUserBatch(
+	ctx context.Context,
+	requests []TeamsUserExchangeRequest,
+	concurrency int,
+	opts ...CallOption,
+) []TeamsUserTokenExchangeResult {
+	results := make([]TeamsUserTokenExchangeResult, len(requests))
+	concurrency = max(1, min(concurrency, len(requests)))
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if err := ctx.Err(); err != nil {
+					results[i].Err = err
+					continue
+				}
+				request := requests[i]
+				token, err := client.TokenForTeamsUser(
+					ctx,
+					request.UserOID,
+					request.MSALToken,
+					opts...,
+				)
+				if err != nil {
+					results[i].Err = err
+					continue
+				}
+				results[i].Token = &token
+			}
+		}()
+	}
+	for i := range requests {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return results
+}
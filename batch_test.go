@@ -0,0 +1,210 @@
+package communicationidentity_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+)
+
+// rejects requests for the voip scope with an ACS error
+func batchHandler(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Scopes []string `json:"scopes"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	w.Header().Set("Content-Type", "application/json")
+	if body.Scopes[0] == string(ci.ScopeVoIP) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"error":{"code":"Forbidden","message":"voip disabled"}}`))
+		return
+	}
+	_ = json.NewEncoder(w).Encode(ci.CommunicationIdentityAccessToken{
+		Token:     "token-" + body.Scopes[0],
+		ExpiresOn: time.Now().Add(time.Hour).UTC(),
+	})
+}
+
+func TestIssueAccessTokenBatch(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(batchHandler))
+	tokens, err := client.IssueAccessTokenBatch(
+		context.Background(),
+		testIdentityID,
+		[]ci.TokenRequest{
+			{Scopes: []ci.Scope{ci.ScopeChat}},
+			{Scopes: []ci.Scope{ci.ScopeChatJoin}},
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 2 || tokens[0].Token != "token-chat" || tokens[1].Token != "token-chat.join" {
+		t.Errorf("tokens not aligned with requests: %+v", tokens)
+	}
+}
+
+func TestIssueAccessTokenBatchPartialFailure(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(batchHandler))
+	tokens, err := client.IssueAccessTokenBatch(
+		context.Background(),
+		testIdentityID,
+		[]ci.TokenRequest{
+			{Scopes: []ci.Scope{ci.ScopeVoIP}},
+			{Scopes: []ci.Scope{ci.ScopeChat}},
+		},
+	)
+	var multiErr *ci.MultiError
+	if !errors.As(err, &multiErr) {
+		t.Fatalf("expected MultiError, got %v", err)
+	}
+	if len(multiErr.Errors) != 1 || multiErr.Errors[0].Code != "Forbidden" {
+		t.Errorf("expected only the ACS error of the failed request, got %v", multiErr.Errors)
+	}
+	if len(multiErr.Causes) != 1 || !strings.Contains(multiErr.Causes[0].Error(), "requests[0]") {
+		t.Errorf("expected the cause of the failed request, got %v", multiErr.Causes)
+	}
+	if !errors.Is(err, &ci.CommunicationIdentityError{StatusCode: http.StatusForbidden}) {
+		t.Errorf("expected the status of the failed request to be kept, got %v", err)
+	}
+	if len(tokens) != 2 || tokens[0].Token != "" || tokens[1].Token != "token-chat" {
+		t.Errorf("tokens not aligned with requests: %+v", tokens)
+	}
+}
+
+func TestIssueAccessTokenBatchCompleteFailure(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(batchHandler))
+	tokens, err := client.IssueAccessTokenBatch(
+		context.Background(),
+		testIdentityID,
+		[]ci.TokenRequest{
+			{Scopes: []ci.Scope{ci.ScopeVoIP}},
+			{Scopes: []ci.Scope{ci.ScopeVoIP}},
+		},
+	)
+	var multiErr *ci.MultiError
+	if !errors.As(err, &multiErr) || len(multiErr.Errors) != 2 {
+		t.Fatalf("expected MultiError with 2 errors, got %v", err)
+	}
+	if tokens != nil {
+		t.Errorf("expected no tokens, got %+v", tokens)
+	}
+}
+
+func TestIssueAccessTokenBatchKeepsContextErrors(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(batchHandler))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := client.IssueAccessTokenBatch(
+		ctx,
+		testIdentityID,
+		[]ci.TokenRequest{{Scopes: []ci.Scope{ci.ScopeChat}}},
+	)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	var multiErr *ci.MultiError
+	if !errors.As(err, &multiErr) || len(multiErr.Errors) != 0 {
+		t.Errorf("expected no ACS errors for a cancelled batch, got %v", err)
+	}
+}
+
+func TestIssueAccessTokenBatchRejectsEmptyScopes(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(batchHandler))
+	_, err := client.IssueAccessTokenBatch(context.Background(), testIdentityID, []ci.TokenRequest{{}})
+	var validationErr *ci.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Errorf("expected ValidationError, got %v", err)
+	}
+}
+
+func TestTokenForTeamsUserBatch(t *testing.T) {
+	var apiVersion string
+	client := newTestClient(t, teamsTokenHandler(&apiVersion))
+	results := client.TokenForTeamsUserBatch(
+		context.Background(),
+		[]ci.TeamsUserExchangeRequest{
+			{UserOID: "oid-1", MSALToken: "msal-1"},
+			{UserOID: "oid-2", MSALToken: "msal-2"},
+			{UserOID: "oid-3", MSALToken: "msal-3"},
+		},
+		2,
+	)
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+	for i, result := range results {
+		if result.Err != nil || result.Token == nil || result.Token.Token != "acs-token" {
+			t.Errorf("unexpected result %d: %+v", i, result)
+		}
+	}
+}
+
+func TestTokenForTeamsUserBatchPartialFailure(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			UserID string `json:"userId"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body.UserID == "oid-bad" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":{"code":"InvalidAccessToken","message":"bad token"}}`))
+			return
+		}
+		teamsTokenHandler(new(string))(w, r)
+	}))
+	results := client.TokenForTeamsUserBatch(
+		context.Background(),
+		[]ci.TeamsUserExchangeRequest{
+			{UserOID: "oid-1", MSALToken: "msal-1"},
+			{UserOID: "oid-bad", MSALToken: "msal-bad"},
+		},
+		2,
+	)
+	if results[0].Err != nil || results[0].Token == nil {
+		t.Errorf("expected first exchange to succeed, got: %+v", results[0])
+	}
+	var identityErr *ci.CommunicationIdentityError
+	if results[1].Token != nil || !errors.As(results[1].Err, &identityErr) {
+		t.Fatalf("expected second exchange to fail, got: %+v", results[1])
+	}
+	if identityErr.ACSError.Code != "InvalidAccessToken" {
+		t.Errorf("unexpected ACS error: %+v", identityErr.ACSError)
+	}
+}
+
+func TestTokenForTeamsUserBatchCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	exchanges := 0
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		exchanges++
+		if exchanges == 2 {
+			cancel()
+		}
+		teamsTokenHandler(new(string))(w, r)
+	}))
+
+	requests := make([]ci.TeamsUserExchangeRequest, 5)
+	for i := range requests {
+		requests[i] = ci.TeamsUserExchangeRequest{UserOID: "oid", MSALToken: "msal"}
+	}
+	results := client.TokenForTeamsUserBatch(ctx, requests, 1)
+
+	if results[0].Err != nil || results[0].Token == nil || results[0].Token.Token != "acs-token" {
+		t.Errorf("expected exchange completed before cancellation to be kept, got: %+v", results[0])
+	}
+	for i, result := range results[2:] {
+		if result.Token != nil || !errors.Is(result.Err, context.Canceled) {
+			t.Errorf("expected result %d to be canceled, got: %+v", i+2, result)
+		}
+	}
+	if exchanges != 2 {
+		t.Errorf("expected no exchanges after cancellation, got %d", exchanges)
+	}
+}
@@ -0,0 +1,68 @@
+package communicationidentity
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// default limit of response bodies, ACS responses are a few kilobytes at most
+const defaultMaxResponseBodySize = 1 << 20
+
+// ErrResponseBodyTooLarge is returned for responses whose body exceeds the limit set with
+// [WithMaxResponseBodySize]
+var ErrResponseBodyTooLarge = errors.New("response body exceeds the size limit")
+
+// WithMaxResponseBodySize stops reading response bodies after n bytes, e.g. in case a misbehaving
+// proxy injects a large body, defaults to 1 MiB. Zero reads bodies of any size. Calls receiving a
+// larger body fail with [ErrResponseBodyTooLarge].
+func WithMaxResponseBodySize(n int64) ClientOption {
+	return func(options *clientOptions) error {
+		if n < 0 {
+			return fmt.Errorf("max response body size can not be negative, got %d", n)
+		}
+		options.maxResponseBodySize = &n
+		return nil
+	}
+}
+
+func (client CommunicationIdentityClient) maxResponseBodySize() int64 {
+	if client.options.maxResponseBodySize == nil {
+		return defaultMaxResponseBodySize
+	}
+	return *client.options.maxResponseBodySize
+}
+
+// limitedBody fails reads beyond its limit instead of cutting the body off silently, so the
+// decoding of a truncated body does not fail with a misleading syntax error
+type limitedBody struct {
+	io.ReadCloser
+	limit     int64
+	remaining int64
+}
+
+func newLimitedBody(body io.ReadCloser, limit int64) *limitedBody {
+	return &limitedBody{ReadCloser: body, limit: limit, remaining: limit}
+}
+
+func (body *limitedBody) Read(p []byte) (int, error) {
+	if body.remaining < 0 {
+		return 0, body.exceeded()
+	}
+	// one byte more than allowed tells a body of exactly the limit from a larger one
+	if int64(len(p)) > body.remaining+1 {
+		p = p[:body.remaining+1]
+	}
+	n, err := body.ReadCloser.Read(p)
+	if int64(n) > body.remaining {
+		n = int(body.remaining)
+		body.remaining = -1
+		return n, body.exceeded()
+	}
+	body.remaining -= int64(n)
+	return n, err
+}
+
+func (body *limitedBody) exceeded() error {
+	return fmt.Errorf("%w of %d bytes", ErrResponseBodyTooLarge, body.limit)
+}
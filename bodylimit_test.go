@@ -0,0 +1,71 @@
+package communicationidentity_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+)
+
+// paddedIdentityHandler answers with a new identity whose token pads the body to size bytes
+func paddedIdentityHandler(status int, size int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		result := ci.CommunicationIdentityAccessTokenResult{
+			AccessToken: ci.CommunicationIdentityAccessToken{
+				ExpiresOn: time.Now().Add(time.Hour).UTC(),
+			},
+			Identity: ci.CommunicationIdentity{ID: "identity"},
+		}
+		unpadded, _ := json.Marshal(result)
+		result.AccessToken.Token = strings.Repeat("x", size-len(unpadded))
+		body, _ := json.Marshal(result)
+		w.WriteHeader(status)
+		_, _ = w.Write(body)
+	}
+}
+
+func TestWithMaxResponseBodySize(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		size    int
+		opts    []ci.ClientOption
+		tooLong bool
+	}{
+		{"default limit", http.StatusCreated, 1 << 20, nil, false},
+		{"above default limit", http.StatusCreated, 1<<20 + 1, nil, true},
+		{"custom limit", http.StatusCreated, 512, []ci.ClientOption{
+			ci.WithMaxResponseBodySize(512),
+		}, false},
+		{"above custom limit", http.StatusCreated, 513, []ci.ClientOption{
+			ci.WithMaxResponseBodySize(512),
+		}, true},
+		{"error above custom limit", http.StatusBadRequest, 513, []ci.ClientOption{
+			ci.WithMaxResponseBodySize(512),
+		}, true},
+		{"unlimited", http.StatusCreated, 2 << 20, []ci.ClientOption{
+			ci.WithMaxResponseBodySize(0),
+		}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := newTestClient(t, paddedIdentityHandler(test.status, test.size), test.opts...)
+			_, err := client.CreateCommunicationIdentity(context.Background(), []string{"chat"}, nil)
+			if tooLong := errors.Is(err, ci.ErrResponseBodyTooLarge); tooLong != test.tooLong {
+				t.Errorf("expected too large to be %t, got: %v", test.tooLong, err)
+			}
+			if !test.tooLong && test.status == http.StatusCreated && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+
+	if _, err := ci.New(nil, testAccessKey, "", ci.WithMaxResponseBodySize(-1)); err == nil {
+		t.Error("expected a negative limit to be rejected")
+	}
+}
@@ -0,0 +1,368 @@
+package communicationidentity
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Strategy to pick the entry dropped when the token cache is full, see [WithCacheEvictionPolicy]
+type EvictionPolicy int
+
+const (
+	// drop the least recently added or used entry, default
+	EvictionPolicyLRU EvictionPolicy = iota
+	// drop the least frequently used entry, ties are broken by recency
+	EvictionPolicyLFU
+	// drop the entry closest to its expiry
+	EvictionPolicyTTL
+)
+
+func (policy EvictionPolicy) String() string {
+	switch policy {
+	case EvictionPolicyLRU:
+		return "LRU"
+	case EvictionPolicyLFU:
+		return "LFU"
+	case EvictionPolicyTTL:
+		return "TTL"
+	default:
+		return fmt.Sprintf("EvictionPolicy(%d)", int(policy))
+	}
+}
+
+// WithMaxCacheSize bounds the number of entries in the token cache, by default it is unbounded.
+// Once full, entries are dropped according to [WithCacheEvictionPolicy].
+func WithMaxCacheSize(n int) ClientOption {
+	return func(options *clientOptions) error {
+		if n <= 0 {
+			return fmt.Errorf("max cache size must be positive, got %d", n)
+		}
+		options.maxCacheSize = n
+		return nil
+	}
+}
+
+// WithCacheEvictionPolicy selects which entry is dropped from a full token cache,
+// only relevant in combination with [WithMaxCacheSize]
+func WithCacheEvictionPolicy(p EvictionPolicy) ClientOption {
+	return func(options *clientOptions) error {
+		switch p {
+		case EvictionPolicyLRU, EvictionPolicyLFU, EvictionPolicyTTL:
+			options.evictionPolicy = p
+			return nil
+		default:
+			return fmt.Errorf("unknown eviction policy: %v", p)
+		}
+	}
+}
+
+// Tokens keyed by an arbitrary key (usually the identity ID), optionally bounded in size.
+// Safe for concurrent use.
+//
+// LRU eviction is O(1), LFU and TTL eviction scan all entries.
+type tokenCache struct {
+	mu      sync.Mutex
+	now     func() time.Time
+	maxSize int
+	policy  EvictionPolicy
+	onEvict func(CommunicationIdentityAccessTokenResult)
+	// called for entries dropped without being returned, after onEvict for evicted ones
+	onDiscard func(CommunicationIdentityAccessTokenResult)
+	entries   map[string]*list.Element
+	// most recently added or used entries at the front
+	order *list.List
+}
+
+type tokenCacheEntry struct {
+	key    string
+	scopes string
+	result CommunicationIdentityAccessTokenResult
+	hits   int
+}
+
+func newTokenCache(
+	now func() time.Time,
+	maxSize int,
+	policy EvictionPolicy,
+	onEvict func(CommunicationIdentityAccessTokenResult),
+) *tokenCache {
+	return &tokenCache{
+		now:     now,
+		maxSize: maxSize,
+		policy:  policy,
+		onEvict: onEvict,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+func (cache *tokenCache) add(
+	key string,
+	scopes string,
+	result CommunicationIdentityAccessTokenResult,
+) {
+	var evicted, replaced *tokenCacheEntry
+	func() {
+		cache.mu.Lock()
+		defer cache.mu.Unlock()
+
+		if element, found := cache.entries[key]; found {
+			replaced = element.Value.(*tokenCacheEntry)
+			cache.removeLocked(element)
+		}
+		if cache.maxSize > 0 && len(cache.entries) >= cache.maxSize {
+			cache.dropExpiredLocked()
+		}
+		if cache.maxSize > 0 && len(cache.entries) >= cache.maxSize {
+			evicted = cache.evictLocked()
+		}
+		entry := &tokenCacheEntry{key: key, scopes: scopes, result: result}
+		cache.entries[key] = cache.order.PushFront(entry)
+	}()
+
+	if evicted != nil && cache.onEvict != nil {
+		cache.onEvict(evicted.result)
+	}
+	if evicted != nil {
+		cache.discard(evicted.result)
+	}
+	// the same result may be added again, e.g. by a retry
+	if replaced != nil && replaced.result != result {
+		cache.discard(replaced.result)
+	}
+}
+
+func (cache *tokenCache) evictLocked() *tokenCacheEntry {
+	victim := cache.order.Back()
+	switch cache.policy {
+	case EvictionPolicyLFU:
+		// walking from the back prefers the least recent entry on equal hits
+		for element := victim; element != nil; element = element.Prev() {
+			if element.Value.(*tokenCacheEntry).hits < victim.Value.(*tokenCacheEntry).hits {
+				victim = element
+			}
+		}
+	case EvictionPolicyTTL:
+		for element := victim; element != nil; element = element.Prev() {
+			expiresOn := element.Value.(*tokenCacheEntry).result.AccessToken.ExpiresOn
+			if expiresOn.Before(victim.Value.(*tokenCacheEntry).result.AccessToken.ExpiresOn) {
+				victim = element
+			}
+		}
+	}
+	if victim == nil {
+		return nil
+	}
+	return cache.removeLocked(victim)
+}
+
+// get returns the non-expired entry for key and marks it as used
+func (cache *tokenCache) get(key string) (CommunicationIdentityAccessTokenResult, bool) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	element, found := cache.entries[key]
+	if !found {
+		return CommunicationIdentityAccessTokenResult{}, false
+	}
+	entry := element.Value.(*tokenCacheEntry)
+	if !cache.now().Before(entry.result.AccessToken.ExpiresOn) {
+		cache.removeLocked(element)
+		cache.discard(entry.result)
+		return CommunicationIdentityAccessTokenResult{}, false
+	}
+	entry.hits++
+	cache.order.MoveToFront(element)
+	return entry.result, true
+}
+
+// take removes and returns a non-expired entry for the given scope set, expired entries
+// found along the way are dropped
+func (cache *tokenCache) take(scopes string) (CommunicationIdentityAccessTokenResult, bool) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	now := cache.now()
+	for element := cache.order.Front(); element != nil; {
+		next := element.Next()
+		entry := element.Value.(*tokenCacheEntry)
+		if !now.Before(entry.result.AccessToken.ExpiresOn) {
+			cache.removeLocked(element)
+			cache.discard(entry.result)
+		} else if entry.scopes == scopes {
+			cache.removeLocked(element)
+			return entry.result, true
+		}
+		element = next
+	}
+	return CommunicationIdentityAccessTokenResult{}, false
+}
+
+// dropExpiredLocked discards all expired entries, they are not reported as evicted
+func (cache *tokenCache) dropExpiredLocked() {
+	now := cache.now()
+	for element := cache.order.Front(); element != nil; {
+		next := element.Next()
+		if entry := element.Value.(*tokenCacheEntry); !now.Before(entry.result.AccessToken.ExpiresOn) {
+			cache.removeLocked(element)
+			cache.discard(entry.result)
+		}
+		element = next
+	}
+}
+
+// len counts held entries including expired ones not dropped yet
+func (cache *tokenCache) len() int {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	return len(cache.entries)
+}
+
+func (cache *tokenCache) flush() {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	for element := cache.order.Front(); element != nil; element = element.Next() {
+		cache.discard(element.Value.(*tokenCacheEntry).result)
+	}
+	clear(cache.entries)
+	cache.order.Init()
+}
+
+func (cache *tokenCache) discard(result CommunicationIdentityAccessTokenResult) {
+	if cache.onDiscard != nil {
+		cache.onDiscard(result)
+	}
+}
+
+func (cache *tokenCache) removeLocked(element *list.Element) *tokenCacheEntry {
+	entry := cache.order.Remove(element).(*tokenCacheEntry)
+	delete(cache.entries, entry.key)
+	return entry
+}
+
+// PrefetchToken creates count identities with tokens for the given scopes ahead of time,
+// e.g. during service startup. Subsequent calls to
+// [CommunicationIdentityClient.CreateCommunicationIdentity] with the same scopes and without
+// explicit expiry are served from this pool until it is empty, expired tokens are discarded.
+//
+// Identities created before a failing call remain in the pool.
+func (client CommunicationIdentityClient) PrefetchToken(
+	ctx context.Context,
+	scopes []Scope,
+	count int,
+	opts ...CallOption,
+) error {
+	if count <= 0 {
+		return &ValidationError{
+			Field: "count",
+			Value: strconv.Itoa(count),
+			Err:   fmt.Errorf("prefetch count must be positive"),
+		}
+	}
+	scope := scopeStrings(scopes)
+	key := scopeKey(scope)
+	for range count {
+		result, err := client.createCommunicationIdentity(ctx, scope, nil, applyCallOptions(opts))
+		if err != nil {
+			return err
+		}
+		result = client.secureResult(result)
+		client.state.prefetched.add(result.Identity.ID, key, result)
+	}
+	return nil
+}
+
+// FlushTokenCache drops all cached tokens, including identities created by
+// [CommunicationIdentityClient.PrefetchToken] and remembered by
+// [CommunicationIdentityClient.CreateCommunicationIdentityOrReuse] as well as the tokens of the
+// caches of [WithTokenCache] and [WithTeamsUserExchangeCache], so the next call is served by ACS.
+// Caches shared with other clients are cleared for them too.
+// Mostly useful to isolate test cases sharing a client.
+func (client CommunicationIdentityClient) FlushTokenCache() error {
+	client.state.prefetched.flush()
+	client.state.reusable.flush()
+	if client.options.tokenCache != nil {
+		client.options.tokenCache.Clear()
+	}
+	if client.options.teamsUserExchangeCache != nil {
+		client.options.teamsUserExchangeCache.Clear()
+	}
+	return nil
+}
+
+// Caches the results of [CommunicationIdentityClient.TokenForTeamsUser] per app registration,
+// Teams user OID and presented Teams token, configured through [WithTeamsUserExchangeCache]. Only
+// a caller presenting the same Teams token is served the cached ACS token, the Teams token is
+// kept as SHA-256 hash only. Least recently used entries are dropped once the cache is full,
+// expired tokens are never returned.
+//
+// NOTE: cached tokens are returned without presenting the Teams token to ACS again, a revoked
+// Teams token is only noticed once the cached ACS token expired.
+type TeamsUserExchangeCache struct {
+	cache *tokenCache
+}
+
+// NewInMemoryTeamsUserExchangeCache creates a cache holding at most maxSize tokens,
+// a non-positive maxSize means unbounded
+func NewInMemoryTeamsUserExchangeCache(maxSize int) *TeamsUserExchangeCache {
+	return &TeamsUserExchangeCache{
+		cache: newTokenCache(time.Now, max(maxSize, 0), EvictionPolicyLRU, nil),
+	}
+}
+
+// Get returns the cached, non-expired token exchanged for teamsToken of userOid by the app
+// registration azClientId
+func (cache *TeamsUserExchangeCache) Get(
+	azClientId string,
+	userOid string,
+	teamsToken string,
+) (CommunicationIdentityAccessToken, bool) {
+	result, found := cache.cache.get(teamsExchangeCacheKey(azClientId, userOid, teamsToken))
+	return result.AccessToken, found
+}
+
+// Set caches tok as exchanged for teamsToken of userOid by the app registration azClientId
+func (cache *TeamsUserExchangeCache) Set(
+	azClientId string,
+	userOid string,
+	teamsToken string,
+	tok CommunicationIdentityAccessToken,
+) {
+	cache.cache.add(
+		teamsExchangeCacheKey(azClientId, userOid, teamsToken),
+		"",
+		CommunicationIdentityAccessTokenResult{AccessToken: tok},
+	)
+}
+
+// Clear drops all cached tokens
+func (cache *TeamsUserExchangeCache) Clear() {
+	cache.cache.flush()
+}
+
+// teamsExchangeCacheKey hashes the Teams token, so the cache holds no usable Entra credential
+func teamsExchangeCacheKey(azClientId string, userOid string, teamsToken string) string {
+	tokenHash := sha256.Sum256([]byte(teamsToken))
+	return strconv.Quote(azClientId) + strconv.Quote(userOid) + hex.EncodeToString(tokenHash[:])
+}
+
+// WithTeamsUserExchangeCache serves [CommunicationIdentityClient.TokenForTeamsUser] from cache,
+// ACS is only called if no valid token is cached for the user and the presented Teams token
+func WithTeamsUserExchangeCache(cache *TeamsUserExchangeCache) ClientOption {
+	return func(options *clientOptions) error {
+		if cache == nil || cache.cache == nil {
+			return fmt.Errorf(
+				"teams user exchange cache must be created by NewInMemoryTeamsUserExchangeCache",
+			)
+		}
+		options.teamsUserExchangeCache = cache
+		return nil
+	}
+}
@@ -0,0 +1,100 @@
+package communicationidentity
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestTokenCacheEviction(t *testing.T) {
+	now := time.Date(2025, 6, 30, 12, 0, 0, 0, time.UTC)
+	entry := func(id string, expiresIn time.Duration) CommunicationIdentityAccessTokenResult {
+		return CommunicationIdentityAccessTokenResult{
+			AccessToken: CommunicationIdentityAccessToken{ExpiresOn: now.Add(expiresIn)},
+			Identity:    CommunicationIdentity{ID: id},
+		}
+	}
+
+	cases := []struct {
+		policy EvictionPolicy
+		want   string
+	}{
+		// "a" is the oldest entry, but was used more recently than "b"
+		{EvictionPolicyLRU, "b"},
+		// "a" and "c" were used, "b" never
+		{EvictionPolicyLFU, "b"},
+		// "c" expires first
+		{EvictionPolicyTTL, "c"},
+	}
+	for _, c := range cases {
+		t.Run(c.policy.String(), func(t *testing.T) {
+			var evicted []string
+			cache := newTokenCache(
+				func() time.Time { return now },
+				3,
+				c.policy,
+				func(result CommunicationIdentityAccessTokenResult) {
+					evicted = append(evicted, result.Identity.ID)
+				},
+			)
+			cache.add("a", "chat", entry("a", 3*time.Hour))
+			cache.add("b", "chat", entry("b", 2*time.Hour))
+			cache.add("c", "chat", entry("c", time.Hour))
+			cache.get("c")
+			cache.get("a")
+
+			cache.add("d", "chat", entry("d", 4*time.Hour))
+
+			if fmt.Sprint(evicted) != fmt.Sprint([]string{c.want}) {
+				t.Errorf("expected %q to be evicted, got %v", c.want, evicted)
+			}
+			if _, found := cache.get(c.want); found {
+				t.Errorf("evicted entry %q is still cached", c.want)
+			}
+		})
+	}
+}
+
+func TestTokenCacheUnboundedByDefault(t *testing.T) {
+	onEvict := func(CommunicationIdentityAccessTokenResult) { t.Error("unexpected eviction") }
+	cache := newTokenCache(time.Now, 0, EvictionPolicyLRU, onEvict)
+	for i := range 100 {
+		cache.add(fmt.Sprint(i), "chat", CommunicationIdentityAccessTokenResult{
+			AccessToken: CommunicationIdentityAccessToken{ExpiresOn: time.Now().Add(time.Hour)},
+			Identity:    CommunicationIdentity{ID: fmt.Sprint(i)},
+		})
+	}
+}
+
+func TestTokenCacheDropsExpiredEntriesBeforeEvicting(t *testing.T) {
+	now := time.Date(2025, 6, 30, 12, 0, 0, 0, time.UTC)
+	var evicted, discarded []string
+	cache := newTokenCache(
+		func() time.Time { return now },
+		2,
+		EvictionPolicyLRU,
+		func(result CommunicationIdentityAccessTokenResult) {
+			evicted = append(evicted, result.Identity.ID)
+		},
+	)
+	cache.onDiscard = func(result CommunicationIdentityAccessTokenResult) {
+		discarded = append(discarded, result.Identity.ID)
+	}
+	add := func(id string, expiresIn time.Duration) {
+		cache.add(id, "chat", CommunicationIdentityAccessTokenResult{
+			AccessToken: CommunicationIdentityAccessToken{ExpiresOn: now.Add(expiresIn)},
+			Identity:    CommunicationIdentity{ID: id},
+		})
+	}
+	add("valid", time.Hour)
+	add("expired", -time.Minute)
+	add("new", time.Hour)
+
+	if len(evicted) != 0 || fmt.Sprint(discarded) != "[expired]" {
+		t.Errorf("expected only the expired entry to be dropped, evicted %v, discarded %v",
+			evicted, discarded)
+	}
+	if cache.len() != 2 {
+		t.Errorf("expected 2 entries, got %d", cache.len())
+	}
+}
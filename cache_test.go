@@ -0,0 +1,153 @@
+package communicationidentity_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+)
+
+func TestPrefetchToken(t *testing.T) {
+	var calls atomic.Int32
+	client := newTestClient(t, createIdentityHandler(&calls))
+	ctx := context.Background()
+
+	if err := client.PrefetchToken(ctx, []ci.Scope{ci.ScopeVoIP, ci.ScopeChat}, 2); err != nil {
+		t.Fatal(err)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("expected 2 prefetch requests, got %d", calls.Load())
+	}
+
+	seen := map[string]bool{}
+	for range 2 {
+		result, err := client.CreateCommunicationIdentity(ctx, []string{"chat", "voip"}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen[result.Identity.ID] = true
+	}
+	if calls.Load() != 2 || len(seen) != 2 {
+		t.Fatalf("expected 2 distinct prefetched identities, got %v", seen)
+	}
+
+	if _, err := client.CreateCommunicationIdentity(ctx, []string{"chat", "voip"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected a live request once the pool is empty, got %d requests", calls.Load())
+	}
+}
+
+func TestPrefetchTokenNotUsedForDifferentScopesOrExpiry(t *testing.T) {
+	var calls atomic.Int32
+	client := newTestClient(t, createIdentityHandler(&calls))
+	ctx := context.Background()
+
+	if err := client.PrefetchToken(ctx, []ci.Scope{ci.ScopeChat}, 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CreateCommunicationIdentity(ctx, []string{"voip"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	expiry := int32(120)
+	if _, err := client.CreateCommunicationIdentity(ctx, []string{"chat"}, &expiry); err != nil {
+		t.Fatal(err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected live requests for non-matching calls, got %d requests", calls.Load())
+	}
+}
+
+func TestPrefetchTokenRejectsNonPositiveCount(t *testing.T) {
+	client := newTestClient(t, createIdentityHandler(new(atomic.Int32)))
+	if err := client.PrefetchToken(context.Background(), []ci.Scope{ci.ScopeChat}, 0); err == nil {
+		t.Error("expected error for zero count")
+	}
+}
+
+func TestFlushTokenCache(t *testing.T) {
+	var calls atomic.Int32
+	client := newTestClient(t, createIdentityHandler(&calls))
+	ctx := context.Background()
+
+	if err := client.PrefetchToken(ctx, []ci.Scope{ci.ScopeChat}, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.FlushTokenCache(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CreateCommunicationIdentity(ctx, []string{"chat"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("expected a live request after flushing the cache, got %d requests", calls.Load())
+	}
+}
+
+func TestFlushTokenCacheClearsConfiguredCaches(t *testing.T) {
+	tokenCache := ci.NewInMemoryTokenCache(0)
+	exchangeCache := ci.NewInMemoryTeamsUserExchangeCache(0)
+	client := newTestClient(
+		t,
+		createIdentityHandler(new(atomic.Int32)),
+		ci.WithTokenCache(tokenCache),
+		ci.WithTeamsUserExchangeCache(exchangeCache),
+	)
+	_, err := client.CreateCommunicationIdentity(
+		context.Background(), []string{"chat"}, nil, ci.WithTokenCacheKey("user-1"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exchangeCache.Set("test-client-id", "oid", "teams", ci.CommunicationIdentityAccessToken{
+		ExpiresOn: time.Now().Add(time.Hour),
+	})
+
+	if err := client.FlushTokenCache(); err != nil {
+		t.Fatal(err)
+	}
+	if _, found := tokenCache.Get("user-1"); found || tokenCache.Len() != 0 {
+		t.Errorf("expected the token cache to be empty, got %d entries", tokenCache.Len())
+	}
+	if _, found := exchangeCache.Get("test-client-id", "oid", "teams"); found {
+		t.Error("expected the teams user exchange cache to be empty")
+	}
+}
+
+func TestMaxCacheSizeRecordsEvictions(t *testing.T) {
+	var calls atomic.Int32
+	var records []ci.TokenIssuanceRecord
+	client := newTestClient(t, createIdentityHandler(&calls),
+		ci.WithMaxCacheSize(2),
+		ci.WithCacheEvictionPolicy(ci.EvictionPolicyLRU),
+		ci.WithTokenIssuanceRecorder(func(record ci.TokenIssuanceRecord) {
+			records = append(records, record)
+		}),
+	)
+
+	if err := client.PrefetchToken(context.Background(), []ci.Scope{ci.ScopeChat}, 3); err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("expected exactly one eviction record, got %v", records)
+	}
+	if records[0].Kind != ci.TokenIssuanceKindEvicted || records[0].IdentityID != "identity-1" {
+		t.Errorf("unexpected eviction record: %+v", records[0])
+	}
+}
+
+func TestCacheOptionsValidation(t *testing.T) {
+	for name, opt := range map[string]ci.ClientOption{
+		"zero size":      ci.WithMaxCacheSize(0),
+		"unknown policy": ci.WithCacheEvictionPolicy(ci.EvictionPolicy(42)),
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ci.New(nil, testAccessKey, "", opt); err == nil {
+				t.Error("expected option to be rejected")
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package communicationidentity
+
+import (
+	"net/http"
+	"time"
+)
+
+const msIdempotencyKeyHeader = "x-ms-idempotency-key"
+
+// Optional per call configuration accepted by client methods, overriding the defaults of the
+// client for a single call
+type CallOption func(*callOptions)
+
+type callOptions struct {
+	idempotencyKey  string
+	clientRequestID string
+	// replaces WithTimeout if positive
+	timeout time.Duration
+	headers http.Header
+	// set by WithRequestLog, the log itself is attached by CallWithResponse
+	recordRequestLog bool
+	requestLog       *RequestLog
+	// see WithTokenCacheKey
+	tokenCacheKey string
+}
+
+func applyCallOptions(opts []CallOption) callOptions {
+	var options callOptions
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&options)
+		}
+	}
+	return options
+}
+
+// WithIdempotencyKey sends key as `x-ms-idempotency-key` header, so ACS creates the resource
+// only once even if the same call is sent multiple times. The key is kept for all retries of
+// a call, use a new key per logical operation, see [GenerateIdempotencyKey].
+func WithIdempotencyKey(key string) CallOption {
+	return func(options *callOptions) {
+		options.idempotencyKey = key
+	}
+}
+
+// GenerateIdempotencyKey returns a new random key for [WithIdempotencyKey]
+func GenerateIdempotencyKey() string {
+	return newUUID()
+}
+
+// WithCallTimeout limits the call to d like [WithTimeout] does for all calls, replacing the
+// timeout of the client. Non-positive durations keep the timeout of the client.
+func WithCallTimeout(d time.Duration) CallOption {
+	return func(options *callOptions) {
+		options.timeout = d
+	}
+}
+
+// WithCallHeader sets the request header key to value, e.g. for a proxy in front of ACS. Headers
+// set by other options take precedence, the signature headers `x-ms-date`,
+// `x-ms-content-sha256` and `Authorization` can not be replaced.
+func WithCallHeader(key, value string) CallOption {
+	return func(options *callOptions) {
+		if options.headers == nil {
+			options.headers = http.Header{}
+		}
+		options.headers.Set(key, value)
+	}
+}
+
+// WithCallClientRequestID is [WithClientRequestID], named like the other per call overrides
+func WithCallClientRequestID(id string) CallOption {
+	return WithClientRequestID(id)
+}
+
+func (options callOptions) applyHeaders(request *http.Request) {
+	for key, values := range options.headers {
+		switch key {
+		case http.CanonicalHeaderKey(msDateHeader),
+			http.CanonicalHeaderKey(msContentHashHeader),
+			http.CanonicalHeaderKey(msAuthHeader):
+			continue
+		}
+		request.Header[key] = values
+	}
+	if options.idempotencyKey != "" {
+		request.Header.Set(msIdempotencyKeyHeader, options.idempotencyKey)
+	}
+}
@@ -0,0 +1,92 @@
+package communicationidentity_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+)
+
+func TestWithIdempotencyKey(t *testing.T) {
+	var calls atomic.Int32
+	var received string
+	handler := createIdentityHandler(&calls)
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get("x-ms-idempotency-key")
+		handler(w, r)
+	})
+
+	key := ci.GenerateIdempotencyKey()
+	_, err := client.CreateCommunicationIdentity(
+		context.Background(),
+		[]string{"chat"},
+		nil,
+		ci.WithIdempotencyKey(key),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if received != key {
+		t.Errorf("expected idempotency key %q, got %q", key, received)
+	}
+}
+
+func TestGenerateIdempotencyKey(t *testing.T) {
+	uuidPattern := regexp.MustCompile(
+		`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`,
+	)
+	first, second := ci.GenerateIdempotencyKey(), ci.GenerateIdempotencyKey()
+	if !uuidPattern.MatchString(first) {
+		t.Errorf("expected a version 4 UUID, got %q", first)
+	}
+	if first == second {
+		t.Error("expected distinct keys")
+	}
+}
+
+func TestWithCallTimeout(t *testing.T) {
+	client := newTestClient(t, slowHandler(time.Minute), ci.WithTimeout(time.Hour))
+	_, err := client.CreateCommunicationIdentity(
+		context.Background(),
+		[]string{"chat"},
+		nil,
+		ci.WithCallTimeout(20*time.Millisecond),
+	)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the call timeout to replace the client timeout, got %v", err)
+	}
+}
+
+func TestWithCallHeader(t *testing.T) {
+	var received http.Header
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	err := client.DeleteCommunicationIdentity(
+		context.Background(),
+		testIdentityID,
+		ci.WithCallHeader("x-tenant", "contoso"),
+		ci.WithCallHeader("x-ms-date", "Thu, 01 Jan 1970 00:00:00 GMT"),
+		ci.WithCallClientRequestID("request-1"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if received.Get("x-tenant") != "contoso" {
+		t.Errorf("expected the call header, got %v", received)
+	}
+	if strings.HasPrefix(received.Get("x-ms-date"), "Thu, 01 Jan 1970") {
+		t.Error("expected the signed date not to be replaced")
+	}
+	if received.Get("x-ms-client-request-id") != "request-1" {
+		t.Errorf("expected the client request id, got %v", received)
+	}
+}
@@ -0,0 +1,150 @@
+package communicationidentity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without sending a request while the circuit breaker set with
+// [WithCircuitBreaker] is open
+var ErrCircuitOpen = errors.New("circuit breaker is open, ACS is failing")
+
+// WithCircuitBreaker stops sending requests after threshold consecutive failed attempts, i.e.
+// transport errors, 429 and 5xx responses. The open circuit fails calls with [ErrCircuitOpen] for
+// timeout, then a single probe request is let through: its success closes the circuit, its
+// failure opens it for another timeout. The circuit is shared by all copies of the client.
+func WithCircuitBreaker(threshold int, timeout time.Duration) ClientOption {
+	return func(options *clientOptions) error {
+		if threshold < 1 {
+			return fmt.Errorf("circuit breaker threshold must be at least 1, got %d", threshold)
+		}
+		if timeout <= 0 {
+			return fmt.Errorf("circuit breaker timeout must be positive, got %v", timeout)
+		}
+		options.circuitBreaker = &circuitBreakerPolicy{threshold: threshold, timeout: timeout}
+		return nil
+	}
+}
+
+type circuitBreakerPolicy struct {
+	threshold int
+	timeout   time.Duration
+}
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+type circuitBreaker struct {
+	policy circuitBreakerPolicy
+	now    func() time.Time
+
+	mu       sync.Mutex
+	state    circuitState
+	failures int
+	openedAt time.Time
+	// whether the probe of the half-open circuit is in flight
+	probing bool
+}
+
+func newCircuitBreaker(policy *circuitBreakerPolicy, now func() time.Time) *circuitBreaker {
+	if policy == nil {
+		return nil
+	}
+	return &circuitBreaker{policy: *policy, now: now}
+}
+
+// allow admits an attempt or returns ErrCircuitOpen, probe reports whether the attempt is the
+// probe of the half-open circuit
+func (breaker *circuitBreaker) allow() (probe bool, err error) {
+	breaker.mu.Lock()
+	defer breaker.mu.Unlock()
+	switch breaker.state {
+	case circuitOpen:
+		if breaker.now().Sub(breaker.openedAt) < breaker.policy.timeout {
+			return false, ErrCircuitOpen
+		}
+		breaker.state = circuitHalfOpen
+		breaker.probing = true
+		return true, nil
+	case circuitHalfOpen:
+		if breaker.probing {
+			return false, ErrCircuitOpen
+		}
+		breaker.probing = true
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// record the outcome of an attempt admitted by allow
+func (breaker *circuitBreaker) record(probe bool, failed bool) {
+	breaker.mu.Lock()
+	defer breaker.mu.Unlock()
+	switch breaker.state {
+	case circuitHalfOpen:
+		// attempts admitted before the circuit opened do not decide the probe
+		if !probe {
+			return
+		}
+		breaker.probing = false
+		if failed {
+			breaker.openLocked()
+			return
+		}
+		breaker.state = circuitClosed
+		breaker.failures = 0
+	case circuitClosed:
+		if !failed {
+			breaker.failures = 0
+			return
+		}
+		breaker.failures++
+		if breaker.failures >= breaker.policy.threshold {
+			breaker.openLocked()
+		}
+	}
+}
+
+func (breaker *circuitBreaker) openLocked() {
+	breaker.state = circuitOpen
+	breaker.openedAt = breaker.now()
+	breaker.failures = 0
+}
+
+// isEndpointFailure reports whether an attempt failed because of ACS rather than the caller
+func isEndpointFailure(ctx context.Context, response *http.Response, err error) bool {
+	if err != nil {
+		return ctx.Err() == nil
+	}
+	return response.StatusCode == http.StatusTooManyRequests ||
+		response.StatusCode >= http.StatusInternalServerError
+}
+
+// guardedAttempt sends request once through the circuit breaker of the client, if any
+func (client CommunicationIdentityClient) guardedAttempt(
+	ctx context.Context,
+	request *http.Request,
+	callOpts callOptions,
+) (*http.Response, error) {
+	breaker := client.state.breaker
+	if breaker == nil {
+		return client.attempt(request, callOpts)
+	}
+	probe, err := breaker.allow()
+	if err != nil {
+		return nil, err
+	}
+	response, err := client.attempt(request, callOpts)
+	breaker.record(probe, isEndpointFailure(ctx, response, err))
+	return response, err
+}
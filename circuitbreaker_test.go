@@ -0,0 +1,110 @@
+package communicationidentity_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+	"github.com/jls-ch/azure-communication-identity-go/communicationidentitytest"
+)
+
+func TestWithCircuitBreaker(t *testing.T) {
+	var requests atomic.Int32
+	var status atomic.Int32
+	status.Store(http.StatusServiceUnavailable)
+	create := createIdentityHandler(new(atomic.Int32))
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if code := int(status.Load()); code != http.StatusCreated {
+			w.WriteHeader(code)
+			return
+		}
+		create(w, r)
+	}
+	clock := communicationidentitytest.NewMockClock(time.Now())
+	client := newTestClient(t, handler,
+		ci.WithTimeSource(clock),
+		ci.WithCircuitBreaker(2, time.Minute),
+	)
+	ctx := context.Background()
+	call := func() error {
+		_, err := client.CreateCommunicationIdentity(ctx, []string{"chat"}, nil)
+		return err
+	}
+	expectRequests := func(want int32) {
+		t.Helper()
+		if got := requests.Load(); got != want {
+			t.Fatalf("expected %d requests, got %d", want, got)
+		}
+	}
+
+	// closed: failures are counted until the threshold
+	for range 2 {
+		if err := call(); err == nil || errors.Is(err, ci.ErrCircuitOpen) {
+			t.Fatalf("expected the ACS error, got: %v", err)
+		}
+	}
+	expectRequests(2)
+
+	// open: calls fail without a request
+	if err := call(); !errors.Is(err, ci.ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got: %v", err)
+	}
+	expectRequests(2)
+
+	// half-open: the failed probe opens the circuit again
+	clock.Advance(time.Minute)
+	if err := call(); err == nil || errors.Is(err, ci.ErrCircuitOpen) {
+		t.Fatalf("expected the probe to fail with the ACS error, got: %v", err)
+	}
+	expectRequests(3)
+	clock.Advance(time.Minute - time.Second)
+	if err := call(); !errors.Is(err, ci.ErrCircuitOpen) {
+		t.Fatalf("expected the failed probe to reset the timeout, got: %v", err)
+	}
+	expectRequests(3)
+
+	// half-open: the successful probe closes the circuit
+	status.Store(http.StatusCreated)
+	clock.Advance(time.Second)
+	for range 3 {
+		if err := call(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expectRequests(6)
+}
+
+func TestCircuitBreakerIgnoresClientErrors(t *testing.T) {
+	var requests atomic.Int32
+	failing := errorHandler(http.StatusBadRequest, "", `{"error":{"code":"InvalidScope"}}`)
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		failing(w, r)
+	}, ci.WithCircuitBreaker(1, time.Minute))
+
+	for range 3 {
+		_, err := client.CreateCommunicationIdentity(context.Background(), []string{"chat"}, nil)
+		if errors.Is(err, ci.ErrCircuitOpen) {
+			t.Fatal("expected 400 responses to keep the circuit closed")
+		}
+	}
+	if requests.Load() != 3 {
+		t.Errorf("expected 3 requests, got %d", requests.Load())
+	}
+}
+
+func TestWithCircuitBreakerValidation(t *testing.T) {
+	for _, opt := range []ci.ClientOption{
+		ci.WithCircuitBreaker(0, time.Minute),
+		ci.WithCircuitBreaker(1, 0),
+	} {
+		if _, err := ci.New(nil, testAccessKey, "", opt); err == nil {
+			t.Error("expected the circuit breaker option to be rejected")
+		}
+	}
+}
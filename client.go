@@ -0,0 +1,55 @@
+package communicationidentity
+
+import (
+	"context"
+	"io"
+)
+
+// Client is the set of ACS operations of [CommunicationIdentityClient], for code that should not
+// depend on the concrete client, e.g. to inject a fake in unit tests (see NewFakeClient in the
+// communicationidentitytest package). Helpers built on top of these operations, such as token
+// caches and refreshes, still require the concrete client.
+type Client interface {
+	IdentityClient
+	TokenForTeamsUserWithADAL(
+		ctx context.Context,
+		userOid string,
+		adalToken string,
+		opts ...CallOption,
+	) (CommunicationIdentityAccessToken, error)
+	TokenForTeamsUserOrCreate(
+		ctx context.Context,
+		options TokenForTeamsUserOrCreateOptions,
+		opts ...CallOption,
+	) (CommunicationIdentityAccessTokenResult, error)
+	IssueShortLivedAccessToken(
+		ctx context.Context,
+		identityID string,
+		scopes []Scope,
+		opts ...CallOption,
+	) (CommunicationIdentityAccessToken, error)
+	IssueAccessTokenBatch(
+		ctx context.Context,
+		identityID string,
+		requests []TokenRequest,
+		opts ...CallOption,
+	) ([]CommunicationIdentityAccessToken, error)
+	TokenForTeamsUserStream(
+		ctx context.Context,
+		userOid string,
+		msalToken string,
+		w io.Writer,
+		opts ...CallOption,
+	) error
+	TokenForTeamsUserBatch(
+		ctx context.Context,
+		requests []TeamsUserExchangeRequest,
+		concurrency int,
+		opts ...CallOption,
+	) []TeamsUserTokenExchangeResult
+	RotateAccessKey(key string) error
+	FlushTokenCache() error
+	Close() error
+}
+
+var _ Client = CommunicationIdentityClient{}
@@ -0,0 +1,54 @@
+package communicationidentity
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+const msClientRequestIDHeader = "x-ms-client-request-id"
+
+// Context key of a client request id, a string, taking precedence over [WithClientRequestID] and
+// [WithDefaultClientRequestID]:
+//
+//	ctx = context.WithValue(ctx, ClientRequestIDKey{}, requestID)
+type ClientRequestIDKey struct{}
+
+// WithClientRequestID sends id as `x-ms-client-request-id` header, which ACS echoes back, to
+// correlate the call with traces of the caller. The id is kept for all retries of the call.
+func WithClientRequestID(id string) CallOption {
+	return func(options *callOptions) {
+		options.clientRequestID = id
+	}
+}
+
+// WithDefaultClientRequestID sends an id returned by gen as `x-ms-client-request-id` header
+// with every call not given one through [WithClientRequestID] or [ClientRequestIDKey], e.g. a
+// new UUID per call
+func WithDefaultClientRequestID(gen func() string) ClientOption {
+	return func(options *clientOptions) error {
+		if gen == nil {
+			return fmt.Errorf("client request id generator can not be nil")
+		}
+		options.clientRequestIDGenerator = gen
+		return nil
+	}
+}
+
+// setClientRequestID sets the client request id of the call on request, if there is one
+func (client CommunicationIdentityClient) setClientRequestID(
+	ctx context.Context,
+	request *http.Request,
+	callOpts callOptions,
+) {
+	id, _ := ctx.Value(ClientRequestIDKey{}).(string)
+	if id == "" {
+		id = callOpts.clientRequestID
+	}
+	if id == "" && client.options.clientRequestIDGenerator != nil {
+		id = client.options.clientRequestIDGenerator()
+	}
+	if id != "" {
+		request.Header.Set(msClientRequestIDHeader, id)
+	}
+}
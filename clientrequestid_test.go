@@ -0,0 +1,69 @@
+package communicationidentity_test
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+)
+
+func TestClientRequestID(t *testing.T) {
+	var received []string
+	create := createIdentityHandler(new(atomic.Int32))
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header.Get("x-ms-client-request-id"))
+		create(w, r)
+	}, ci.WithDefaultClientRequestID(func() string { return "generated" }))
+
+	background := context.Background()
+	withKey := context.WithValue(background, ci.ClientRequestIDKey{}, "from-context")
+	for _, c := range []struct {
+		ctx  context.Context
+		opts []ci.CallOption
+	}{
+		{background, nil},
+		{background, []ci.CallOption{ci.WithClientRequestID("per-call")}},
+		{withKey, []ci.CallOption{ci.WithClientRequestID("per-call")}},
+	} {
+		if _, err := client.CreateCommunicationIdentity(
+			c.ctx, []string{"chat"}, nil, c.opts...,
+		); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []string{"generated", "per-call", "from-context"}
+	if len(received) != len(want) {
+		t.Fatalf("expected %v, got %v", want, received)
+	}
+	for i := range want {
+		if received[i] != want[i] {
+			t.Errorf("request %d: expected client request id %q, got %q", i, want[i], received[i])
+		}
+	}
+}
+
+func TestClientRequestIDIsOptional(t *testing.T) {
+	var received []string
+	create := createIdentityHandler(new(atomic.Int32))
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header.Values("x-ms-client-request-id")...)
+		create(w, r)
+	})
+	if _, err := client.CreateCommunicationIdentity(
+		context.Background(), []string{"chat"}, nil,
+	); err != nil {
+		t.Fatal(err)
+	}
+	if len(received) != 0 {
+		t.Errorf("expected no client request id, got %v", received)
+	}
+}
+
+func TestWithDefaultClientRequestIDRejectsNil(t *testing.T) {
+	if _, err := ci.New(nil, testAccessKey, "", ci.WithDefaultClientRequestID(nil)); err == nil {
+		t.Error("expected a nil generator to be rejected")
+	}
+}
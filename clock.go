@@ -0,0 +1,43 @@
+package communicationidentity
+
+import (
+	"fmt"
+	"time"
+)
+
+// Source of the current time used by the client, e.g. for the `x-ms-date` request header
+// and for waiting on timers. Can be replaced through [WithTimeSource] for deterministic tests.
+//
+// see also: MockClock in the communicationidentitytest package
+type TimeSource interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type systemTimeSource struct{}
+
+func (systemTimeSource) Now() time.Time {
+	return time.Now()
+}
+
+func (systemTimeSource) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// WithTimeSource replaces the system clock used by the client
+func WithTimeSource(ts TimeSource) ClientOption {
+	return func(options *clientOptions) error {
+		if ts == nil {
+			return fmt.Errorf("time source can not be nil")
+		}
+		options.timeSource = ts
+		return nil
+	}
+}
+
+func (client CommunicationIdentityClient) timeSource() TimeSource {
+	if client.options.timeSource == nil {
+		return systemTimeSource{}
+	}
+	return client.options.timeSource
+}
@@ -0,0 +1,94 @@
+package communicationidentity
+
+import (
+	"context"
+	"errors"
+	"runtime"
+)
+
+// ErrClientClosed is returned by the client methods sending requests to ACS once the client was
+// closed, see [CommunicationIdentityClient.Close]
+var ErrClientClosed = errors.New("communication identity client is closed")
+
+// Close stops the background work of the client, i.e. the refresh of [WithAutoRefresh] and the
+// watch of [NewFromConnectionStringWithRotation], waits for it to finish and overwrites the
+// decoded access key with zeros. Copies of the client share this state, closing one closes all
+// of them. Requests of later calls fail with [ErrClientClosed], calls still in flight must be
+// finished before Close is called. Closing a closed client does nothing. None of these steps
+// fails at the moment, the error is reserved for background work whose shutdown can fail.
+//
+// The background work of clients that become unreachable without being closed is stopped by a
+// finalizer, which logs a warning but leaves the keys alone. This is a safety net only: the
+// garbage collector runs finalizers at an unspecified time or not at all, e.g. before the program
+// exits, so call Close explicitly.
+//
+// Only the key buffers owned by the client are zeroed, best effort: the key string passed to
+// [New], copies made by the runtime or the caller and the keys of a [RotatingKeyManager], which
+// may be shared with other clients, are not overwritten.
+func (client CommunicationIdentityClient) Close() error {
+	if client.state == nil {
+		return nil
+	}
+	client.state.closeOnce.Do(func() {
+		client.state.closed.Store(true)
+		client.stopBackground()
+
+		clear(client.decodedAcsSecret)
+		if rotated := client.state.rotatedKey.Load(); rotated != nil {
+			clear(*rotated)
+		}
+	})
+	return nil
+}
+
+// clientFinalizer is referenced only by the copies of a client returned by New, not by its
+// background work or its state, so it becomes unreachable once the application dropped the
+// client even while background work keeps the state alive
+type clientFinalizer struct {
+	// the client without finalizer, referencing it would keep clientFinalizer reachable
+	client CommunicationIdentityClient
+}
+
+// goBackground runs fn with a context that is done once the client is closed, Close waits for fn
+// to return. fn must not reference the finalizer of the client, see clientFinalizer.
+func (client CommunicationIdentityClient) goBackground(fn func(ctx context.Context)) {
+	client.state.background.Add(1)
+	go func() {
+		defer client.state.background.Done()
+		fn(client.state.backgroundCtx)
+	}()
+}
+
+// stopBackground cancels the background work of the client and waits for it to finish
+func (client CommunicationIdentityClient) stopBackground() {
+	client.state.cancelBackground()
+	client.state.background.Wait()
+}
+
+// withoutFinalizer returns a copy of client for background work, which must not keep the
+// finalizer reachable
+func (client CommunicationIdentityClient) withoutFinalizer() CommunicationIdentityClient {
+	client.finalizer = nil
+	return client
+}
+
+// withFinalizer returns client with a finalizer stopping its background work, see Close
+func (client CommunicationIdentityClient) withFinalizer() CommunicationIdentityClient {
+	finalizer := &clientFinalizer{client: client}
+	runtime.SetFinalizer(finalizer, (*clientFinalizer).finalize)
+	client.finalizer = finalizer
+	return client
+}
+
+func (finalizer *clientFinalizer) finalize() {
+	client := finalizer.client
+	if client.state.closed.Load() {
+		return
+	}
+	client.logger().Warn(
+		"ACS client was garbage collected without being closed, stopping its background work",
+	)
+	// key material may be shared with live clients, e.g. through a RotatingKeyManager, and is not
+	// zeroed. Waiting for background work must not block the finalizer goroutine.
+	go client.stopBackground()
+}
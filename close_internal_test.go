@@ -0,0 +1,138 @@
+package communicationidentity
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCloseZeroesAccessKeys(t *testing.T) {
+	client, err := New(nil, "c2VjcmV0", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := client.decodedAcsSecret
+	if err := client.RotateAccessKey("cm90YXRlZA=="); err != nil {
+		t.Fatal(err)
+	}
+	rotated := *client.state.rotatedKey.Load()
+
+	if err := client.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for name, key := range map[string][]byte{"original": original, "rotated": rotated} {
+		if len(key) == 0 || !bytes.Equal(key, make([]byte, len(key))) {
+			t.Errorf("expected the %s key to be zeroed, got %q", name, key)
+		}
+	}
+}
+
+// warnings leaves out the startup line logged by New
+var warnings = &slog.HandlerOptions{Level: slog.LevelWarn}
+
+// logLines passes each log line to the test, the finalizer logs from another goroutine
+type logLines chan string
+
+func (lines logLines) Write(p []byte) (int, error) {
+	lines <- string(p)
+	return len(p), nil
+}
+
+func TestFinalizerStopsBackgroundWorkOfUnreachableClient(t *testing.T) {
+	lines := make(logLines, 1)
+	manager := &RotatingKeyManager{}
+	refresh := func(context.Context, string) (CommunicationIdentityAccessTokenResult, error) {
+		return CommunicationIdentityAccessTokenResult{}, nil
+	}
+	dropped, err := New(
+		nil,
+		"c2VjcmV0",
+		"",
+		WithLogger(slog.New(slog.NewTextHandler(lines, warnings))),
+		WithRotatingKeyManager(manager),
+		WithTokenCache(NewInMemoryTokenCache(0)),
+		WithAutoRefresh(time.Hour, refresh),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	live, err := New(nil, "c2VjcmV0", "", WithRotatingKeyManager(manager))
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, key := dropped.state, dropped.decodedAcsSecret
+	dropped = CommunicationIdentityClient{}
+
+	deadline := time.After(5 * time.Second)
+	for warned := false; !warned; {
+		runtime.GC()
+		select {
+		case line := <-lines:
+			if !strings.Contains(line, "garbage collected without being closed") {
+				t.Errorf("expected a warning, got %q", line)
+			}
+			warned = true
+		case <-deadline:
+			t.Fatal("expected the finalizer to run")
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		state.background.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-deadline:
+		t.Fatal("expected the background refresh to stop")
+	}
+	if string(key) != "secret" || string(live.accessKey()) != "secret" {
+		t.Errorf("expected the finalizer to leave the keys alone, got %q and %q",
+			key, live.accessKey())
+	}
+}
+
+func TestFinalizerSkipsClosedClient(t *testing.T) {
+	lines := make(logLines, 1)
+	client, err := New(nil, "c2VjcmV0", "", WithLogger(slog.New(slog.NewTextHandler(lines, warnings))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Close(); err != nil {
+		t.Fatal(err)
+	}
+	client.finalizer.finalize()
+	select {
+	case line := <-lines:
+		t.Errorf("expected no warning for a closed client, got %q", line)
+	default:
+	}
+}
+
+func TestCloseKeepsKeysOfSharedKeyManager(t *testing.T) {
+	manager := &RotatingKeyManager{}
+	closed, err := New(nil, "c2VjcmV0", "", WithRotatingKeyManager(manager))
+	if err != nil {
+		t.Fatal(err)
+	}
+	open, err := New(nil, "c2VjcmV0", "", WithRotatingKeyManager(manager))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := closed.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if key := open.accessKey(); string(key) != "secret" {
+		t.Errorf("expected the shared key to be kept, got %q", key)
+	}
+	if !bytes.Equal(closed.decodedAcsSecret, make([]byte, len("secret"))) {
+		t.Errorf("expected the key owned by the closed client to be zeroed")
+	}
+}
@@ -0,0 +1,37 @@
+package communicationidentity_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+)
+
+func TestCallsFailAfterClose(t *testing.T) {
+	var calls atomic.Int32
+	client := newTestClient(t, createIdentityHandler(&calls))
+	ctx := context.Background()
+
+	// closing a copy closes the client it was copied from
+	closed := client
+	if err := closed.Close(); err != nil {
+		t.Fatal(err)
+	}
+	_, err := client.CreateCommunicationIdentity(ctx, []string{"chat"}, nil)
+	if !errors.Is(err, ci.ErrClientClosed) {
+		t.Errorf("expected ErrClientClosed, got %v", err)
+	}
+	if err := client.DeleteCommunicationIdentity(ctx, testIdentityID); !errors.Is(
+		err, ci.ErrClientClosed,
+	) {
+		t.Errorf("expected ErrClientClosed, got %v", err)
+	}
+	if calls.Load() != 0 {
+		t.Errorf("expected no requests after Close, got %d", calls.Load())
+	}
+	if err := client.Close(); err != nil {
+		t.Errorf("expected closing again to succeed, got %v", err)
+	}
+}
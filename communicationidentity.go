@@ -8,17 +8,16 @@ package communicationidentity
 import (
 	"bytes"
 	"context"
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
-	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
-	"unicode/utf8"
 )
 
 // REST client to perform calls to 'Azure Communication Identity' endpoints
@@ -27,33 +26,138 @@ type CommunicationIdentityClient struct {
 	acsEndpoint      *url.URL
 	decodedAcsSecret []byte
 	azClientId       string
+	options          clientOptions
+	requestSigner    RequestSigner
+	state            *clientState
+	// closes state once no copy of the client returned by New is reachable, see Close
+	finalizer *clientFinalizer
+}
+
+// mutable state shared between copies of a client
+type clientState struct {
+	prefetched *tokenCache
+	// identities by idempotency key, see CreateCommunicationIdentityOrReuse
+	reusable  *tokenCache
+	creations flightGroup[CommunicationIdentityAccessTokenResult]
+	// nil unless WithFastStart is set
+	dnsWarmup      *dnsWarmup
+	teamsExchanges teamsExchangeCounters
+	// replaces decodedAcsSecret once set, see RotateAccessKey
+	rotatedKey atomic.Pointer[[]byte]
+	metrics    *requestMetrics
+	// nil unless WithCircuitBreaker is set
+	breaker *circuitBreaker
+	// source of the retry jitter
+	retryRand *lockedRand
+	// nil unless WithAutoRefresh is set
+	refresher *tokenRefresher
+	// done once the client is closed, see goBackground
+	backgroundCtx    context.Context
+	cancelBackground context.CancelFunc
+	background       sync.WaitGroup
+	closeOnce        sync.Once
+	closed           atomic.Bool
 }
 
 type azAPIVersion string
 
+// DefaultAPIVersion is the ACS identity API version requests are sent with, see [WithAPIVersion]
+const DefaultAPIVersion = "2025-06-30"
+
 const (
 	tokenForTeamsUserEndpoint                        = "/teamsUser/:exchangeAccessToken"
 	createCommunicationIdentityEndpoint              = "/identities"
-	apiVersion                          azAPIVersion = "2025-06-30"
+	issueAccessTokenAction                           = ":issueAccessToken"
+	revokeAccessTokensAction                         = ":revokeAccessTokens"
+	adalAPIVersion                      azAPIVersion = "2022-06-01"
 	msAuthHeader                                     = "Authorization"
 	msDateHeader                                     = "x-ms-date"
 	msContentHashHeader                              = "x-ms-content-sha256"
 )
 
-// constructor for the REST Client
+// constructor for the REST Client, optional behaviour can be configured through [ClientOption]s.
+// acsEndpoint must be an HTTPS URL, only loopback hosts may be reached through plain HTTP.
 func New(
 	acsEndpoint *url.URL,
 	acsAccessKey string,
 	azClientId string,
+	opts ...ClientOption,
 ) (CommunicationIdentityClient, error) {
-	decodedAcsSecret, err := base64.StdEncoding.DecodeString(acsAccessKey)
+	options, err := applyClientOptions(opts)
 	if err != nil {
-		return CommunicationIdentityClient{}, fmt.Errorf(
-			"ACS access key is not valid base64: %w",
-			err,
-		)
+		return CommunicationIdentityClient{}, err
+	}
+	acsEndpoint, acsAccessKey, err = options.credentials(acsEndpoint, acsAccessKey)
+	if err != nil {
+		return CommunicationIdentityClient{}, err
+	}
+	if err := options.validateAuthentication(acsAccessKey); err != nil {
+		return CommunicationIdentityClient{}, err
+	}
+	if acsEndpoint != nil {
+		if err := validateEndpoint(acsEndpoint); err != nil {
+			return CommunicationIdentityClient{}, err
+		}
+		acsEndpoint = trimEndpointPath(acsEndpoint)
+	}
+	var decodedAcsSecret []byte
+	steps := append([]initStep{func(*url.URL) (err error) {
+		decodedAcsSecret, err = decodeAccessKey(acsAccessKey)
+		return err
+	}}, options.initSteps...)
+	if err := runInitSteps(steps, acsEndpoint, options.concurrentInit); err != nil {
+		return CommunicationIdentityClient{}, err
+	}
+	client := CommunicationIdentityClient{
+		acsEndpoint:      acsEndpoint,
+		decodedAcsSecret: decodedAcsSecret,
+		azClientId:       azClientId,
+		options:          options,
+	}
+	client.state = &clientState{
+		prefetched: newTokenCache(
+			client.timeSource().Now,
+			options.maxCacheSize,
+			options.evictionPolicy,
+			func(result CommunicationIdentityAccessTokenResult) {
+				client.recordTokenIssuance(TokenIssuanceKindEvicted, result)
+			},
+		),
+		reusable: newTokenCache(
+			client.timeSource().Now,
+			maxReusableIdentities,
+			EvictionPolicyTTL,
+			nil,
+		),
+	}
+	if options.secureTokens {
+		client.state.prefetched.onDiscard = zeroResult
 	}
-	return CommunicationIdentityClient{acsEndpoint, decodedAcsSecret, azClientId}, nil
+	client.state.backgroundCtx, client.state.cancelBackground = context.WithCancel(
+		context.Background(),
+	)
+	client.requestSigner = client.newRequestSigner()
+	client.state.breaker = newCircuitBreaker(options.circuitBreaker, client.timeSource().Now)
+	client.state.retryRand = newLockedRand(options.retry.seed)
+	client.state.refresher, err = newTokenRefresher(options.autoRefresh, options.tokenCache)
+	if err != nil {
+		return CommunicationIdentityClient{}, err
+	}
+	if client.state.metrics, err = newRequestMetrics(options); err != nil {
+		return CommunicationIdentityClient{}, err
+	}
+	if options.keyManager != nil {
+		options.keyManager.initPrimary(decodedAcsSecret)
+	}
+	if err := client.publishExpvar(); err != nil {
+		return CommunicationIdentityClient{}, err
+	}
+	if options.fastStart && acsEndpoint != nil {
+		client.state.dnsWarmup = startDNSWarmup(acsEndpoint.Hostname())
+	}
+	client.startAutoRefresh()
+	client.logStartup(acsAccessKey)
+	return client.withFinalizer(), nil
 }
 
 func (client CommunicationIdentityClient) buildEndpointURL(
@@ -68,60 +172,55 @@ func (client CommunicationIdentityClient) buildEndpointURL(
 	return endpointURL
 }
 
-// see: https://learn.microsoft.com/en-us/azure/communication-services/tutorials/hmac-header-tutorial?pivots=programming-language-csharp
+// buildIdentityEndpointURL builds the URL of an action on a single identity,
+// e.g. `/identities/{id}/:issueAccessToken`. The identity ID is escaped as a single path segment.
+func (client CommunicationIdentityClient) buildIdentityEndpointURL(
+	identityID string,
+	action string,
+	apiVersion azAPIVersion,
+) *url.URL {
+	endpointURL := client.buildEndpointURL(createCommunicationIdentityEndpoint, apiVersion)
+	escapedPath := endpointURL.EscapedPath() + "/" + url.PathEscape(identityID)
+	endpointURL.Path += "/" + identityID
+	if action != "" {
+		escapedPath += "/" + action
+		endpointURL.Path += "/" + action
+	}
+	endpointURL.RawPath = escapedPath
+
+	return endpointURL
+}
+
+// buildSignedRequest builds a request for httpMethod signed by the RequestSigner of the client,
+// with ctx for signers fetching tokens. GET and DELETE requests carry no body, their content hash
+// is the one of an empty body.
 func (client CommunicationIdentityClient) buildSignedRequest(
+	ctx context.Context,
+	httpMethod string,
 	url *url.URL,
 	body []byte,
 ) (*http.Request, error) {
 	if url == nil {
 		return nil, fmt.Errorf("url for signed request can not be nil")
 	}
-	computeHash := func(content []byte) string {
-		hash := sha256.Sum256(content)
-		return base64.StdEncoding.EncodeToString(hash[:])
+	if client.state != nil && client.state.closed.Load() {
+		return nil, ErrClientClosed
 	}
-	computeSignature := func(toSign string) (string, error) {
-		if !utf8.ValidString(toSign) {
-			return "", fmt.Errorf("string to sign is not valid utf-8")
+	switch httpMethod {
+	case http.MethodGet, http.MethodDelete:
+		if len(body) > 0 {
+			return nil, fmt.Errorf("%s requests can not have a body", httpMethod)
 		}
-
-		mac := hmac.New(sha256.New, client.decodedAcsSecret)
-		_, err := mac.Write([]byte(toSign))
-		if err != nil {
-			return "", fmt.Errorf("failed to write to MAC: %w", err)
-		}
-		macSum := mac.Sum(nil)
-
-		return base64.StdEncoding.EncodeToString(macSum), nil
-	}
-
-	// DO NOT USE 'time.RFC1123' : https://github.com/golang/go/issues/13781
-	date := time.Now().UTC().Format(http.TimeFormat)
-	contentHash := computeHash(body)
-	pathAndQuery := fmt.Sprintf("%s?%s", url.EscapedPath(), url.RawQuery)
-
-	stringToSign := fmt.Sprintf("POST\n%s\n%s;%s;%s", pathAndQuery, date, url.Host, contentHash)
-	signature, err := computeSignature(stringToSign)
-	if err != nil {
-		return nil, fmt.Errorf("failed to build request signature: %w", err)
+		body = []byte{}
 	}
-
-	authorization :=
-		fmt.Sprintf(
-			"HMAC-SHA256 SignedHeaders=x-ms-date;host;x-ms-content-sha256&Signature=%s",
-			signature,
-		)
-
-	request, err := http.NewRequest(http.MethodPost, url.String(), bytes.NewReader(body))
+	request, err := http.NewRequestWithContext(ctx, httpMethod, url.String(), bytes.NewReader(body))
 	if err != nil {
 		return nil, fmt.Errorf("failed to build request: %w", err)
 	}
-
-	request.Header.Add("Content-Type", "application/json")
-	request.Header.Add(msDateHeader, date)
-	request.Header.Add(msContentHashHeader, contentHash)
-	request.Header.Add(msAuthHeader, authorization)
-
+	request.Header.Set("Content-Type", "application/json")
+	if err := client.requestSigner.SignRequest(request, body); err != nil {
+		return nil, err
+	}
 	return request, nil
 }
 
@@ -134,11 +233,73 @@ type teamsUserExchangeTokenRequest struct {
 type CommunicationIdentityAccessToken struct {
 	Token     string    `json:"token"`
 	ExpiresOn time.Time `json:"expiresOn"`
+	// holds the token instead of Token for clients created with [WithSecureTokenHandling]
+	Secure *SecureTokenBuffer `json:"-"`
+	// value of the `x-ms-request-id` header of the response the token was issued with, include it
+	// in bug reports and support requests
+	RequestID string `json:"-"`
+}
+
+// Clone returns a copy of the token that can be stored in shared state. Strings are immutable, so
+// copying the value is enough, the copy shares Secure with the original though, see CloneSecure.
+func (token CommunicationIdentityAccessToken) Clone() CommunicationIdentityAccessToken {
+	return token
+}
+
+// CloneSecure additionally copies Token and Secure into new memory, so the copy survives zeroing
+// of the original by [SecureTokenBuffer.Zero]
+func (token CommunicationIdentityAccessToken) CloneSecure() CommunicationIdentityAccessToken {
+	token.Token = strings.Clone(token.Token)
+	if token.Secure != nil {
+		token.Secure = NewSecureTokenBuffer(token)
+	}
+	return token
+}
+
+// raw returns the token, read from Secure if set
+func (token CommunicationIdentityAccessToken) raw() string {
+	if token.Secure != nil {
+		return string(token.Secure.Bytes())
+	}
+	return token.Token
+}
+
+// plain returns the token with Token set instead of Secure, e.g. to encode it as JSON
+func (token CommunicationIdentityAccessToken) plain() CommunicationIdentityAccessToken {
+	return CommunicationIdentityAccessToken{Token: token.raw(), ExpiresOn: token.ExpiresOn}
+}
+
+// IsExpired reports whether ExpiresOn has passed
+func (token CommunicationIdentityAccessToken) IsExpired() bool {
+	return token.IsExpiredWithThreshold(0)
+}
+
+// IsExpiredWithThreshold reports whether the token expires within d, e.g. to refresh tokens
+// expiring in the next 5 minutes
+func (token CommunicationIdentityAccessToken) IsExpiredWithThreshold(d time.Duration) bool {
+	return token.TimeUntilExpiry() < d
+}
+
+// TimeUntilExpiry returns the time left until ExpiresOn, negative if the token has expired
+func (token CommunicationIdentityAccessToken) TimeUntilExpiry() time.Duration {
+	return token.ExpiresOn.Sub(time.Now().UTC())
 }
 
 type CommunicationIdentityAccessTokenResult struct {
 	AccessToken CommunicationIdentityAccessToken `json:"accessToken"`
 	Identity    CommunicationIdentity            `json:"identity"`
+	// value of the `x-ms-request-id` response header, include it in bug reports and support
+	// requests
+	RequestID string `json:"-"`
+}
+
+func (token *CommunicationIdentityAccessToken) setRequestID(requestID string) {
+	token.RequestID = requestID
+}
+
+func (result *CommunicationIdentityAccessTokenResult) setRequestID(requestID string) {
+	result.RequestID = requestID
+	result.AccessToken.RequestID = requestID
 }
 
 type CommunicationIdentity struct {
@@ -149,7 +310,13 @@ type CommunicationIdentity struct {
 // `Code` can be used to handle errors in a stable way, though microsoft may add
 // new codes in the future
 //
-// NOTE: no Unwrap implementation to Innererror on purpose, this may change
+// [errors.Is] and [errors.As] follow the chain of inner errors, a *CommunicationError target
+// matches on its Code, so
+//
+//	errors.Is(err, &CommunicationError{Code: "Unauthorized"})
+//
+// holds if err or any of its inner errors has code "Unauthorized". Details are not part of the
+// chain, see [ContainsCode].
 type CommunicationError struct {
 	Code       string               `json:"code"`
 	Details    []CommunicationError `json:"details"`
@@ -161,17 +328,73 @@ type CommunicationError struct {
 
 func (err *CommunicationError) Error() string {
 	var out strings.Builder
+	err.writeTo(&out)
+	return out.String()
+}
 
+// writeTo formats err with its details, followed by its chain of inner errors
+func (err *CommunicationError) writeTo(out *strings.Builder) {
 	if err.Target != "" {
 		out.WriteString(fmt.Sprintf("[target:%s]", err.Target))
 	}
-	out.WriteString(
-		fmt.Sprintf("%s - %s\n", err.Code, err.Message))
-	out.WriteString(fmt.Sprintf("details: %+v\n", err.Details))
+	out.WriteString(fmt.Sprintf("%s - %s", err.Code, err.Message))
+	if len(err.Details) > 0 {
+		out.WriteString(" (details: ")
+		for i := range err.Details {
+			if i > 0 {
+				out.WriteString("; ")
+			}
+			err.Details[i].writeTo(out)
+		}
+		out.WriteString(")")
+	}
 	if err.Innererror != nil {
-		out.WriteString(fmt.Sprintf("inner error: %v\n", err.Innererror))
+		out.WriteString(": ")
+		err.Innererror.writeTo(out)
 	}
-	return out.String()
+}
+
+// Unwrap returns the inner error, nil if there is none
+func (err *CommunicationError) Unwrap() error {
+	if err.Innererror == nil {
+		return nil
+	}
+	return err.Innererror
+}
+
+// Is reports whether target is a *CommunicationError with the same non-empty Code
+func (err *CommunicationError) Is(target error) bool {
+	want, ok := target.(*CommunicationError)
+	return ok && want != nil && want.Code != "" && want.Code == err.Code
+}
+
+// Fields returns the error as structured map for log attributes, the inner error is nested
+// under "inner" if present
+func (err *CommunicationError) Fields() map[string]any {
+	fields := map[string]any{
+		"code":          err.Code,
+		"message":       err.Message,
+		"target":        err.Target,
+		"details_count": len(err.Details),
+	}
+	if err.Innererror != nil {
+		fields["inner"] = err.Innererror.Fields()
+	}
+	return fields
+}
+
+// LogValue logs the error as group of its [CommunicationError.Fields] with [log/slog]
+func (err *CommunicationError) LogValue() slog.Value {
+	attrs := []slog.Attr{
+		slog.String("code", err.Code),
+		slog.String("message", err.Message),
+		slog.String("target", err.Target),
+		slog.Int("details_count", len(err.Details)),
+	}
+	if err.Innererror != nil {
+		attrs = append(attrs, slog.Any("inner", err.Innererror))
+	}
+	return slog.GroupValue(attrs...)
 }
 
 type communicationErrorResponse struct {
@@ -183,63 +406,195 @@ func (client CommunicationIdentityClient) TokenForTeamsUser(
 	ctx context.Context,
 	userOid string,
 	teamsScopeMSALToken string,
+	opts ...CallOption,
 ) (CommunicationIdentityAccessToken, error) {
+	token, err := client.tokenForTeamsUser(
+		ctx,
+		userOid,
+		teamsScopeMSALToken,
+		client.apiVersion(),
+		applyCallOptions(opts),
+	)
+	return client.secureToken(token), err
+}
+
+// TokenForTeamsUserWithADAL exchanges a Teams token issued through the retired ADAL library.
+// The exchange is sent with API version "2022-06-01", the first stable version of the route.
+// ACS does not define a header for the token type, the token is validated by its claims only.
+//
+// Deprecated: ADAL is retired by Microsoft, migrate to MSAL and use
+// [CommunicationIdentityClient.TokenForTeamsUser].
+func (client CommunicationIdentityClient) TokenForTeamsUserWithADAL(
+	ctx context.Context,
+	userOid string,
+	adalToken string,
+	opts ...CallOption,
+) (CommunicationIdentityAccessToken, error) {
+	token, err := client.tokenForTeamsUser(
+		ctx,
+		userOid,
+		adalToken,
+		adalAPIVersion,
+		applyCallOptions(opts),
+	)
+	return client.secureToken(token), err
+}
+
+func (client CommunicationIdentityClient) buildTeamsUserExchangeRequest(
+	ctx context.Context,
+	userOid string,
+	teamsToken string,
+	apiVersion azAPIVersion,
+) (*http.Request, error) {
 	fullResourceURL := client.buildEndpointURL(tokenForTeamsUserEndpoint, apiVersion)
+	requestBody, err := client.teamsUserExchangeBody(userOid, teamsToken)
+	if err != nil {
+		return nil, err
+	}
+	request, err := client.buildSignedRequest(ctx, http.MethodPost, fullResourceURL, requestBody)
+	if err != nil {
+		return nil, newTransportError("failed to create signed request: %w", err)
+	}
+	return request, nil
+}
+
+func (client CommunicationIdentityClient) teamsUserExchangeBody(
+	userOid string,
+	teamsToken string,
+) ([]byte, error) {
 	requestBody, err := json.Marshal(teamsUserExchangeTokenRequest{
 		AppId:  client.azClientId,
-		Token:  teamsScopeMSALToken,
+		Token:  teamsToken,
 		UserId: userOid,
 	})
 	if err != nil {
-		return CommunicationIdentityAccessToken{}, fmt.Errorf(
-			"failed to build request body: %w",
-			err,
-		)
+		return nil, newTransportError("failed to build request body: %w", err)
 	}
-	request, err := client.buildSignedRequest(fullResourceURL, requestBody)
+	return requestBody, nil
+}
+
+// TokenForTeamsUserStream is like [CommunicationIdentityClient.TokenForTeamsUser] but copies the
+// JSON response body of ACS unmodified to w, e.g. an [http.ResponseWriter], instead of decoding
+// it. Nothing is written to w if ACS does not respond with status OK.
+//
+// NOTE: bypasses the [TeamsUserExchangeCache], every call is sent to ACS
+func (client CommunicationIdentityClient) TokenForTeamsUserStream(
+	ctx context.Context,
+	userOid string,
+	msalToken string,
+	w io.Writer,
+	opts ...CallOption,
+) error {
+	callOpts := applyCallOptions(opts)
+	_, err := intercept(
+		client,
+		ctx,
+		"TokenForTeamsUserStream",
+		&TokenForTeamsUserRequest{UserOID: userOid, MSALToken: msalToken},
+		func(ctx context.Context, req *TokenForTeamsUserRequest) (struct{}, error) {
+			return struct{}{}, client.streamTeamsUserToken(ctx, req.UserOID, req.MSALToken, w, callOpts)
+		},
+	)
+	return err
+}
+
+func (client CommunicationIdentityClient) streamTeamsUserToken(
+	ctx context.Context,
+	userOid string,
+	msalToken string,
+	w io.Writer,
+	callOpts callOptions,
+) (err error) {
+	request, err := client.buildTeamsUserExchangeRequest(ctx, userOid, msalToken, client.apiVersion())
 	if err != nil {
-		return CommunicationIdentityAccessToken{}, fmt.Errorf(
-			"failed to create signed request: %w",
-			err,
-		)
+		return err
 	}
-	request = request.WithContext(ctx)
-	response, err := http.DefaultClient.Do(request)
+	response, err := client.send(ctx, request, callOpts)
 	if err != nil {
-		return CommunicationIdentityAccessToken{}, fmt.Errorf(
-			"failed to send request to ACS: %w",
-			err,
-		)
+		return newTransportError("failed to send request to ACS: %w", err)
 	}
-	defer func() {
-		if err := response.Body.Close(); err != nil {
-			// TODO: do something nicer here
-			fmt.Fprintf(
-				os.Stderr,
-				"'Communication Identity' failed to close response body: %v",
-				err,
-			)
-		}
-	}()
-
-	if response.StatusCode == http.StatusOK {
-		var tokenResponse CommunicationIdentityAccessToken
-		if err := json.NewDecoder(response.Body).Decode(&tokenResponse); err != nil {
-			return CommunicationIdentityAccessToken{}, fmt.Errorf(
-				"failed to parse response body for status OK %v",
-				err,
-			)
-		}
-		return tokenResponse, nil
+	defer client.closeResponse(response, callOpts, &err)
 
-	} else {
+	if response.StatusCode != http.StatusOK {
 		var errorResponse communicationErrorResponse
 		if err := json.NewDecoder(response.Body).Decode(&errorResponse); err != nil {
-			return CommunicationIdentityAccessToken{}, fmt.Errorf("ACS responded with non-OK status(%v) and response body was not parseable", response.Status)
+			return newBodyError(response, "response body was not parseable", err)
+		}
+		return newResponseError(response, &errorResponse.Error, nil)
+	}
+	if _, err := io.Copy(w, response.Body); err != nil {
+		return newBodyError(response, "failed to copy response body", err)
+	}
+	return nil
+}
+
+func (client CommunicationIdentityClient) tokenForTeamsUser(
+	ctx context.Context,
+	userOid string,
+	teamsToken string,
+	apiVersion azAPIVersion,
+	callOpts callOptions,
+) (CommunicationIdentityAccessToken, error) {
+	exchangeCache := client.options.teamsUserExchangeCache
+	if exchangeCache != nil {
+		if token, found := exchangeCache.Get(client.azClientId, userOid, teamsToken); found {
+			return token, nil
 		}
+	}
+
+	start := client.timeSource().Now()
+	token, err := intercept(
+		client,
+		ctx,
+		"TokenForTeamsUser",
+		&TokenForTeamsUserRequest{UserOID: userOid, MSALToken: teamsToken},
+		func(
+			ctx context.Context,
+			req *TokenForTeamsUserRequest,
+		) (CommunicationIdentityAccessToken, error) {
+			return client.exchangeTeamsUserToken(
+				ctx,
+				req.UserOID,
+				req.MSALToken,
+				apiVersion,
+				callOpts,
+			)
+		},
+	)
+	client.state.teamsExchanges.record(start, client.timeSource().Now(), err == nil)
+	if err != nil {
+		return CommunicationIdentityAccessToken{}, err
+	}
+	client.emit(ClientEventTokenIssued, TokenIssuedEvent{
+		Method:    "TokenForTeamsUser",
+		ExpiresOn: token.ExpiresOn,
+	})
+	if exchangeCache != nil {
+		exchangeCache.Set(client.azClientId, userOid, teamsToken, token)
+	}
+	return token, nil
+}
 
-		return CommunicationIdentityAccessToken{}, fmt.Errorf("ACS responded with non-OK status(%v), error: %w", response.Status, &errorResponse.Error)
+func (client CommunicationIdentityClient) exchangeTeamsUserToken(
+	ctx context.Context,
+	userOid string,
+	teamsToken string,
+	apiVersion azAPIVersion,
+	callOpts callOptions,
+) (CommunicationIdentityAccessToken, error) {
+	requestBody, err := client.teamsUserExchangeBody(userOid, teamsToken)
+	if err != nil {
+		return CommunicationIdentityAccessToken{}, err
 	}
+	return doRequest[CommunicationIdentityAccessToken](
+		client,
+		ctx,
+		http.MethodPost,
+		client.buildEndpointURL(tokenForTeamsUserEndpoint, apiVersion),
+		requestBody,
+		http.StatusOK,
+		callOpts,
+	)
 }
 
 type createAndReturnTokenRequest struct {
@@ -248,66 +603,322 @@ type createAndReturnTokenRequest struct {
 }
 
 // CreateCommunicationIdentity Azure Documentation https://learn.microsoft.com/en-us/rest/api/communication/identity/communication-identity/create?view=rest-communication-identity-2025-06-30&tabs=HTTP
+//
+// Served from identities created by [CommunicationIdentityClient.PrefetchToken] if available
+// for scope and expireInMinutes is nil. A nil expireInMinutes uses the ACS default unless
+// [WithDefaultExpirationDuration] is set.
+//
+// Like [CommunicationIdentityClient.IssueAccessToken], at least one scope supported by ACS is
+// required, see [ValidateScopes].
 func (client CommunicationIdentityClient) CreateCommunicationIdentity(
 	ctx context.Context,
 	scope []string,
 	expireInMinutes *int32,
+	opts ...CallOption,
+) (CommunicationIdentityAccessTokenResult, error) {
+	if err := validateTokenRequest(scope, expireInMinutes); err != nil {
+		return CommunicationIdentityAccessTokenResult{}, err
+	}
+	callOpts := applyCallOptions(opts)
+	tokenCache := client.options.tokenCache
+	if tokenCache != nil && callOpts.tokenCacheKey != "" {
+		if result, found := tokenCache.Get(callOpts.tokenCacheKey); found {
+			return result, nil
+		}
+	}
+	if expireInMinutes == nil {
+		if result, found := client.state.prefetched.take(scopeKey(scope)); found {
+			client.cacheResult(callOpts, scope, result)
+			return result, nil
+		}
+	}
+	result, err := client.createCommunicationIdentity(ctx, scope, expireInMinutes, callOpts)
+	if err != nil {
+		return client.secureResult(result), err
+	}
+	result = client.secureResult(result)
+	client.cacheResult(callOpts, scope, result)
+	return result, nil
+}
+
+// cacheResult stores result in the cache of WithTokenCache, if set
+func (client CommunicationIdentityClient) cacheResult(
+	callOpts callOptions,
+	scopes []string,
+	result CommunicationIdentityAccessTokenResult,
+) {
+	if client.options.tokenCache == nil {
+		return
+	}
+	key := callOpts.cacheKey(result.Identity.ID, scopes)
+	client.options.tokenCache.Set(key, result)
+	if client.state.refresher != nil {
+		client.state.refresher.track(key, result.Identity.ID)
+	}
+}
+
+func (client CommunicationIdentityClient) createCommunicationIdentity(
+	ctx context.Context,
+	scope []string,
+	expireInMinutes *int32,
+	callOpts callOptions,
 ) (CommunicationIdentityAccessTokenResult, error) {
-	fullResourceURL := client.buildEndpointURL(createCommunicationIdentityEndpoint, apiVersion)
+	result, err := intercept(
+		client,
+		ctx,
+		"CreateCommunicationIdentity",
+		&CreateCommunicationIdentityRequest{Scopes: scope, ExpireInMinutes: expireInMinutes},
+		func(
+			ctx context.Context,
+			req *CreateCommunicationIdentityRequest,
+		) (CommunicationIdentityAccessTokenResult, error) {
+			return client.sendCreateCommunicationIdentity(ctx, req.Scopes, req.ExpireInMinutes, callOpts)
+		},
+	)
+	if err == nil {
+		client.emit(ClientEventTokenIssued, TokenIssuedEvent{
+			Method:     "CreateCommunicationIdentity",
+			IdentityID: result.Identity.ID,
+			ExpiresOn:  result.AccessToken.ExpiresOn,
+		})
+	}
+	return result, err
+}
+
+func (client CommunicationIdentityClient) sendCreateCommunicationIdentity(
+	ctx context.Context,
+	scope []string,
+	expireInMinutes *int32,
+	callOpts callOptions,
+) (CommunicationIdentityAccessTokenResult, error) {
+	fullResourceURL := client.buildEndpointURL(
+		createCommunicationIdentityEndpoint,
+		client.apiVersion(),
+	)
 
 	requestBody, err := json.Marshal(createAndReturnTokenRequest{
 		Scope:  scope,
-		Expire: expireInMinutes,
+		Expire: client.expiryOrDefault(scope, expireInMinutes),
 	})
 	if err != nil {
-		return CommunicationIdentityAccessTokenResult{}, fmt.Errorf(
-			"failed to build requeset body: %w",
+		return CommunicationIdentityAccessTokenResult{}, newTransportError(
+			"failed to build request body: %w",
 			err,
 		)
 	}
 
-	request, err := client.buildSignedRequest(fullResourceURL, requestBody)
+	return doRequest[CommunicationIdentityAccessTokenResult](
+		client,
+		ctx,
+		http.MethodPost,
+		fullResourceURL,
+		requestBody,
+		http.StatusCreated,
+		callOpts,
+	)
+}
+
+type issueAccessTokenRequest struct {
+	Scopes []string `json:"scopes"`
+	Expire *int32   `json:"expiresInMinutes,omitempty"`
+}
 
+// IssueAccessToken Azure Documentation https://learn.microsoft.com/en-us/rest/api/communication/identity/communication-identity/issue-access-token?view=rest-communication-identity-2025-06-30&tabs=HTTP
+//
+// Issues a new token for an existing identity. A nil expireInMinutes uses the ACS default unless
+// [WithDefaultExpirationDuration] is set.
+func (client CommunicationIdentityClient) IssueAccessToken(
+	ctx context.Context,
+	identityID string,
+	scopes []string,
+	expireInMinutes *int32,
+	opts ...CallOption,
+) (CommunicationIdentityAccessToken, error) {
+	if err := client.validateIdentityID(identityID); err != nil {
+		return CommunicationIdentityAccessToken{}, err
+	}
+	if err := validateTokenRequest(scopes, expireInMinutes); err != nil {
+		return CommunicationIdentityAccessToken{}, err
+	}
+	callOpts := applyCallOptions(opts)
+	if client.options.tokenCache != nil {
+		key := callOpts.cacheKey(identityID, scopes)
+		if result, found := client.options.tokenCache.Get(key); found {
+			return result.AccessToken, nil
+		}
+	}
+	token, err := client.issueAccessToken(ctx, identityID, scopes, expireInMinutes, callOpts)
 	if err != nil {
-		return CommunicationIdentityAccessTokenResult{}, fmt.Errorf(
-			"failed to create signed request: %w",
-			err,
-		)
+		return client.secureToken(token), err
+	}
+	token = client.secureToken(token)
+	client.cacheResult(callOpts, scopes, CommunicationIdentityAccessTokenResult{
+		AccessToken: token,
+		Identity:    CommunicationIdentity{ID: identityID},
+	})
+	return token, nil
+}
+
+func (client CommunicationIdentityClient) issueAccessToken(
+	ctx context.Context,
+	identityID string,
+	scopes []string,
+	expireInMinutes *int32,
+	callOpts callOptions,
+) (CommunicationIdentityAccessToken, error) {
+	token, err := intercept(
+		client,
+		ctx,
+		"IssueAccessToken",
+		&IssueAccessTokenRequest{
+			IdentityID:      identityID,
+			Scopes:          scopes,
+			ExpireInMinutes: expireInMinutes,
+		},
+		func(
+			ctx context.Context,
+			req *IssueAccessTokenRequest,
+		) (CommunicationIdentityAccessToken, error) {
+			return client.sendIssueAccessToken(
+				ctx,
+				req.IdentityID,
+				req.Scopes,
+				req.ExpireInMinutes,
+				callOpts,
+			)
+		},
+	)
+	if err == nil {
+		client.emit(ClientEventTokenIssued, TokenIssuedEvent{
+			Method:     "IssueAccessToken",
+			IdentityID: identityID,
+			ExpiresOn:  token.ExpiresOn,
+		})
 	}
-	request = request.WithContext(ctx)
-	response, err := http.DefaultClient.Do(request)
+	return token, err
+}
+
+func (client CommunicationIdentityClient) sendIssueAccessToken(
+	ctx context.Context,
+	identityID string,
+	scopes []string,
+	expireInMinutes *int32,
+	callOpts callOptions,
+) (CommunicationIdentityAccessToken, error) {
+	if err := client.validateIdentityID(identityID); err != nil {
+		return CommunicationIdentityAccessToken{}, err
+	}
+	fullResourceURL := client.buildIdentityEndpointURL(
+		identityID,
+		issueAccessTokenAction,
+		client.apiVersion(),
+	)
+
+	requestBody, err := json.Marshal(issueAccessTokenRequest{
+		Scopes: scopes,
+		Expire: client.expiryOrDefault(scopes, expireInMinutes),
+	})
 	if err != nil {
-		return CommunicationIdentityAccessTokenResult{}, fmt.Errorf(
-			"failed to send reqeust to ACS: %w",
+		return CommunicationIdentityAccessToken{}, newTransportError(
+			"failed to build request body: %w",
 			err,
 		)
 	}
-	defer func() {
-		if err := response.Body.Close(); err != nil {
-			// TODO: do something nicer here
-			fmt.Fprintf(
-				os.Stderr,
-				"'Communication Identity' failed to close response body: %v",
-				err,
-			)
-		}
-	}()
-	if response.StatusCode == http.StatusCreated {
-		var tokenResponse CommunicationIdentityAccessTokenResult
-		if err := json.NewDecoder(response.Body).Decode(&tokenResponse); err != nil {
-			return CommunicationIdentityAccessTokenResult{}, fmt.Errorf(
-				"failed to parse response body for status OK: %v",
-				err,
-			)
-		}
-		return tokenResponse, nil
+	return doRequest[CommunicationIdentityAccessToken](
+		client,
+		ctx,
+		http.MethodPost,
+		fullResourceURL,
+		requestBody,
+		http.StatusOK,
+		callOpts,
+	)
+}
 
-	} else {
-		var errorResponse communicationErrorResponse
-		if err := json.NewDecoder(response.Body).Decode(&errorResponse); err != nil {
-			return CommunicationIdentityAccessTokenResult{}, fmt.Errorf("ACS responded with non-OK status(%v) and response body was not parseable", response.Status)
-		}
+// DeleteCommunicationIdentity Azure Documentation https://learn.microsoft.com/en-us/rest/api/communication/identity/communication-identity/delete?view=rest-communication-identity-2025-06-30&tabs=HTTP
+//
+// Deletes the identity and revokes all of its tokens
+func (client CommunicationIdentityClient) DeleteCommunicationIdentity(
+	ctx context.Context,
+	identityID string,
+	opts ...CallOption,
+) error {
+	callOpts := applyCallOptions(opts)
+	_, err := intercept(
+		client,
+		ctx,
+		"DeleteCommunicationIdentity",
+		&DeleteCommunicationIdentityRequest{IdentityID: identityID},
+		func(ctx context.Context, req *DeleteCommunicationIdentityRequest) (struct{}, error) {
+			return struct{}{}, client.deleteCommunicationIdentity(ctx, req.IdentityID, callOpts)
+		},
+	)
+	return err
+}
 
-		return CommunicationIdentityAccessTokenResult{}, fmt.Errorf("ACS responded with non-OK status(%v), error: %w", response.Status, &errorResponse.Error)
+func (client CommunicationIdentityClient) deleteCommunicationIdentity(
+	ctx context.Context,
+	identityID string,
+	callOpts callOptions,
+) error {
+	if err := client.validateIdentityID(identityID); err != nil {
+		return err
+	}
+	fullResourceURL := client.buildIdentityEndpointURL(identityID, "", client.apiVersion())
+	_, err := doRequest[struct{}](
+		client,
+		ctx,
+		http.MethodDelete,
+		fullResourceURL,
+		nil,
+		http.StatusNoContent,
+		callOpts,
+	)
+	return err
+}
+
+// RevokeAccessTokens Azure Documentation https://learn.microsoft.com/en-us/rest/api/communication/identity/communication-identity/revoke-access-tokens?view=rest-communication-identity-2025-06-30&tabs=HTTP
+//
+// Revokes all tokens issued for the identity, e.g. after one was compromised
+func (client CommunicationIdentityClient) RevokeAccessTokens(
+	ctx context.Context,
+	identityID string,
+	opts ...CallOption,
+) error {
+	callOpts := applyCallOptions(opts)
+	_, err := intercept(
+		client,
+		ctx,
+		"RevokeAccessTokens",
+		&RevokeAccessTokensRequest{IdentityID: identityID},
+		func(ctx context.Context, req *RevokeAccessTokensRequest) (struct{}, error) {
+			return struct{}{}, client.revokeAccessTokens(ctx, req.IdentityID, callOpts)
+		},
+	)
+	return err
+}
+
+func (client CommunicationIdentityClient) revokeAccessTokens(
+	ctx context.Context,
+	identityID string,
+	callOpts callOptions,
+) error {
+	if err := client.validateIdentityID(identityID); err != nil {
+		return err
 	}
+	fullResourceURL := client.buildIdentityEndpointURL(
+		identityID,
+		revokeAccessTokensAction,
+		client.apiVersion(),
+	)
+	_, err := doRequest[struct{}](
+		client,
+		ctx,
+		http.MethodPost,
+		fullResourceURL,
+		nil,
+		http.StatusNoContent,
+		callOpts,
+	)
+	return err
 }
@@ -0,0 +1,236 @@
+package communicationidentitytest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+)
+
+// Outcome of one call of the client returned by [NewFakeClient]
+type FakeResponse struct {
+	// returned by methods issuing or exchanging a token, and as token of created identities
+	AccessToken ci.CommunicationIdentityAccessToken
+	// returned by methods creating an identity
+	Identity ci.CommunicationIdentity
+	// returned instead of the result if set
+	Err error
+}
+
+var _ ci.Client = (*fakeClient)(nil)
+
+type fakeClient struct {
+	mu        sync.Mutex
+	responses []FakeResponse
+	closed    bool
+}
+
+// NewFakeClient returns a [ci.Client] answering calls with responses in order, regardless of the
+// method and its arguments. Every call takes one response, a batch one per request. Once all
+// responses are used up, calls fail. Calls with a done context fail with the context error
+// without taking a response. Once closed, calls fail with [ci.ErrClientClosed], key rotations
+// and cache flushes take no response.
+//
+// Safe for concurrent use, though the order of concurrent calls is undefined.
+func NewFakeClient(responses ...FakeResponse) ci.Client {
+	return &fakeClient{responses: responses}
+}
+
+func (client *fakeClient) next(ctx context.Context, method string) (FakeResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return FakeResponse{}, err
+	}
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if client.closed {
+		return FakeResponse{}, ci.ErrClientClosed
+	}
+	if len(client.responses) == 0 {
+		return FakeResponse{}, fmt.Errorf("fake client has no response left for %s", method)
+	}
+	response := client.responses[0]
+	client.responses = client.responses[1:]
+	return response, response.Err
+}
+
+func (client *fakeClient) token(
+	ctx context.Context,
+	method string,
+) (ci.CommunicationIdentityAccessToken, error) {
+	response, err := client.next(ctx, method)
+	if err != nil {
+		return ci.CommunicationIdentityAccessToken{}, err
+	}
+	return response.AccessToken, nil
+}
+
+func (client *fakeClient) result(
+	ctx context.Context,
+	method string,
+) (ci.CommunicationIdentityAccessTokenResult, error) {
+	response, err := client.next(ctx, method)
+	if err != nil {
+		return ci.CommunicationIdentityAccessTokenResult{}, err
+	}
+	return ci.CommunicationIdentityAccessTokenResult{
+		AccessToken: response.AccessToken,
+		Identity:    response.Identity,
+	}, nil
+}
+
+func (client *fakeClient) TokenForTeamsUser(
+	ctx context.Context,
+	_ string,
+	_ string,
+	_ ...ci.CallOption,
+) (ci.CommunicationIdentityAccessToken, error) {
+	return client.token(ctx, "TokenForTeamsUser")
+}
+
+func (client *fakeClient) TokenForTeamsUserWithADAL(
+	ctx context.Context,
+	_ string,
+	_ string,
+	_ ...ci.CallOption,
+) (ci.CommunicationIdentityAccessToken, error) {
+	return client.token(ctx, "TokenForTeamsUserWithADAL")
+}
+
+func (client *fakeClient) TokenForTeamsUserOrCreate(
+	ctx context.Context,
+	_ ci.TokenForTeamsUserOrCreateOptions,
+	_ ...ci.CallOption,
+) (ci.CommunicationIdentityAccessTokenResult, error) {
+	return client.result(ctx, "TokenForTeamsUserOrCreate")
+}
+
+func (client *fakeClient) CreateCommunicationIdentity(
+	ctx context.Context,
+	_ []string,
+	_ *int32,
+	_ ...ci.CallOption,
+) (ci.CommunicationIdentityAccessTokenResult, error) {
+	return client.result(ctx, "CreateCommunicationIdentity")
+}
+
+func (client *fakeClient) IssueAccessToken(
+	ctx context.Context,
+	_ string,
+	_ []string,
+	_ *int32,
+	_ ...ci.CallOption,
+) (ci.CommunicationIdentityAccessToken, error) {
+	return client.token(ctx, "IssueAccessToken")
+}
+
+func (client *fakeClient) IssueShortLivedAccessToken(
+	ctx context.Context,
+	_ string,
+	_ []ci.Scope,
+	_ ...ci.CallOption,
+) (ci.CommunicationIdentityAccessToken, error) {
+	return client.token(ctx, "IssueShortLivedAccessToken")
+}
+
+// IssueAccessTokenBatch fails with a *ci.MultiError like the real client if any request fails
+func (client *fakeClient) IssueAccessTokenBatch(
+	ctx context.Context,
+	_ string,
+	requests []ci.TokenRequest,
+	_ ...ci.CallOption,
+) ([]ci.CommunicationIdentityAccessToken, error) {
+	tokens := make([]ci.CommunicationIdentityAccessToken, len(requests))
+	multiErr := &ci.MultiError{}
+	for i := range requests {
+		token, err := client.token(ctx, "IssueAccessTokenBatch")
+		if err != nil {
+			var acsErr *ci.CommunicationError
+			if errors.As(err, &acsErr) {
+				multiErr.Errors = append(multiErr.Errors, acsErr)
+			}
+			multiErr.Causes = append(multiErr.Causes, fmt.Errorf("requests[%d]: %w", i, err))
+			continue
+		}
+		tokens[i] = token
+	}
+	if len(multiErr.Causes) > 0 {
+		return tokens, multiErr
+	}
+	return tokens, nil
+}
+
+func (client *fakeClient) DeleteCommunicationIdentity(
+	ctx context.Context,
+	_ string,
+	_ ...ci.CallOption,
+) error {
+	_, err := client.next(ctx, "DeleteCommunicationIdentity")
+	return err
+}
+
+func (client *fakeClient) RevokeAccessTokens(
+	ctx context.Context,
+	_ string,
+	_ ...ci.CallOption,
+) error {
+	_, err := client.next(ctx, "RevokeAccessTokens")
+	return err
+}
+
+// TokenForTeamsUserStream writes the token of the response to w as JSON
+func (client *fakeClient) TokenForTeamsUserStream(
+	ctx context.Context,
+	_ string,
+	_ string,
+	w io.Writer,
+	_ ...ci.CallOption,
+) error {
+	token, err := client.token(ctx, "TokenForTeamsUserStream")
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(w).Encode(token)
+}
+
+// TokenForTeamsUserBatch takes the responses in the order of requests, regardless of concurrency
+func (client *fakeClient) TokenForTeamsUserBatch(
+	ctx context.Context,
+	requests []ci.TeamsUserExchangeRequest,
+	_ int,
+	_ ...ci.CallOption,
+) []ci.TeamsUserTokenExchangeResult {
+	results := make([]ci.TeamsUserTokenExchangeResult, len(requests))
+	for i := range requests {
+		token, err := client.token(ctx, "TokenForTeamsUserBatch")
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		results[i].Token = &token
+	}
+	return results
+}
+
+func (client *fakeClient) RotateAccessKey(_ string) error {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if client.closed {
+		return ci.ErrClientClosed
+	}
+	return nil
+}
+
+func (client *fakeClient) FlushTokenCache() error {
+	return nil
+}
+
+func (client *fakeClient) Close() error {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.closed = true
+	return nil
+}
@@ -0,0 +1,106 @@
+package communicationidentitytest_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+	"github.com/jls-ch/azure-communication-identity-go/communicationidentitytest"
+)
+
+func TestFakeClientReplaysResponsesInOrder(t *testing.T) {
+	errDenied := errors.New("denied")
+	token := ci.CommunicationIdentityAccessToken{Token: "token", ExpiresOn: time.Now()}
+	client := communicationidentitytest.NewFakeClient(
+		communicationidentitytest.FakeResponse{
+			AccessToken: token,
+			Identity:    ci.CommunicationIdentity{ID: "identity-1"},
+		},
+		communicationidentitytest.FakeResponse{Err: errDenied},
+		communicationidentitytest.FakeResponse{AccessToken: token},
+	)
+	ctx := context.Background()
+
+	result, err := client.CreateCommunicationIdentity(ctx, []string{"chat"}, nil)
+	if err != nil || result.Identity.ID != "identity-1" || result.AccessToken != token {
+		t.Errorf("expected the first response, got %+v, %v", result, err)
+	}
+	if _, err := client.TokenForTeamsUser(ctx, "oid", "msal"); !errors.Is(err, errDenied) {
+		t.Errorf("expected the error of the second response, got %v", err)
+	}
+	if got, err := client.IssueAccessToken(ctx, "identity-1", nil, nil); err != nil || got != token {
+		t.Errorf("expected the token of the third response, got %+v, %v", got, err)
+	}
+	if err := client.DeleteCommunicationIdentity(ctx, "identity-1"); err == nil {
+		t.Error("expected calls to fail once the responses are used up")
+	}
+}
+
+func TestFakeClientBatchTakesOneResponsePerRequest(t *testing.T) {
+	errDenied := errors.New("denied")
+	client := communicationidentitytest.NewFakeClient(
+		communicationidentitytest.FakeResponse{AccessToken: ci.CommunicationIdentityAccessToken{
+			Token: "chat",
+		}},
+		communicationidentitytest.FakeResponse{Err: errDenied},
+	)
+
+	tokens, err := client.IssueAccessTokenBatch(context.Background(), "identity-1", []ci.TokenRequest{
+		{Scopes: []ci.Scope{ci.ScopeChat}},
+		{Scopes: []ci.Scope{ci.ScopeVoIP}},
+	})
+	var multiErr *ci.MultiError
+	if !errors.As(err, &multiErr) || !errors.Is(err, errDenied) {
+		t.Errorf("expected a MultiError with the failed request, got %v", err)
+	}
+	if len(tokens) != 2 || tokens[0].Token != "chat" || tokens[1].Token != "" {
+		t.Errorf("expected the tokens aligned with the requests, got %+v", tokens)
+	}
+}
+
+func TestFakeClientKeepsResponsesForDoneContexts(t *testing.T) {
+	client := communicationidentitytest.NewFakeClient(communicationidentitytest.FakeResponse{})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := client.RevokeAccessTokens(ctx, "identity-1"); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the context error, got %v", err)
+	}
+	if err := client.RevokeAccessTokens(context.Background(), "identity-1"); err != nil {
+		t.Errorf("expected the response to be left for the next call, got %v", err)
+	}
+}
+
+func TestFakeClientFailsOnceClosed(t *testing.T) {
+	client := communicationidentitytest.NewFakeClient(
+		communicationidentitytest.FakeResponse{AccessToken: ci.CommunicationIdentityAccessToken{
+			Token: "teams",
+		}},
+		communicationidentitytest.FakeResponse{},
+	)
+	ctx := context.Background()
+
+	var body strings.Builder
+	if err := client.TokenForTeamsUserStream(ctx, "oid", "msal", &body); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(body.String(), `"teams"`) {
+		t.Errorf("expected the token to be written as JSON, got %s", body.String())
+	}
+	if err := client.RotateAccessKey("key"); err != nil {
+		t.Errorf("expected the rotation to succeed, got %v", err)
+	}
+	if err := client.Close(); err != nil {
+		t.Fatal(err)
+	}
+	_, err := client.IssueAccessToken(ctx, "identity-1", nil, nil)
+	if !errors.Is(err, ci.ErrClientClosed) {
+		t.Errorf("expected ErrClientClosed, got %v", err)
+	}
+	if err := client.RotateAccessKey("key"); !errors.Is(err, ci.ErrClientClosed) {
+		t.Errorf("expected ErrClientClosed from the rotation, got %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+// Test helpers for code depending on [communicationidentity].
+package communicationidentitytest
+
+import (
+	"sync"
+	"time"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+)
+
+var _ ci.TimeSource = (*MockClock)(nil)
+
+// Manually controlled [ci.TimeSource] for deterministic tests of time dependent behaviour.
+// Time only moves through [MockClock.Advance] and [MockClock.Set], channels returned by
+// [MockClock.After] fire once the clock has been moved past their deadline.
+//
+// Safe for concurrent use.
+type MockClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []mockClockWaiter
+}
+
+type mockClockWaiter struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+func NewMockClock(initial time.Time) *MockClock {
+	return &MockClock{now: initial}
+}
+
+func (clock *MockClock) Now() time.Time {
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+	return clock.now
+}
+
+// After behaves like [time.After] on the mocked time, a non-positive duration fires immediately
+func (clock *MockClock) After(d time.Duration) <-chan time.Time {
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+
+	// buffered, so publishing never blocks on receivers that stopped listening
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- clock.now
+		return ch
+	}
+	clock.waiters = append(clock.waiters, mockClockWaiter{clock.now.Add(d), ch})
+	return ch
+}
+
+// Advance moves the clock forward by d and publishes the new time to all due [MockClock.After]
+// channels
+func (clock *MockClock) Advance(d time.Duration) {
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+	clock.setLocked(clock.now.Add(d))
+}
+
+// Set moves the clock to t and publishes the new time to all due [MockClock.After] channels.
+// Moving the clock backwards is allowed, but never un-fires a channel.
+func (clock *MockClock) Set(t time.Time) {
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+	clock.setLocked(t)
+}
+
+func (clock *MockClock) setLocked(t time.Time) {
+	clock.now = t
+	pending := clock.waiters[:0]
+	for _, waiter := range clock.waiters {
+		if waiter.deadline.After(t) {
+			pending = append(pending, waiter)
+			continue
+		}
+		waiter.ch <- t
+	}
+	clock.waiters = pending
+}
@@ -0,0 +1,64 @@
+package communicationidentitytest_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jls-ch/azure-communication-identity-go/communicationidentitytest"
+)
+
+func TestMockClockAdvanceUnblocksAfter(t *testing.T) {
+	start := time.Date(2025, 6, 30, 12, 0, 0, 0, time.UTC)
+	clock := communicationidentitytest.NewMockClock(start)
+
+	fired := make(chan time.Time)
+	ch := clock.After(time.Minute)
+	go func() { fired <- <-ch }()
+
+	clock.Advance(30 * time.Second)
+	select {
+	case <-fired:
+		t.Fatal("channel fired before its deadline")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	clock.Advance(30 * time.Second)
+	select {
+	case got := <-fired:
+		if want := start.Add(time.Minute); !got.Equal(want) {
+			t.Errorf("expected published time %v, got %v", want, got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("channel did not fire after advancing past its deadline")
+	}
+}
+
+func TestMockClockSet(t *testing.T) {
+	start := time.Date(2025, 6, 30, 12, 0, 0, 0, time.UTC)
+	clock := communicationidentitytest.NewMockClock(start)
+	ch := clock.After(time.Hour)
+
+	target := start.Add(2 * time.Hour)
+	clock.Set(target)
+
+	if got := clock.Now(); !got.Equal(target) {
+		t.Errorf("expected %v, got %v", target, got)
+	}
+	select {
+	case got := <-ch:
+		if !got.Equal(target) {
+			t.Errorf("expected published time %v, got %v", target, got)
+		}
+	default:
+		t.Fatal("channel did not fire after setting the clock past its deadline")
+	}
+}
+
+func TestMockClockNonPositiveAfterFiresImmediately(t *testing.T) {
+	clock := communicationidentitytest.NewMockClock(time.Now())
+	select {
+	case <-clock.After(0):
+	default:
+		t.Fatal("expected zero duration to fire immediately")
+	}
+}
@@ -0,0 +1,159 @@
+package communicationidentity
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	connectionStringEndpoint  = "endpoint"
+	connectionStringAccessKey = "accesskey"
+)
+
+// parseConnectionString splits an ACS connection string (`endpoint=https://…;accesskey=…`),
+// key names are case-insensitive
+func parseConnectionString(connStr string) (*url.URL, string, error) {
+	var rawEndpoint, accessKey string
+	for i, part := range strings.Split(connStr, ";") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		key, value, found := strings.Cut(part, "=")
+		if !found {
+			// the part is not quoted, it might be a pasted access key
+			return nil, "", fmt.Errorf("connection string part %d is not a key=value pair", i)
+		}
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case connectionStringEndpoint:
+			rawEndpoint = strings.TrimSpace(value)
+		case connectionStringAccessKey:
+			accessKey = strings.TrimSpace(value)
+		}
+	}
+	if rawEndpoint == "" {
+		return nil, "", fmt.Errorf("connection string has no %q", connectionStringEndpoint)
+	}
+	if accessKey == "" {
+		return nil, "", fmt.Errorf("connection string has no %q", connectionStringAccessKey)
+	}
+	endpoint, err := url.Parse(rawEndpoint)
+	if err != nil {
+		return nil, "", fmt.Errorf("connection string endpoint is not a valid URL: %w", err)
+	}
+	return endpoint, accessKey, nil
+}
+
+// NewFromConnectionString creates a client from an ACS connection string as shown in the Azure
+// portal (`endpoint=https://…;accesskey=…`), key names are case-insensitive. The endpoint must be
+// an HTTPS URL, opts are applied as for [New].
+func NewFromConnectionString(
+	connStr string,
+	azClientId string,
+	opts ...ClientOption,
+) (CommunicationIdentityClient, error) {
+	endpoint, accessKey, err := parseConnectionString(connStr)
+	if err != nil {
+		return CommunicationIdentityClient{}, fmt.Errorf("failed to parse connection string: %w", err)
+	}
+	if endpoint.Scheme != "https" || endpoint.Host == "" {
+		return CommunicationIdentityClient{}, &ValidationError{
+			Field: "endpoint",
+			Value: endpoint.String(),
+			Err:   fmt.Errorf("connection string endpoint must be an HTTPS URL"),
+		}
+	}
+	return New(endpoint, accessKey, azClientId, opts...)
+}
+
+// ACS connection string (`endpoint=https://…;accesskey=…`) which does not leak its access key
+// when printed, marshaled or logged, the key is replaced by `accesskey=<redacted>`.
+//
+// The original value is only available through [MaskedConnectionString.Unmask].
+type MaskedConnectionString string
+
+// MaskConnectionString wraps connStr so it can be safely passed to loggers
+func MaskConnectionString(connStr string) MaskedConnectionString {
+	return MaskedConnectionString(connStr)
+}
+
+func (connStr MaskedConnectionString) String() string {
+	parts := strings.Split(string(connStr), ";")
+	for i, part := range parts {
+		key, _, found := strings.Cut(part, "=")
+		if found && strings.EqualFold(strings.TrimSpace(key), connectionStringAccessKey) {
+			parts[i] = connectionStringAccessKey + "=<redacted>"
+		}
+	}
+	return strings.Join(parts, ";")
+}
+
+// GoString masks the access key for the `%#v` verb as well
+func (connStr MaskedConnectionString) GoString() string {
+	return strconv.Quote(connStr.String())
+}
+
+// MarshalText masks the access key for encoders, e.g. JSON and [log/slog] handlers
+func (connStr MaskedConnectionString) MarshalText() ([]byte, error) {
+	return []byte(connStr.String()), nil
+}
+
+// Unmask returns the original connection string, but only if the environment variable envVar
+// is set to a true value as understood by [strconv.ParseBool]. This makes unmasking an explicit
+// decision of the deployment instead of the code.
+func (connStr MaskedConnectionString) Unmask(envVar string) (string, error) {
+	value, isSet := os.LookupEnv(envVar)
+	if !isSet {
+		return "", fmt.Errorf(
+			"unmasking is not allowed, environment variable %q is not set",
+			envVar,
+		)
+	}
+	allowed, err := strconv.ParseBool(value)
+	if err != nil {
+		return "", fmt.Errorf("environment variable %q is not a boolean: %w", envVar, err)
+	}
+	if !allowed {
+		return "", fmt.Errorf("unmasking is disabled by environment variable %q", envVar)
+	}
+	return string(connStr), nil
+}
+
+// WithConnectionString takes the endpoint and access key of the client from connStr, [New] must
+// then be called with a nil endpoint and an empty access key. Setting them in both places is
+// reported as configuration error by [New].
+func WithConnectionString(connStr string) ClientOption {
+	return func(options *clientOptions) error {
+		if options.connectionEndpoint != nil {
+			return fmt.Errorf("connection string is set more than once")
+		}
+		endpoint, accessKey, err := parseConnectionString(connStr)
+		if err != nil {
+			return fmt.Errorf("failed to parse connection string: %w", err)
+		}
+		options.connectionEndpoint = endpoint
+		options.connectionAccessKey = accessKey
+		return nil
+	}
+}
+
+// credentials returns the endpoint and access key passed to New or set by WithConnectionString
+func (options *clientOptions) credentials(
+	acsEndpoint *url.URL,
+	acsAccessKey string,
+) (*url.URL, string, error) {
+	if options.connectionEndpoint == nil {
+		return acsEndpoint, acsAccessKey, nil
+	}
+	if acsEndpoint != nil || acsAccessKey != "" {
+		return nil, "", &ValidationError{
+			Field: "connectionString",
+			Err: fmt.Errorf(
+				"endpoint and access key must not be passed to New with WithConnectionString",
+			),
+		}
+	}
+	return options.connectionEndpoint, options.connectionAccessKey, nil
+}
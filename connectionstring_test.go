@@ -0,0 +1,176 @@
+package communicationidentity_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+)
+
+const testConnectionString = "endpoint=https://example.communication.azure.com/;accesskey=c2VjcmV0"
+
+func TestMaskConnectionString(t *testing.T) {
+	masked := ci.MaskConnectionString(testConnectionString)
+	want := "endpoint=https://example.communication.azure.com/;accesskey=<redacted>"
+
+	formatted := []string{masked.String(), fmt.Sprintf("%v", masked), fmt.Sprintf("%#v", masked)}
+	marshaled, err := json.Marshal(masked)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var unmarshaled string
+	if err := json.Unmarshal(marshaled, &unmarshaled); err != nil {
+		t.Fatal(err)
+	}
+	formatted = append(formatted, unmarshaled)
+
+	for _, out := range formatted {
+		if strings.Contains(out, "c2VjcmV0") {
+			t.Errorf("access key leaked: %s", out)
+		}
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in %q", want, out)
+		}
+	}
+}
+
+func TestMaskConnectionStringCaseInsensitiveKey(t *testing.T) {
+	masked := ci.MaskConnectionString("Endpoint=https://example.com;AccessKey=c2VjcmV0")
+	if strings.Contains(masked.String(), "c2VjcmV0") {
+		t.Errorf("access key leaked: %s", masked)
+	}
+}
+
+func TestUnmaskConnectionString(t *testing.T) {
+	const envVar = "TEST_ACS_ALLOW_UNMASK"
+	masked := ci.MaskConnectionString(testConnectionString)
+
+	if _, err := masked.Unmask(envVar); err == nil {
+		t.Error("expected unmasking to fail without environment variable")
+	}
+
+	t.Setenv(envVar, "false")
+	if _, err := masked.Unmask(envVar); err == nil {
+		t.Error("expected unmasking to fail when disabled")
+	}
+
+	t.Setenv(envVar, "true")
+	unmasked, err := masked.Unmask(envVar)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unmasked != testConnectionString {
+		t.Errorf("expected original connection string, got %q", unmasked)
+	}
+}
+
+func TestWithConnectionString(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(createIdentityHandler(&calls))
+	t.Cleanup(server.Close)
+	client, err := ci.New(nil, "", "",
+		ci.WithConnectionString("endpoint="+server.URL+";accesskey="+testAccessKey),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CreateCommunicationIdentity(
+		context.Background(), []string{"chat"}, nil,
+	); err != nil {
+		t.Fatal(err)
+	}
+	if calls.Load() != 1 {
+		t.Error("expected endpoint of the connection string to be used")
+	}
+}
+
+func TestWithConnectionStringConflicts(t *testing.T) {
+	endpoint, _ := url.Parse("https://example.communication.azure.com")
+	opt := ci.WithConnectionString(testConnectionString)
+	cases := map[string]func() error{
+		"endpoint passed to New": func() error {
+			_, err := ci.New(endpoint, "", "", opt)
+			return err
+		},
+		"access key passed to New": func() error {
+			_, err := ci.New(nil, testAccessKey, "", opt)
+			return err
+		},
+		"set twice": func() error {
+			_, err := ci.New(nil, "", "", opt, opt)
+			return err
+		},
+		"invalid": func() error {
+			_, err := ci.New(nil, "", "", ci.WithConnectionString("endpoint=x"))
+			return err
+		},
+	}
+	for name, build := range cases {
+		t.Run(name, func(t *testing.T) {
+			if err := build(); err == nil {
+				t.Error("expected configuration error")
+			}
+		})
+	}
+}
+
+func TestNewFromConnectionString(t *testing.T) {
+	cases := []struct {
+		name    string
+		connStr string
+		valid   bool
+	}{
+		{"valid", testConnectionString, true},
+		{"case-insensitive keys", "Endpoint=https://example.com/;AccessKey=c2VjcmV0", true},
+		{"malformed part", "endpoint=https://example.com/;accesskey", false},
+		{"missing endpoint", "accesskey=c2VjcmV0", false},
+		{"missing access key", "endpoint=https://example.com/", false},
+		{"http endpoint", "endpoint=http://example.com/;accesskey=c2VjcmV0", false},
+		{"endpoint without host", "endpoint=https://;accesskey=c2VjcmV0", false},
+		{"empty", "", false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := ci.NewFromConnectionString(c.connStr, "")
+			if c.valid && err != nil {
+				t.Errorf("expected %q to be accepted, got: %v", c.connStr, err)
+			}
+			if !c.valid && err == nil {
+				t.Errorf("expected %q to be rejected", c.connStr)
+			}
+		})
+	}
+}
+
+func TestNewFromConnectionStringAppliesOptions(t *testing.T) {
+	client, err := ci.NewFromConnectionString(
+		testConnectionString,
+		"",
+		ci.WithStickySession("session-1"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.SessionID() != "session-1" {
+		t.Errorf("expected options to be applied, got session ID %q", client.SessionID())
+	}
+}
+
+func TestNewFromConnectionStringDoesNotLeakMalformedParts(t *testing.T) {
+	_, err := ci.NewFromConnectionString("endpoint=https://example.com;c2VjcmV0", "")
+	if err == nil {
+		t.Fatal("expected error for part without key")
+	}
+	if strings.Contains(err.Error(), "c2VjcmV0") {
+		t.Errorf("malformed part leaked: %v", err)
+	}
+	if !strings.Contains(err.Error(), "part 1") {
+		t.Errorf("expected the index of the malformed part, got %v", err)
+	}
+}
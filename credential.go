@@ -0,0 +1,92 @@
+package communicationidentity
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// default scope of Entra ID tokens for ACS, see WithTokenScope
+const acsTokenScope = "https://communication.azure.com/.default"
+
+// TokenCredential provides Entra ID (Azure AD) access tokens, e.g. of a managed identity or a
+// service principal. It has the shape of the azcore TokenCredential, azidentity credentials are
+// adapted by converting the options and the returned token.
+type TokenCredential interface {
+	GetToken(ctx context.Context, opts TokenRequestOptions) (AccessToken, error)
+}
+
+// TokenRequestOptions are passed to [TokenCredential.GetToken]
+type TokenRequestOptions struct {
+	// scopes the token is requested for, "https://communication.azure.com/.default" for ACS
+	Scopes []string
+}
+
+// AccessToken is an Entra ID access token returned by a [TokenCredential]
+type AccessToken struct {
+	Token     string
+	ExpiresOn time.Time
+}
+
+// WithTokenCredential authenticates requests with Bearer tokens of cred instead of signing them
+// with the access key. [New] must then be called with an empty access key, using both is reported
+// as configuration error. See also [NewWithTokenCredential].
+func WithTokenCredential(cred TokenCredential) ClientOption {
+	return func(options *clientOptions) error {
+		if cred == nil {
+			return fmt.Errorf("token credential can not be nil")
+		}
+		options.tokenCredential = cred
+		return nil
+	}
+}
+
+// WithTokenScope requests the tokens of the [TokenCredential] for scope instead of
+// "https://communication.azure.com/.default", e.g. for sovereign clouds
+func WithTokenScope(scope string) ClientOption {
+	return func(options *clientOptions) error {
+		if scope == "" {
+			return fmt.Errorf("token scope can not be empty")
+		}
+		options.tokenScope = scope
+		return nil
+	}
+}
+
+// NewWithTokenCredential creates a client authenticating with Entra ID tokens of cred, e.g. of a
+// managed identity or a service principal. opts are applied as for [New].
+func NewWithTokenCredential(
+	endpoint *url.URL,
+	cred TokenCredential,
+	opts ...ClientOption,
+) (CommunicationIdentityClient, error) {
+	return New(endpoint, "", "", append([]ClientOption{WithTokenCredential(cred)}, opts...)...)
+}
+
+// validateAuthentication rejects clients configured with more than one authentication mode
+func (options *clientOptions) validateAuthentication(acsAccessKey string) error {
+	modes := 0
+	for _, configured := range []bool{
+		acsAccessKey != "" || options.keyManager != nil,
+		options.tokenCredential != nil,
+		options.requestSigner != nil,
+	} {
+		if configured {
+			modes++
+		}
+	}
+	if modes > 1 {
+		return fmt.Errorf(
+			"access key, token credential and request signer authentication are mutually exclusive",
+		)
+	}
+	return nil
+}
+
+func (client CommunicationIdentityClient) tokenScope() string {
+	if client.options.tokenScope == "" {
+		return acsTokenScope
+	}
+	return client.options.tokenScope
+}
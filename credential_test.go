@@ -0,0 +1,142 @@
+package communicationidentity_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+)
+
+// staticCredential returns token and records the scopes of its calls
+type staticCredential struct {
+	token  string
+	err    error
+	scopes [][]string
+}
+
+func (cred *staticCredential) GetToken(
+	ctx context.Context,
+	opts ci.TokenRequestOptions,
+) (ci.AccessToken, error) {
+	cred.scopes = append(cred.scopes, opts.Scopes)
+	if cred.err != nil {
+		return ci.AccessToken{}, cred.err
+	}
+	return ci.AccessToken{Token: cred.token, ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+func TestNewWithTokenCredential(t *testing.T) {
+	var authorization, contentHash string
+	create := createIdentityHandler(new(atomic.Int32))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		contentHash = r.Header.Get("x-ms-content-sha256")
+		create(w, r)
+	}))
+	t.Cleanup(server.Close)
+	endpoint, _ := url.Parse(server.URL)
+
+	cred := &staticCredential{token: "entra-token"}
+	client, err := ci.NewWithTokenCredential(endpoint, cred)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CreateCommunicationIdentity(
+		context.Background(), []string{"chat"}, nil,
+	); err != nil {
+		t.Fatal(err)
+	}
+	if authorization != "Bearer entra-token" || contentHash != "" {
+		t.Errorf("expected only a Bearer token, got %q and hash %q", authorization, contentHash)
+	}
+	want := [][]string{{"https://communication.azure.com/.default"}}
+	if !slices.EqualFunc(cred.scopes, want, slices.Equal) {
+		t.Errorf("expected the token to be requested for %v, got %v", want, cred.scopes)
+	}
+}
+
+func TestTokenCredentialErrorsAreReturned(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(createIdentityHandler(&calls))
+	t.Cleanup(server.Close)
+	endpoint, _ := url.Parse(server.URL)
+
+	errNoToken := errors.New("no token")
+	client, err := ci.NewWithTokenCredential(endpoint, &staticCredential{err: errNoToken})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.CreateCommunicationIdentity(context.Background(), []string{"chat"}, nil)
+	if !errors.Is(err, errNoToken) {
+		t.Errorf("expected the credential error, got: %v", err)
+	}
+	if calls.Load() != 0 {
+		t.Errorf("expected no request without token, got %d", calls.Load())
+	}
+}
+
+func TestTokenCredentialAndAccessKeyAreExclusive(t *testing.T) {
+	endpoint, _ := url.Parse("https://myresource.communication.azure.com")
+	cred := &staticCredential{token: "entra-token"}
+	if _, err := ci.New(endpoint, testAccessKey, "", ci.WithTokenCredential(cred)); err == nil {
+		t.Error("expected an error for an access key and a token credential")
+	}
+	connStr := "endpoint=" + endpoint.String() + ";accesskey=" + testAccessKey
+	if _, err := ci.NewWithTokenCredential(nil, cred, ci.WithConnectionString(connStr)); err == nil {
+		t.Error("expected an error for a connection string and a token credential")
+	}
+	if _, err := ci.NewWithTokenCredential(endpoint, nil); err == nil {
+		t.Error("expected an error for a nil token credential")
+	}
+}
+
+func TestWithTokenScope(t *testing.T) {
+	server := httptest.NewServer(createIdentityHandler(new(atomic.Int32)))
+	t.Cleanup(server.Close)
+	endpoint, _ := url.Parse(server.URL)
+
+	cred := &staticCredential{token: "entra-token"}
+	client, err := ci.NewWithTokenCredential(
+		endpoint,
+		cred,
+		ci.WithTokenScope("https://communication.azure.us/.default"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CreateCommunicationIdentity(
+		context.Background(), []string{"chat"}, nil,
+	); err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"https://communication.azure.us/.default"}}
+	if !slices.EqualFunc(cred.scopes, want, slices.Equal) {
+		t.Errorf("expected the token to be requested for %v, got %v", want, cred.scopes)
+	}
+
+	if _, err := ci.NewWithTokenCredential(endpoint, cred, ci.WithTokenScope("")); err == nil {
+		t.Error("expected an error for an empty token scope")
+	}
+}
+
+func TestNewFromManagedIdentity(t *testing.T) {
+	endpoint, _ := url.Parse("https://myresource.communication.azure.com")
+	if _, err := ci.NewFromManagedIdentity(endpoint, ""); err != nil {
+		t.Fatal(err)
+	}
+	_, err := ci.NewFromManagedIdentity(
+		endpoint,
+		"",
+		ci.WithRotatingKeyManager(&ci.RotatingKeyManager{}),
+	)
+	if err == nil {
+		t.Error("expected an error for a rotating key manager")
+	}
+}
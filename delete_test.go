@@ -0,0 +1,54 @@
+package communicationidentity_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+)
+
+func TestDeleteCommunicationIdentity(t *testing.T) {
+	var method, path string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.EscapedPath()
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	if err := client.DeleteCommunicationIdentity(context.Background(), testIdentityID); err != nil {
+		t.Fatal(err)
+	}
+	if method != http.MethodDelete {
+		t.Errorf("expected DELETE, got %s", method)
+	}
+	if want := "/identities/8:acs:b6aada1f-0b1d-47ac-866f-91aae00a1d01_" +
+		"00000005-4ad5-d0b4-6a0b-343a0d00ab6c"; path != want {
+		t.Errorf("expected path %s, got %s", want, path)
+	}
+}
+
+func TestDeleteCommunicationIdentityErrors(t *testing.T) {
+	cases := []struct {
+		status int
+		code   string
+	}{
+		{http.StatusNotFound, "IdentityNotFound"},
+		{http.StatusForbidden, "Forbidden"},
+	}
+	for _, c := range cases {
+		t.Run(c.code, func(t *testing.T) {
+			body := `{"error":{"code":"` + c.code + `","message":"failed"}}`
+			client := newTestClient(t, errorHandler(c.status, "request-1", body))
+
+			err := client.DeleteCommunicationIdentity(context.Background(), testIdentityID)
+			var identityErr *ci.CommunicationIdentityError
+			if !errors.As(err, &identityErr) {
+				t.Fatalf("expected CommunicationIdentityError, got %v", err)
+			}
+			if identityErr.StatusCode != c.status || identityErr.ACSError.Code != c.code {
+				t.Errorf("unexpected error: %+v", identityErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package communicationidentity
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+)
+
+// background resolution of the ACS endpoint host, see WithFastStart
+type dnsWarmup struct {
+	done chan struct{}
+	err  error
+}
+
+// WithFastStart resolves the ACS endpoint hostname in the background while [New] returns
+// immediately, [CommunicationIdentityClient.WaitForDNS] reports the outcome.
+//
+// NOTE: Go does not cache DNS results itself, the first request only benefits if the system
+// resolver caches them. The main use is failing fast at startup on endpoints that do not resolve.
+func WithFastStart() ClientOption {
+	return func(options *clientOptions) error {
+		options.fastStart = true
+		return nil
+	}
+}
+
+// WithEndpointResolution resolves the ACS endpoint hostname in [New], construction fails if the
+// endpoint is not set or does not resolve. Unlike [WithFastStart] New blocks until the hostname is
+// resolved, in parallel to the other initialization with [WithConcurrentInitialization].
+func WithEndpointResolution() ClientOption {
+	return func(options *clientOptions) error {
+		options.addInitStep(func(acsEndpoint *url.URL) error {
+			if acsEndpoint == nil {
+				return fmt.Errorf("client has no ACS endpoint to resolve")
+			}
+			return lookupHost(context.Background(), acsEndpoint.Hostname())
+		})
+		return nil
+	}
+}
+
+func startDNSWarmup(host string) *dnsWarmup {
+	warmup := &dnsWarmup{done: make(chan struct{})}
+	go func() {
+		defer close(warmup.done)
+		warmup.err = lookupHost(context.Background(), host)
+	}()
+	return warmup
+}
+
+func lookupHost(ctx context.Context, host string) error {
+	if _, err := net.DefaultResolver.LookupHost(ctx, host); err != nil {
+		return fmt.Errorf("failed to resolve ACS endpoint host %q: %w", host, err)
+	}
+	return nil
+}
+
+// WaitForDNS waits until the background resolution started by [WithFastStart] completed and
+// returns its error. Without [WithFastStart], the hostname is resolved synchronously. A client
+// without ACS endpoint reports an error.
+func (client CommunicationIdentityClient) WaitForDNS(ctx context.Context) error {
+	if client.acsEndpoint == nil {
+		return fmt.Errorf("client has no ACS endpoint to resolve")
+	}
+	warmup := client.state.dnsWarmup
+	if warmup == nil {
+		return lookupHost(ctx, client.acsEndpoint.Hostname())
+	}
+	select {
+	case <-warmup.done:
+		return warmup.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,60 @@
+package communicationidentity_test
+
+import (
+	"context"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+)
+
+func TestWaitForDNS(t *testing.T) {
+	fastStart := newTestClient(t, createIdentityHandler(new(atomic.Int32)), ci.WithFastStart())
+	if err := fastStart.WaitForDNS(context.Background()); err != nil {
+		t.Errorf("expected test server host to resolve, got %v", err)
+	}
+
+	synchronous := newTestClient(t, createIdentityHandler(new(atomic.Int32)))
+	if err := synchronous.WaitForDNS(context.Background()); err != nil {
+		t.Errorf("expected test server host to resolve, got %v", err)
+	}
+}
+
+func TestWaitForDNSRespectsContext(t *testing.T) {
+	endpoint, _ := url.Parse("https://example.communication.azure.com")
+	client, err := ci.New(endpoint, testAccessKey, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := client.WaitForDNS(ctx); err == nil {
+		t.Error("expected error for cancelled context")
+	}
+}
+
+func TestWaitForDNSWithoutEndpoint(t *testing.T) {
+	client, err := ci.New(nil, testAccessKey, "", ci.WithFastStart())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.WaitForDNS(context.Background()); err == nil {
+		t.Error("expected error for client without endpoint")
+	}
+}
+
+func TestWithEndpointResolution(t *testing.T) {
+	endpoint, _ := url.Parse("https://127.0.0.1")
+	for name, opts := range map[string][]ci.ClientOption{
+		"sequential": {ci.WithEndpointResolution()},
+		"concurrent": {ci.WithEndpointResolution(), ci.WithConcurrentInitialization()},
+	} {
+		if _, err := ci.New(endpoint, testAccessKey, "", opts...); err != nil {
+			t.Errorf("%s: expected endpoint to resolve, got %v", name, err)
+		}
+	}
+	if _, err := ci.New(nil, testAccessKey, "", ci.WithEndpointResolution()); err == nil {
+		t.Error("expected error for client without endpoint")
+	}
+}
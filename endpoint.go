@@ -0,0 +1,131 @@
+package communicationidentity
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+const acsDefaultDomain = "communication.azure.com"
+
+// NormalizeCommunicationEndpoint turns the different endpoint notations into the canonical
+// HTTPS URL of an ACS resource:
+//
+//   - resource name: "myresource" becomes "https://myresource.communication.azure.com"
+//   - hostname: "myresource.communication.azure.com" gains the "https://" scheme
+//   - full URL: "https://myresource.communication.azure.com/" is kept, sans trailing slash
+//
+// Hosts are lowercased, non-HTTPS URLs and URLs with credentials, query or fragment are rejected.
+func NormalizeCommunicationEndpoint(raw string) (*url.URL, error) {
+	trimmed := strings.TrimSpace(raw)
+	if trimmed == "" {
+		return nil, fmt.Errorf("endpoint can not be empty")
+	}
+
+	if !strings.ContainsAny(trimmed, "./:") {
+		if !isResourceName(trimmed) {
+			return nil, fmt.Errorf(
+				"resource name %q may only contain letters, digits and hyphens",
+				trimmed,
+			)
+		}
+		trimmed = fmt.Sprintf("%s.%s", trimmed, acsDefaultDomain)
+	}
+	if !strings.Contains(trimmed, "://") {
+		trimmed = "https://" + trimmed
+	}
+
+	endpoint, err := url.Parse(trimmed)
+	if err != nil {
+		return nil, fmt.Errorf("endpoint %q is not a valid URL: %w", raw, err)
+	}
+	if endpoint.Scheme != "https" {
+		return nil, fmt.Errorf("endpoint %q must use https, got %q", raw, endpoint.Scheme)
+	}
+	if endpoint.Hostname() == "" {
+		return nil, fmt.Errorf("endpoint %q has no host", raw)
+	}
+	if endpoint.User != nil || endpoint.RawQuery != "" || endpoint.Fragment != "" {
+		return nil, fmt.Errorf("endpoint %q must not contain credentials, query or fragment", raw)
+	}
+
+	endpoint.Host = strings.ToLower(endpoint.Host)
+	endpoint.Path = strings.TrimRight(endpoint.Path, "/")
+	endpoint.RawPath = ""
+	return endpoint, nil
+}
+
+// validateEndpoint rejects endpoints signed requests would be sent to unencrypted. Loopback hosts
+// may use http, e.g. for local emulators and test servers.
+func validateEndpoint(endpoint *url.URL) error {
+	host := endpoint.Hostname()
+	if host == "" {
+		return fmt.Errorf("endpoint %q has no host", endpoint.Redacted())
+	}
+	if endpoint.Scheme == "https" || endpoint.Scheme == "http" && isLoopback(host) {
+		return nil
+	}
+	return fmt.Errorf(
+		"endpoint %q uses scheme %q, use https to not send signed requests unencrypted",
+		endpoint.Redacted(),
+		endpoint.Scheme,
+	)
+}
+
+// trimEndpointPath returns a copy of endpoint without trailing slashes, endpoint paths are joined
+// with the paths of the ACS API
+func trimEndpointPath(endpoint *url.URL) *url.URL {
+	trimmed := *endpoint
+	trimmed.Path = strings.TrimRight(endpoint.Path, "/")
+	trimmed.RawPath = strings.TrimRight(endpoint.RawPath, "/")
+	return &trimmed
+}
+
+func isLoopback(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func isResourceName(name string) bool {
+	for _, r := range name {
+		isValid := (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') ||
+			(r >= '0' && r <= '9') || r == '-'
+		if !isValid {
+			return false
+		}
+	}
+	return true
+}
+
+// region of ACS endpoints without a region segment
+const acsGlobalRegion = "global"
+
+// DetectACSRegion returns the region segment of an ACS endpoint hostname in lowercase, e.g.
+// "eastus" for "myresource.eastus.communication.azure.com", or "global" for endpoints without
+// one like "myresource.communication.azure.com". Hosts outside of the ACS domain are rejected.
+func DetectACSRegion(endpoint *url.URL) (string, error) {
+	if endpoint == nil {
+		return "", fmt.Errorf("endpoint can not be nil")
+	}
+	host := strings.ToLower(endpoint.Hostname())
+	prefix, found := strings.CutSuffix(host, "."+acsDefaultDomain)
+	if !found || prefix == "" {
+		return "", fmt.Errorf("endpoint host %q is not an ACS endpoint", host)
+	}
+	labels := strings.Split(prefix, ".")
+	switch len(labels) {
+	case 1:
+		return acsGlobalRegion, nil
+	case 2:
+		if labels[1] == "" || !isResourceName(labels[1]) {
+			return "", fmt.Errorf("endpoint host %q has an invalid region segment", host)
+		}
+		return labels[1], nil
+	default:
+		return "", fmt.Errorf("endpoint host %q has more than one region segment", host)
+	}
+}
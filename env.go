@@ -0,0 +1,44 @@
+package communicationidentity
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+)
+
+// Environment variables read by [NewFromEnv]
+const (
+	// ACS resource endpoint, e.g. "https://my-resource.communication.azure.com"
+	EnvACSEndpoint = "ACS_ENDPOINT"
+	// base64 encoded access key of the ACS resource as shown in the Azure portal
+	EnvACSAccessKey = "ACS_ACCESS_KEY"
+)
+
+// NewFromEnv creates a client from the endpoint and access key in the environment variables
+// [EnvACSEndpoint] and [EnvACSAccessKey], opts are applied as for [New]
+func NewFromEnv(azClientId string, opts ...ClientOption) (CommunicationIdentityClient, error) {
+	rawEndpoint, err := lookupEnv(EnvACSEndpoint)
+	if err != nil {
+		return CommunicationIdentityClient{}, err
+	}
+	accessKey, err := lookupEnv(EnvACSAccessKey)
+	if err != nil {
+		return CommunicationIdentityClient{}, err
+	}
+	endpoint, err := url.Parse(rawEndpoint)
+	if err != nil {
+		return CommunicationIdentityClient{}, err
+	}
+	return New(endpoint, accessKey, azClientId, opts...)
+}
+
+func lookupEnv(name string) (string, error) {
+	value, isSet := os.LookupEnv(name)
+	if !isSet {
+		return "", fmt.Errorf("environment variable %q is not set", name)
+	}
+	if value == "" {
+		return "", fmt.Errorf("environment variable %q is empty", name)
+	}
+	return value, nil
+}
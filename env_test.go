@@ -0,0 +1,65 @@
+package communicationidentity_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+)
+
+func TestNewFromEnv(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(createIdentityHandler(&calls))
+	t.Cleanup(server.Close)
+	t.Setenv(ci.EnvACSEndpoint, server.URL)
+	t.Setenv(ci.EnvACSAccessKey, testAccessKey)
+
+	client, err := ci.NewFromEnv("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CreateCommunicationIdentity(
+		context.Background(), []string{"chat"}, nil,
+	); err != nil {
+		t.Fatal(err)
+	}
+	if calls.Load() != 1 {
+		t.Error("expected endpoint of the environment to be used")
+	}
+}
+
+func TestNewFromEnvMissingVariable(t *testing.T) {
+	for _, missing := range []string{ci.EnvACSEndpoint, ci.EnvACSAccessKey} {
+		t.Run(missing, func(t *testing.T) {
+			t.Setenv(ci.EnvACSEndpoint, "https://example.communication.azure.com")
+			t.Setenv(ci.EnvACSAccessKey, testAccessKey)
+			t.Setenv(missing, "")
+
+			_, err := ci.NewFromEnv("")
+			if err == nil || !strings.Contains(err.Error(), missing+`" is empty`) {
+				t.Errorf("expected error naming empty %s, got: %v", missing, err)
+			}
+
+			// t.Setenv restores the variable after the test
+			if err := os.Unsetenv(missing); err != nil {
+				t.Fatal(err)
+			}
+			_, err = ci.NewFromEnv("")
+			if err == nil || !strings.Contains(err.Error(), missing+`" is not set`) {
+				t.Errorf("expected error naming unset %s, got: %v", missing, err)
+			}
+		})
+	}
+}
+
+func TestNewFromEnvInvalidAccessKey(t *testing.T) {
+	t.Setenv(ci.EnvACSEndpoint, "https://example.communication.azure.com")
+	t.Setenv(ci.EnvACSAccessKey, "not base64!")
+	if _, err := ci.NewFromEnv(""); err == nil {
+		t.Error("expected error for invalid access key")
+	}
+}
@@ -0,0 +1,330 @@
+package communicationidentity
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const msRequestIDHeader = "x-ms-request-id"
+
+// Sentinels for common ACS error codes, matched by [errors.Is] against the ACS error and its inner
+// errors of any error returned by a client method, see [CommunicationError]:
+//
+//	notFound := errors.Is(err, ErrIdentityNotFound)
+var (
+	// sent with status 401 Unauthorized if the request is not signed with a valid access key
+	ErrUnauthorized = &CommunicationError{Code: "Unauthorized"}
+	// sent with status 401 Unauthorized if a token used for the request has expired, e.g. the
+	// Teams token of [CommunicationIdentityClient.TokenForTeamsUser]
+	ErrTokenExpired = &CommunicationError{Code: "TokenExpired"}
+	// sent with status 404 Not Found if the identity does not exist or has been deleted
+	ErrIdentityNotFound = &CommunicationError{Code: "IdentityNotFound"}
+)
+
+// Error returned by all client methods talking to ACS. Either the request failed locally or on
+// the wire (TransportError, StatusCode is 0) or ACS answered with an unexpected status
+// (StatusCode set, ACSError set if the response body could be parsed).
+//
+// [errors.Is] matches against a *CommunicationIdentityError target by comparing all of its non-zero
+// fields StatusCode, RequestID and ACSError.Code, so
+//
+//	errors.Is(err, &CommunicationIdentityError{StatusCode: http.StatusNotFound})
+//
+// holds for any not found response.
+type CommunicationIdentityError struct {
+	StatusCode     int
+	ACSError       *CommunicationError
+	TransportError error
+	// value of the `x-ms-request-id` response header, include it in support requests
+	RequestID string
+}
+
+func (err *CommunicationIdentityError) Error() string {
+	if err.StatusCode == 0 {
+		if err.TransportError == nil {
+			return "communication identity request failed"
+		}
+		return err.TransportError.Error()
+	}
+
+	var out strings.Builder
+	out.WriteString(fmt.Sprintf(
+		"ACS responded with status %d %s",
+		err.StatusCode,
+		http.StatusText(err.StatusCode),
+	))
+	if err.RequestID != "" {
+		out.WriteString(fmt.Sprintf(" (request id: %s)", err.RequestID))
+	}
+	if err.ACSError != nil {
+		out.WriteString(fmt.Sprintf(", error: %v", err.ACSError))
+	}
+	if err.TransportError != nil {
+		out.WriteString(fmt.Sprintf(": %v", err.TransportError))
+	}
+	return out.String()
+}
+
+func (err *CommunicationIdentityError) Unwrap() []error {
+	var errs []error
+	if err.ACSError != nil {
+		errs = append(errs, err.ACSError)
+	}
+	if err.TransportError != nil {
+		errs = append(errs, err.TransportError)
+	}
+	return errs
+}
+
+func (err *CommunicationIdentityError) Is(target error) bool {
+	var want *CommunicationIdentityError
+	if !errors.As(target, &want) || want == nil {
+		return false
+	}
+	wantCode := ""
+	if want.ACSError != nil {
+		wantCode = want.ACSError.Code
+	}
+	if want.StatusCode == 0 && want.RequestID == "" && wantCode == "" {
+		return false
+	}
+	if want.StatusCode != 0 && want.StatusCode != err.StatusCode {
+		return false
+	}
+	if want.RequestID != "" && want.RequestID != err.RequestID {
+		return false
+	}
+	if wantCode != "" && (err.ACSError == nil || err.ACSError.Code != wantCode) {
+		return false
+	}
+	return true
+}
+
+// As provides a [StatusError] to [errors.As] for errors with a status
+func (err *CommunicationIdentityError) As(target any) bool {
+	statusErr, ok := target.(**StatusError)
+	if !ok || err.StatusCode == 0 {
+		return false
+	}
+	acsError := err.ACSError
+	if acsError == nil {
+		acsError = &CommunicationError{}
+	}
+	*statusErr = &StatusError{
+		CommunicationError: acsError,
+		HTTPStatus:         err.StatusCode,
+		RequestID:          err.RequestID,
+	}
+	return true
+}
+
+// StatusError is an unsuccessful ACS response, available through [errors.As] from every error a
+// client method returns for such a response:
+//
+//	var statusErr *StatusError
+//	notFound := errors.As(err, &statusErr) && statusErr.HTTPStatus == http.StatusNotFound
+//
+// The embedded CommunicationError is empty if the response carried no ACS error.
+type StatusError struct {
+	*CommunicationError
+	HTTPStatus int
+	// value of the `x-ms-request-id` response header, include it in bug reports and support
+	// requests
+	RequestID string
+}
+
+func (err *StatusError) Error() string {
+	status := fmt.Sprintf("%d %s", err.HTTPStatus, http.StatusText(err.HTTPStatus))
+	if err.Code == "" && err.Message == "" {
+		return status
+	}
+	return fmt.Sprintf("%s: %v", status, err.CommunicationError)
+}
+
+// Unwrap returns the ACS error, [errors.Is] and [errors.As] continue with its inner errors
+func (err *StatusError) Unwrap() error {
+	return err.CommunicationError
+}
+
+func newTransportError(format string, args ...any) *CommunicationIdentityError {
+	return &CommunicationIdentityError{TransportError: fmt.Errorf(format, args...)}
+}
+
+func newResponseError(
+	response *http.Response,
+	acsError *CommunicationError,
+	transportError error,
+) *CommunicationIdentityError {
+	return &CommunicationIdentityError{
+		StatusCode:     response.StatusCode,
+		ACSError:       acsError,
+		TransportError: transportError,
+		RequestID:      response.Header.Get(msRequestIDHeader),
+	}
+}
+
+// Aggregates independent ACS errors of operations affecting multiple resources.
+// Client methods never return a MultiError without errors, but nil instead.
+type MultiError struct {
+	// ACS errors of the failed operations, nil entries are skipped
+	Errors []*CommunicationError
+	// the errors of the failed operations as returned, including failures without ACS error such
+	// as transport errors and context cancellation, matched by [errors.Is] and [errors.As]
+	Causes []error
+}
+
+func (err *MultiError) Error() string {
+	var out strings.Builder
+	if len(err.Causes) > 0 {
+		out.WriteString(fmt.Sprintf("%d errors occurred:", len(err.Causes)))
+		for _, cause := range err.Causes {
+			out.WriteString(fmt.Sprintf("\n\t%v", cause))
+		}
+		return out.String()
+	}
+	out.WriteString(fmt.Sprintf("%d ACS errors occurred:", len(err.Errors)))
+	for _, communicationErr := range err.Errors {
+		if communicationErr == nil {
+			continue
+		}
+		out.WriteString(fmt.Sprintf("\n\t%s - %s", communicationErr.Code, communicationErr.Message))
+	}
+	return out.String()
+}
+
+func (err *MultiError) Unwrap() []error {
+	if len(err.Causes) > 0 {
+		return err.Causes
+	}
+	errs := make([]error, 0, len(err.Errors))
+	for _, communicationErr := range err.Errors {
+		if communicationErr != nil {
+			errs = append(errs, communicationErr)
+		}
+	}
+	return errs
+}
+
+// First returns the first non-nil error, nil if there is none
+func (err *MultiError) First() *CommunicationError {
+	for _, communicationErr := range err.Errors {
+		if communicationErr != nil {
+			return communicationErr
+		}
+	}
+	return nil
+}
+
+// newMultiError drops nil errors and returns nil if none remain
+func newMultiError(errs []*CommunicationError) error {
+	var nonNil []*CommunicationError
+	for _, err := range errs {
+		if err != nil {
+			nonNil = append(nonNil, err)
+		}
+	}
+	if len(nonNil) == 0 {
+		return nil
+	}
+	return &MultiError{Errors: nonNil}
+}
+
+// Matches reports whether the top-level code of err is code, use [ContainsCode] to search the
+// details and inner errors as well
+func (err *CommunicationError) Matches(code string) bool {
+	return err != nil && err.Code == code
+}
+
+// FlattenCommunicationErrors returns err followed by all of its details and inner errors,
+// depth-first
+func FlattenCommunicationErrors(err *CommunicationError) []*CommunicationError {
+	if err == nil {
+		return nil
+	}
+	flattened := []*CommunicationError{err}
+	for i := range err.Details {
+		flattened = append(flattened, FlattenCommunicationErrors(&err.Details[i])...)
+	}
+	return append(flattened, FlattenCommunicationErrors(err.Innererror)...)
+}
+
+// ContainsCode reports whether any ACS error in the tree of err, including the details and inner
+// errors of every [CommunicationError] in it, has code
+func ContainsCode(err error, code string) bool {
+	switch e := err.(type) {
+	case nil:
+		return false
+	case *CommunicationError:
+		for _, nested := range FlattenCommunicationErrors(e) {
+			if nested.Matches(code) {
+				return true
+			}
+		}
+		return false
+	case interface{ Unwrap() error }:
+		return ContainsCode(e.Unwrap(), code)
+	case interface{ Unwrap() []error }:
+		for _, wrapped := range e.Unwrap() {
+			if ContainsCode(wrapped, code) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsRetryable reports whether sending the request of the failed call again may succeed: ACS
+// answered 429, 500 or 503, or the request failed on the wire for a reason other than the
+// context, e.g. a reset connection. [WithRetry] retries the same errors.
+func IsRetryable(err error) bool {
+	if statusErr, ok := asStatusError(err); ok {
+		return isRetryableStatus(statusErr.HTTPStatus)
+	}
+	var identityErr *CommunicationIdentityError
+	return errors.As(err, &identityErr) && identityErr.TransportError != nil &&
+		isRetryableTransportError(identityErr.TransportError)
+}
+
+// IsNotFound reports whether err is a 404 Not Found response of ACS or carries the
+// [ErrIdentityNotFound] code
+func IsNotFound(err error) bool {
+	statusErr, ok := asStatusError(err)
+	return ok && (statusErr.HTTPStatus == http.StatusNotFound ||
+		statusErr.hasCode(ErrIdentityNotFound))
+}
+
+// IsUnauthorized reports whether err is a 401 Unauthorized response of ACS or carries the
+// [ErrUnauthorized] code, e.g. because the access key is invalid or was rotated
+func IsUnauthorized(err error) bool {
+	statusErr, ok := asStatusError(err)
+	return ok && (statusErr.HTTPStatus == http.StatusUnauthorized ||
+		statusErr.hasCode(ErrUnauthorized))
+}
+
+// IsRateLimited reports whether err is the 429 Too Many Requests response of ACS, e.g. because
+// the Retry-After delay exceeded the deadline of the call, see [WithRetry]
+func IsRateLimited(err error) bool {
+	statusErr, ok := asStatusError(err)
+	return ok && statusErr.HTTPStatus == http.StatusTooManyRequests
+}
+
+// IsServerError reports whether err is a 5xx response of ACS
+func IsServerError(err error) bool {
+	statusErr, ok := asStatusError(err)
+	return ok && statusErr.HTTPStatus >= http.StatusInternalServerError
+}
+
+func asStatusError(err error) (*StatusError, bool) {
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr == nil {
+		return nil, false
+	}
+	return statusErr, true
+}
+
+// hasCode reports whether the ACS error or one of its inner errors carries the code of sentinel
+func (err *StatusError) hasCode(sentinel *CommunicationError) bool {
+	return err.CommunicationError != nil && errors.Is(err.CommunicationError, sentinel)
+}
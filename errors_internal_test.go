@@ -0,0 +1,36 @@
+package communicationidentity
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNewMultiError(t *testing.T) {
+	if err := newMultiError(nil); err != nil {
+		t.Errorf("expected nil for no errors, got %v", err)
+	}
+	if err := newMultiError([]*CommunicationError{nil, nil}); err != nil {
+		t.Errorf("expected nil for only nil errors, got %v", err)
+	}
+
+	notFound := &CommunicationError{Code: "IdentityNotFound", Message: "not found"}
+	forbidden := &CommunicationError{Code: "Forbidden", Message: "forbidden"}
+	err := newMultiError([]*CommunicationError{nil, notFound, forbidden})
+
+	var multiErr *MultiError
+	if !errors.As(err, &multiErr) {
+		t.Fatalf("expected MultiError, got %T", err)
+	}
+	if len(multiErr.Errors) != 2 || multiErr.First() != notFound {
+		t.Errorf("unexpected errors: %v", multiErr.Errors)
+	}
+	if !errors.Is(err, forbidden) {
+		t.Error("expected errors.Is to find a contained error")
+	}
+	for _, code := range []string{"IdentityNotFound", "Forbidden"} {
+		if !strings.Contains(err.Error(), code) {
+			t.Errorf("expected %q in error message %q", code, err.Error())
+		}
+	}
+}
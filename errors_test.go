@@ -0,0 +1,457 @@
+package communicationidentity_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"testing"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+)
+
+func errorHandler(status int, requestID string, body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("x-ms-request-id", requestID)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}
+}
+
+func TestCommunicationIdentityErrorFromACSError(t *testing.T) {
+	client := newTestClient(t, errorHandler(
+		http.StatusNotFound,
+		"request-1",
+		`{"error":{"code":"IdentityNotFound","message":"identity does not exist"}}`,
+	))
+
+	_, err := client.CreateCommunicationIdentity(context.Background(), []string{"chat"}, nil)
+
+	var identityErr *ci.CommunicationIdentityError
+	if !errors.As(err, &identityErr) {
+		t.Fatalf("expected CommunicationIdentityError, got %T: %v", err, err)
+	}
+	if identityErr.StatusCode != http.StatusNotFound || identityErr.RequestID != "request-1" {
+		t.Errorf("unexpected error metadata: %+v", identityErr)
+	}
+	var acsErr *ci.CommunicationError
+	if !errors.As(err, &acsErr) || acsErr.Code != "IdentityNotFound" {
+		t.Errorf("expected wrapped CommunicationError with code, got %v", acsErr)
+	}
+
+	matching := []*ci.CommunicationIdentityError{
+		{StatusCode: http.StatusNotFound},
+		{RequestID: "request-1"},
+		{ACSError: &ci.CommunicationError{Code: "IdentityNotFound"}},
+		{
+			StatusCode: http.StatusNotFound,
+			ACSError:   &ci.CommunicationError{Code: "IdentityNotFound"},
+		},
+	}
+	for _, target := range matching {
+		if !errors.Is(err, target) {
+			t.Errorf("expected error to match %+v", target)
+		}
+	}
+	notMatching := []*ci.CommunicationIdentityError{
+		{},
+		{StatusCode: http.StatusForbidden},
+		{StatusCode: http.StatusNotFound, RequestID: "request-2"},
+		{ACSError: &ci.CommunicationError{Code: "Unauthorized"}},
+	}
+	for _, target := range notMatching {
+		if errors.Is(err, target) {
+			t.Errorf("expected error not to match %+v", target)
+		}
+	}
+}
+
+func TestCommunicationIdentityErrorFromUnparseableBody(t *testing.T) {
+	client := newTestClient(t, errorHandler(http.StatusBadGateway, "", "<html>"))
+
+	_, err := client.TokenForTeamsUser(context.Background(), "oid", "token")
+
+	var identityErr *ci.CommunicationIdentityError
+	if !errors.As(err, &identityErr) {
+		t.Fatalf("expected CommunicationIdentityError, got %T: %v", err, err)
+	}
+	if identityErr.StatusCode != http.StatusBadGateway || identityErr.ACSError != nil {
+		t.Errorf("unexpected error content: %+v", identityErr)
+	}
+	if identityErr.TransportError == nil {
+		t.Error("expected parse error to be reported as TransportError")
+	}
+}
+
+func TestCommunicationIdentityErrorFromTransport(t *testing.T) {
+	endpoint, _ := url.Parse("http://127.0.0.1:1")
+	client, err := ci.New(endpoint, testAccessKey, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.CreateCommunicationIdentity(context.Background(), []string{"chat"}, nil)
+
+	var identityErr *ci.CommunicationIdentityError
+	if !errors.As(err, &identityErr) {
+		t.Fatalf("expected CommunicationIdentityError, got %T: %v", err, err)
+	}
+	if identityErr.StatusCode != 0 || identityErr.TransportError == nil {
+		t.Errorf("expected transport error without status, got %+v", identityErr)
+	}
+}
+
+func TestCommunicationErrorFields(t *testing.T) {
+	err := &ci.CommunicationError{
+		Code:       "Forbidden",
+		Message:    "denied",
+		Details:    []ci.CommunicationError{{Code: "a"}, {Code: "b"}},
+		Innererror: &ci.CommunicationError{Code: "InvalidScope"},
+	}
+	fields := err.Fields()
+	if fields["code"] != "Forbidden" || fields["message"] != "denied" || fields["details_count"] != 2 {
+		t.Errorf("unexpected fields: %v", fields)
+	}
+	inner, ok := fields["inner"].(map[string]any)
+	if !ok || inner["code"] != "InvalidScope" {
+		t.Errorf("expected inner error fields, got %v", fields["inner"])
+	}
+}
+
+func TestCommunicationErrorLogValue(t *testing.T) {
+	var out bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&out, nil))
+	var err error = &ci.CommunicationError{
+		Code:       "Forbidden",
+		Innererror: &ci.CommunicationError{Code: "InvalidScope"},
+	}
+	logger.Error("request failed", slog.Any("acs_error", err))
+
+	var entry struct {
+		ACSError struct {
+			Code  string `json:"code"`
+			Inner struct {
+				Code string `json:"code"`
+			} `json:"inner"`
+		} `json:"acs_error"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry.ACSError.Code != "Forbidden" || entry.ACSError.Inner.Code != "InvalidScope" {
+		t.Errorf("expected structured error attribute, got %s", out.String())
+	}
+}
+
+// three levels deep: root -> details[1] -> innererror -> details[0]
+var nestedCommunicationError = &ci.CommunicationError{
+	Code: "BadRequest",
+	Details: []ci.CommunicationError{
+		{Code: "InvalidScope"},
+		{
+			Code: "InvalidExpiry",
+			Innererror: &ci.CommunicationError{
+				Code:    "ExpiryTooLong",
+				Details: []ci.CommunicationError{{Code: "ExpiryAboveMaximum"}},
+			},
+		},
+	},
+}
+
+func TestCommunicationErrorMatches(t *testing.T) {
+	if !nestedCommunicationError.Matches("BadRequest") {
+		t.Error("expected top-level code to match")
+	}
+	if nestedCommunicationError.Matches("InvalidScope") {
+		t.Error("expected nested code not to match")
+	}
+}
+
+func TestFlattenCommunicationErrors(t *testing.T) {
+	var codes []string
+	for _, err := range ci.FlattenCommunicationErrors(nestedCommunicationError) {
+		codes = append(codes, err.Code)
+	}
+	want := []string{
+		"BadRequest", "InvalidScope", "InvalidExpiry", "ExpiryTooLong", "ExpiryAboveMaximum",
+	}
+	if !slices.Equal(codes, want) {
+		t.Errorf("expected %v, got %v", want, codes)
+	}
+}
+
+func TestContainsCode(t *testing.T) {
+	wrapped := fmt.Errorf("create failed: %w", &ci.CommunicationIdentityError{
+		StatusCode: http.StatusBadRequest,
+		ACSError:   nestedCommunicationError,
+	})
+	multi := &ci.MultiError{Errors: []*ci.CommunicationError{nil, nestedCommunicationError}}
+	for _, code := range []string{
+		"BadRequest", "InvalidScope", "InvalidExpiry", "ExpiryTooLong", "ExpiryAboveMaximum",
+	} {
+		for name, err := range map[string]error{"wrapped": wrapped, "multi": multi} {
+			if !ci.ContainsCode(err, code) {
+				t.Errorf("expected %s error to contain %s", name, code)
+			}
+		}
+	}
+	if ci.ContainsCode(wrapped, "Unknown") || ci.ContainsCode(nil, "BadRequest") {
+		t.Error("expected unknown code and nil error not to match")
+	}
+}
+
+func TestCreateCommunicationIdentityUnparsableResponse(t *testing.T) {
+	client := newTestClient(t, errorHandler(http.StatusCreated, "request-1", "not json"))
+
+	_, err := client.CreateCommunicationIdentity(context.Background(), []string{"chat"}, nil)
+	var identityErr *ci.CommunicationIdentityError
+	if !errors.As(err, &identityErr) {
+		t.Fatalf("expected CommunicationIdentityError, got %T: %v", err, err)
+	}
+	if identityErr.StatusCode != http.StatusCreated || identityErr.RequestID != "request-1" {
+		t.Errorf("expected response metadata on parse errors, got: %+v", identityErr)
+	}
+	if identityErr.TransportError == nil {
+		t.Error("expected the parse error as transport error")
+	}
+}
+
+func TestCommunicationErrorChain(t *testing.T) {
+	err := fmt.Errorf("create failed: %w", &ci.CommunicationIdentityError{
+		StatusCode: http.StatusUnauthorized,
+		ACSError: &ci.CommunicationError{
+			Code:    "Denied",
+			Message: "access denied",
+			Innererror: &ci.CommunicationError{
+				Code:       "Unauthorized",
+				Message:    "invalid key",
+				Innererror: &ci.CommunicationError{Code: "KeyRevoked", Message: "key revoked"},
+			},
+		},
+	})
+
+	for _, code := range []string{"Denied", "Unauthorized", "KeyRevoked"} {
+		if !errors.Is(err, &ci.CommunicationError{Code: code}) {
+			t.Errorf("expected the chain to contain %s", code)
+		}
+	}
+	if errors.Is(err, &ci.CommunicationError{Code: "Forbidden"}) {
+		t.Error("expected an unknown code not to match")
+	}
+	if errors.Is(err, &ci.CommunicationError{}) {
+		t.Error("expected a target without code not to match")
+	}
+
+	var acsErr *ci.CommunicationError
+	if !errors.As(err, &acsErr) || acsErr.Code != "Denied" {
+		t.Fatalf("expected the outermost ACS error, got %v", acsErr)
+	}
+	inner, ok := errors.Unwrap(acsErr).(*ci.CommunicationError)
+	if !ok || inner.Code != "Unauthorized" {
+		t.Errorf("expected Unwrap to return the inner error, got %v", inner)
+	}
+	want := "Denied - access denied: Unauthorized - invalid key: KeyRevoked - key revoked"
+	if got := acsErr.Error(); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestCommunicationErrorFormatsDetails(t *testing.T) {
+	want := "BadRequest -  (details: InvalidScope - ; InvalidExpiry - : " +
+		"ExpiryTooLong -  (details: ExpiryAboveMaximum - ))"
+	if got := nestedCommunicationError.Error(); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestStatusError(t *testing.T) {
+	client := newTestClient(t, errorHandler(
+		http.StatusForbidden,
+		"request-1",
+		`{"error":{"code":"Forbidden","message":"denied"}}`,
+	))
+	ctx := context.Background()
+	_, teamsErr := client.TokenForTeamsUser(ctx, "oid", "token")
+	_, createErr := client.CreateCommunicationIdentity(ctx, []string{"chat"}, nil)
+
+	for name, err := range map[string]error{"teams": teamsErr, "create": createErr} {
+		var statusErr *ci.StatusError
+		if !errors.As(err, &statusErr) {
+			t.Fatalf("%s: expected StatusError, got %T: %v", name, err, err)
+		}
+		if statusErr.HTTPStatus != http.StatusForbidden || statusErr.Code != "Forbidden" {
+			t.Errorf("%s: unexpected status error %+v", name, statusErr)
+		}
+		if got, want := statusErr.Error(), "403 Forbidden: Forbidden - denied"; got != want {
+			t.Errorf("%s: expected %q, got %q", name, want, got)
+		}
+		if !errors.Is(statusErr, &ci.CommunicationError{Code: "Forbidden"}) {
+			t.Errorf("%s: expected the ACS error to be unwrapped", name)
+		}
+	}
+}
+
+func TestStatusErrorWithoutACSError(t *testing.T) {
+	client := newTestClient(t, errorHandler(http.StatusBadGateway, "", "<html>"))
+	_, err := client.TokenForTeamsUser(context.Background(), "oid", "token")
+
+	var statusErr *ci.StatusError
+	if !errors.As(err, &statusErr) || statusErr.HTTPStatus != http.StatusBadGateway {
+		t.Fatalf("expected StatusError with status 502, got %v", err)
+	}
+	if got := statusErr.Error(); got != "502 Bad Gateway" {
+		t.Errorf("expected only the status, got %q", got)
+	}
+}
+
+func TestStatusErrorNotForTransportErrors(t *testing.T) {
+	endpoint, _ := url.Parse("http://127.0.0.1:1")
+	client, err := ci.New(endpoint, testAccessKey, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.CreateCommunicationIdentity(context.Background(), []string{"chat"}, nil)
+	var statusErr *ci.StatusError
+	if errors.As(err, &statusErr) {
+		t.Errorf("expected no StatusError for a transport error, got %v", statusErr)
+	}
+}
+
+func TestErrorSentinels(t *testing.T) {
+	for sentinel, response := range map[*ci.CommunicationError]struct {
+		status int
+		body   string
+	}{
+		ci.ErrUnauthorized: {
+			http.StatusUnauthorized,
+			`{"error":{"code":"Unauthorized","message":"invalid key"}}`,
+		},
+		ci.ErrTokenExpired: {
+			http.StatusUnauthorized,
+			`{"error":{"code":"InvalidToken","message":"rejected",` +
+				`"innererror":{"code":"TokenExpired","message":"expired"}}}`,
+		},
+		ci.ErrIdentityNotFound: {
+			http.StatusNotFound,
+			`{"error":{"code":"IdentityNotFound","message":"unknown"}}`,
+		},
+	} {
+		t.Run(sentinel.Code, func(t *testing.T) {
+			client := newTestClient(t, errorHandler(response.status, "", response.body))
+			_, err := client.IssueAccessToken(
+				context.Background(),
+				testIdentityID,
+				[]string{"chat"},
+				nil,
+			)
+			if !errors.Is(err, sentinel) {
+				t.Errorf("expected %v to match %s", err, sentinel.Code)
+			}
+			for _, other := range []*ci.CommunicationError{
+				ci.ErrUnauthorized, ci.ErrTokenExpired, ci.ErrIdentityNotFound,
+			} {
+				if other != sentinel && errors.Is(err, other) {
+					t.Errorf("expected %v not to match %s", err, other.Code)
+				}
+			}
+		})
+	}
+}
+
+func TestErrorHelpers(t *testing.T) {
+	statusErr := func(status int, code string) error {
+		err := &ci.CommunicationIdentityError{StatusCode: status}
+		if code != "" {
+			err.ACSError = &ci.CommunicationError{Code: code}
+		}
+		return err
+	}
+	transportErr := func(err error) error {
+		return &ci.CommunicationIdentityError{
+			TransportError: &url.Error{Op: "Post", URL: "https://acs.test", Err: err},
+		}
+	}
+	type helpers struct{ retryable, notFound, unauthorized, rateLimited, serverError bool }
+
+	for _, test := range []struct {
+		name string
+		err  error
+		want helpers
+	}{
+		{"nil", nil, helpers{}},
+		{"plain error", errors.New("failed"), helpers{}},
+		{"bad request", statusErr(http.StatusBadRequest, "InvalidRequest"), helpers{}},
+		{"not found", statusErr(http.StatusNotFound, ""), helpers{notFound: true}},
+		{
+			"identity not found code",
+			statusErr(http.StatusBadRequest, ci.ErrIdentityNotFound.Code),
+			helpers{notFound: true},
+		},
+		{"unauthorized", statusErr(http.StatusUnauthorized, ""), helpers{unauthorized: true}},
+		{
+			"unauthorized code",
+			statusErr(http.StatusForbidden, ci.ErrUnauthorized.Code),
+			helpers{unauthorized: true},
+		},
+		{
+			"inner unauthorized code",
+			&ci.CommunicationIdentityError{
+				StatusCode: http.StatusForbidden,
+				ACSError: &ci.CommunicationError{
+					Code:       "Denied",
+					Innererror: &ci.CommunicationError{Code: ci.ErrUnauthorized.Code},
+				},
+			},
+			helpers{unauthorized: true},
+		},
+		{
+			"rate limited",
+			statusErr(http.StatusTooManyRequests, ""),
+			helpers{retryable: true, rateLimited: true},
+		},
+		{
+			"internal server error",
+			statusErr(http.StatusInternalServerError, ""),
+			helpers{retryable: true, serverError: true},
+		},
+		{"bad gateway", statusErr(http.StatusBadGateway, ""), helpers{serverError: true}},
+		{
+			"service unavailable",
+			statusErr(http.StatusServiceUnavailable, "ServiceUnavailable"),
+			helpers{retryable: true, serverError: true},
+		},
+		{
+			"wrapped",
+			fmt.Errorf("create identity: %w", statusErr(http.StatusServiceUnavailable, "")),
+			helpers{retryable: true, serverError: true},
+		},
+		{
+			"status error",
+			&ci.StatusError{HTTPStatus: http.StatusNotFound},
+			helpers{notFound: true},
+		},
+		{"transport error", transportErr(errors.New("connection reset")), helpers{retryable: true}},
+		{"canceled", transportErr(context.Canceled), helpers{}},
+		{"local failure", &ci.CommunicationIdentityError{}, helpers{}},
+		{"circuit open", ci.ErrCircuitOpen, helpers{}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got := helpers{
+				retryable:    ci.IsRetryable(test.err),
+				notFound:     ci.IsNotFound(test.err),
+				unauthorized: ci.IsUnauthorized(test.err),
+				rateLimited:  ci.IsRateLimited(test.err),
+				serverError:  ci.IsServerError(test.err),
+			}
+			if got != test.want {
+				t.Errorf("expected %+v for %v, got %+v", test.want, test.err, got)
+			}
+		})
+	}
+}
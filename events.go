@@ -0,0 +1,127 @@
+package communicationidentity
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Kind of a [ClientEvent]
+type ClientEventKind string
+
+const (
+	// ACS issued a token, Data is a TokenIssuedEvent
+	ClientEventTokenIssued ClientEventKind = "token_issued"
+	// the access key requests are signed with changed, Data is a KeyRotatedEvent
+	ClientEventKeyRotated ClientEventKind = "key_rotated"
+	// ACS rejected the primary access key, the request is retried with the secondary key of the
+	// RotatingKeyManager. Data is a PrimaryKeyRejectedEvent.
+	ClientEventPrimaryKeyRejected ClientEventKind = "primary_key_rejected"
+)
+
+// Lifecycle event of a client, see [WithEventEmitter]
+type ClientEvent struct {
+	Kind      ClientEventKind
+	Timestamp time.Time
+	Data      any
+}
+
+// Data of ClientEventTokenIssued events, the token itself is not part of the event
+type TokenIssuedEvent struct {
+	// ACS operation that issued the token, e.g. "CreateCommunicationIdentity"
+	Method string
+	// empty for 'Teams user' tokens
+	IdentityID string
+	ExpiresOn  time.Time
+}
+
+// Data of ClientEventKeyRotated events
+type KeyRotatedEvent struct {
+	// "RotateAccessKey" or "SecondaryKeyPromoted"
+	Reason string
+}
+
+// Data of ClientEventPrimaryKeyRejected events, the primary key has probably been rotated
+type PrimaryKeyRejectedEvent struct {
+	Method string
+	Path   string
+}
+
+// Receives client events. Emit is called synchronously on the calling goroutine of the client
+// and must not block.
+type EventEmitter interface {
+	Emit(event ClientEvent)
+}
+
+// WithEventEmitter publishes the lifecycle events of the client to e
+func WithEventEmitter(e EventEmitter) ClientOption {
+	return func(options *clientOptions) error {
+		if e == nil {
+			return fmt.Errorf("event emitter can not be nil")
+		}
+		options.eventEmitter = e
+		return nil
+	}
+}
+
+func (client CommunicationIdentityClient) emit(kind ClientEventKind, data any) {
+	if client.options.eventEmitter == nil {
+		return
+	}
+	client.options.eventEmitter.Emit(ClientEvent{
+		Kind:      kind,
+		Timestamp: client.timeSource().Now(),
+		Data:      data,
+	})
+}
+
+// NewChannelEventEmitter sends events to ch without ever blocking. Events that do not fit into ch
+// are queued, up to bufferSize of them, and delivered first by later calls to Emit. If the queue
+// is full as well, the oldest event is dropped. No goroutines are started.
+func NewChannelEventEmitter(ch chan<- ClientEvent, bufferSize int) EventEmitter {
+	return &channelEventEmitter{ch: ch, bufferSize: max(bufferSize, 0)}
+}
+
+type channelEventEmitter struct {
+	mu         sync.Mutex
+	ch         chan<- ClientEvent
+	bufferSize int
+	pending    []ClientEvent
+}
+
+func (emitter *channelEventEmitter) Emit(event ClientEvent) {
+	emitter.mu.Lock()
+	defer emitter.mu.Unlock()
+	emitter.pending = append(emitter.pending, event)
+	emitter.deliverLocked()
+	if overflow := len(emitter.pending) - emitter.bufferSize; overflow > 0 {
+		emitter.pending = emitter.pending[overflow:]
+	}
+}
+
+// deliverLocked sends pending events until ch is full
+func (emitter *channelEventEmitter) deliverLocked() {
+	for len(emitter.pending) > 0 {
+		select {
+		case emitter.ch <- emitter.pending[0]:
+			emitter.pending = emitter.pending[1:]
+		default:
+			return
+		}
+	}
+}
+
+// NewMultiEventEmitter passes every event to all emitters in order, nil emitters are skipped
+func NewMultiEventEmitter(emitters ...EventEmitter) EventEmitter {
+	return multiEventEmitter(emitters)
+}
+
+type multiEventEmitter []EventEmitter
+
+func (emitters multiEventEmitter) Emit(event ClientEvent) {
+	for _, emitter := range emitters {
+		if emitter != nil {
+			emitter.Emit(event)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package communicationidentity_test
+
+import (
+	"context"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+)
+
+func TestWithEventEmitter(t *testing.T) {
+	events := make(chan ci.ClientEvent, 4)
+	client := newTestClient(t, createIdentityHandler(new(atomic.Int32)),
+		ci.WithEventEmitter(ci.NewChannelEventEmitter(events, 0)),
+	)
+
+	if _, err := client.CreateCommunicationIdentity(
+		context.Background(), []string{"chat"}, nil,
+	); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.RotateAccessKey(testAccessKey); err != nil {
+		t.Fatal(err)
+	}
+
+	issued := <-events
+	data, ok := issued.Data.(ci.TokenIssuedEvent)
+	if issued.Kind != ci.ClientEventTokenIssued || !ok || data.IdentityID != "identity-1" {
+		t.Errorf("unexpected event: %+v", issued)
+	}
+	if rotated := <-events; rotated.Kind != ci.ClientEventKeyRotated {
+		t.Errorf("unexpected event: %+v", rotated)
+	}
+}
+
+func TestChannelEventEmitterFullChannel(t *testing.T) {
+	events := make(chan ci.ClientEvent, 1)
+	emitter := ci.NewChannelEventEmitter(events, 2)
+	before := runtime.NumGoroutine()
+
+	for i := range 100 {
+		emitter.Emit(ci.ClientEvent{Data: i})
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("expected no goroutines to be started, got %d before and %d after", before, after)
+	}
+
+	// the channel holds the first event, the queue the two most recent ones
+	if event := <-events; event.Data != 0 {
+		t.Errorf("expected the first event in the channel, got %v", event.Data)
+	}
+	emitter.Emit(ci.ClientEvent{Data: 100})
+	if event := <-events; event.Data != 98 {
+		t.Errorf("expected queued events to be delivered in order, got %v", event.Data)
+	}
+}
+
+func TestMultiEventEmitter(t *testing.T) {
+	first, second := make(chan ci.ClientEvent, 1), make(chan ci.ClientEvent, 1)
+	emitter := ci.NewMultiEventEmitter(
+		ci.NewChannelEventEmitter(first, 0),
+		nil,
+		ci.NewChannelEventEmitter(second, 0),
+	)
+	emitter.Emit(ci.ClientEvent{Kind: ci.ClientEventKeyRotated, Timestamp: time.Now()})
+	if len(first) != 1 || len(second) != 1 {
+		t.Errorf("expected the event in every channel, got %d and %d", len(first), len(second))
+	}
+}
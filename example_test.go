@@ -2,10 +2,17 @@ package communicationidentity_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"net/http"
 	"net/url"
+	"time"
 
 	ci "github.com/jls-ch/azure-communication-identity-go"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 )
 
 func Example() {
@@ -44,3 +51,227 @@ func Example() {
 
 	// ...
 }
+
+type loggingClient struct{ ci.IdentityClient }
+
+func (client loggingClient) CreateCommunicationIdentity(
+	ctx context.Context,
+	scope []string,
+	expireInMinutes *int32,
+	opts ...ci.CallOption,
+) (ci.CommunicationIdentityAccessTokenResult, error) {
+	log.Printf("creating identity with scopes %v", scope)
+	result, err := client.IdentityClient.CreateCommunicationIdentity(
+		ctx,
+		scope,
+		expireInMinutes,
+		opts...,
+	)
+	if err != nil {
+		log.Printf("failed to create identity: %v", err)
+	}
+	return result, err
+}
+
+func ExampleMiddlewareChain() {
+	acsURL, err := url.Parse("YOUR-ACS-ENDPOINT")
+	if err != nil {
+		panic(err)
+	}
+	// retries and the circuit breaker are built in, middlewares add behavior on top of them
+	client, err := ci.New(
+		acsURL,
+		"YOUR-ACS-SECRET-ACCESS-KEY",
+		"YOUR-APP-ID",
+		ci.WithRetry(3, 100*time.Millisecond),
+		ci.WithCircuitBreaker(5, 30*time.Second),
+	)
+	if err != nil {
+		panic(err)
+	}
+	defer client.Close()
+
+	var chain ci.MiddlewareChain
+	decorated := chain.
+		Use(func(inner ci.IdentityClient) ci.IdentityClient {
+			return loggingClient{inner}
+		}).
+		Then(client)
+
+	// logging -> client with retries and circuit breaker
+	result, err := decorated.CreateCommunicationIdentity(context.TODO(), []string{"chat"}, nil)
+	if errors.Is(err, ci.ErrCircuitOpen) {
+		log.Printf("ACS is failing, not sending requests for now")
+		return
+	}
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("created identity: %v\n", result.Identity.ID)
+}
+
+func ExampleWithTracerProvider() {
+	acsURL, err := url.Parse("YOUR-ACS-ENDPOINT")
+	if err != nil {
+		panic(err)
+	}
+	// register an exporter with sdktrace.WithBatcher to ship the spans
+	tracerProvider := sdktrace.NewTracerProvider()
+	defer func() { _ = tracerProvider.Shutdown(context.TODO()) }()
+
+	client, err := ci.New(
+		acsURL,
+		"YOUR-ACS-SECRET-ACCESS-KEY",
+		"YOUR-APP-ID",
+		ci.WithTracerProvider(tracerProvider),
+		ci.WithServiceName("identity-service"),
+	)
+	if err != nil {
+		panic(err)
+	}
+	defer client.Close()
+
+	// the span of the call is a child of the span in ctx
+	ctx, span := tracerProvider.Tracer("identity-service").Start(context.TODO(), "signup")
+	defer span.End()
+	if _, err := client.CreateCommunicationIdentity(ctx, []string{"chat"}, nil); err != nil {
+		panic(err)
+	}
+}
+
+func ExampleWithMeterProvider() {
+	acsURL, err := url.Parse("YOUR-ACS-ENDPOINT")
+	if err != nil {
+		panic(err)
+	}
+	// use a periodic reader with an exporter, e.g. of Prometheus or OTLP, in production
+	reader := sdkmetric.NewManualReader()
+	meterProvider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	defer func() { _ = meterProvider.Shutdown(context.TODO()) }()
+
+	client, err := ci.New(
+		acsURL,
+		"YOUR-ACS-SECRET-ACCESS-KEY",
+		"YOUR-APP-ID",
+		ci.WithMeterProvider(meterProvider),
+		ci.WithServiceName("identity-service"),
+	)
+	if err != nil {
+		panic(err)
+	}
+	defer client.Close()
+
+	_, err = client.CreateCommunicationIdentity(context.TODO(), []string{"chat"}, nil)
+	if err != nil {
+		panic(err)
+	}
+	var metrics metricdata.ResourceMetrics
+	if err := reader.Collect(context.TODO(), &metrics); err != nil {
+		panic(err)
+	}
+	for _, scope := range metrics.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			fmt.Printf("recorded metric %s\n", m.Name)
+		}
+	}
+}
+
+func ExampleNewFromManagedIdentity() {
+	acsURL, err := url.Parse("YOUR-ACS-ENDPOINT")
+	if err != nil {
+		panic(err)
+	}
+	// no access key is deployed, requests carry Entra ID tokens of the managed identity
+	client, err := ci.NewFromManagedIdentity(
+		acsURL,
+		"YOUR-APP-ID",
+		ci.WithTokenCache(ci.NewInMemoryTokenCache(10000)),
+	)
+	if err != nil {
+		panic(err)
+	}
+	defer client.Close()
+
+	// the identity of a user is created once and served from the cache while its token is valid
+	for range 2 {
+		result, err := client.CreateCommunicationIdentity(
+			context.TODO(),
+			[]string{"chat", "voip"},
+			nil,
+			ci.WithTokenCacheKey("APPLICATION-USER-ID"),
+		)
+		if err != nil {
+			panic(err)
+		}
+		fmt.Printf("identity %v, token expires on %v\n",
+			result.Identity.ID, result.AccessToken.ExpiresOn)
+	}
+}
+
+func ExampleWithTeamsUserExchangeCache() {
+	client, err := ci.NewFromEnv(
+		"ID-OF-APP-REGISTRATION-WITH-TEAMS-PERMISSIONS",
+		ci.WithTeamsUserExchangeCache(ci.NewInMemoryTeamsUserExchangeCache(1000)),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	// the second exchange for the same user is served from the cache
+	for range 2 {
+		token, err := client.TokenForTeamsUser(
+			context.TODO(),
+			"USER-OID",
+			"ENTRA-TOKEN-WITH-TEAMS-SCOPE",
+		)
+		if err != nil {
+			panic(err)
+		}
+		fmt.Printf("token for teams user expires on: %v\n", token.ExpiresOn)
+	}
+}
+
+func ExampleNewHTTPProxy() {
+	client, err := ci.NewFromConnectionString("YOUR-ACS-CONNECTION-STRING", "YOUR-APP-ID")
+	if err != nil {
+		panic(err)
+	}
+
+	// sidecar serving `POST /tokens` and `POST /teams-tokens` to other processes of the pod,
+	// the ACS access key never leaves this process
+	server := &http.Server{
+		Addr:              "127.0.0.1:8080",
+		Handler:           ci.NewHTTPProxy(client),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	log.Fatal(server.ListenAndServe())
+}
+
+func ExampleIdentityClientPool() {
+	var pool ci.IdentityClientPool
+	for region, weight := range map[string]int{"westeurope": 2, "northeurope": 1} {
+		client, err := ci.NewFromConnectionString("CONNECTION-STRING-OF-"+region, "YOUR-APP-ID")
+		if err != nil {
+			panic(err)
+		}
+		pool.Add(region, weight, client)
+	}
+
+	// health check: report regions failing their requests
+	go func() {
+		for range time.Tick(time.Minute) {
+			for region, metrics := range pool.PoolMetrics() {
+				if metrics.Requests > 0 && metrics.Errors*10 > metrics.Requests {
+					log.Printf("region %s: %d of %d requests failed, p50 %.1fms",
+						region, metrics.Errors, metrics.Requests, metrics.P50LatencyMs)
+				}
+			}
+		}
+	}()
+
+	result, err := pool.CreateCommunicationIdentity(context.TODO(), []string{"chat"}, nil)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("created identity: %v\n", result.Identity.ID)
+}
@@ -0,0 +1,153 @@
+package communicationidentity
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Token lifetime range accepted by ACS, in minutes. Values of `expireInMinutes` outside of it are
+// rejected before a request is sent.
+const (
+	MinTokenExpiryMinutes = 60
+	MaxTokenExpiryMinutes = 1440
+)
+
+// WithDefaultExpirationDuration sets the token lifetime used whenever a method is called with
+// a nil `expireInMinutes`. This overrides the ACS-side default (currently 60 minutes), so a
+// change of that default does not silently change the lifetime of issued tokens.
+//
+// d must be a whole number of minutes within the range accepted by ACS (60 to 1440 minutes).
+func WithDefaultExpirationDuration(d time.Duration) ClientOption {
+	return func(options *clientOptions) error {
+		if d%time.Minute != 0 {
+			return fmt.Errorf("default expiration duration must be whole minutes, got %v", d)
+		}
+		minutes := d / time.Minute
+		if minutes < MinTokenExpiryMinutes || minutes > MaxTokenExpiryMinutes {
+			return fmt.Errorf(
+				"default expiration duration must be between %d and %d minutes, got %v",
+				MinTokenExpiryMinutes,
+				MaxTokenExpiryMinutes,
+				d,
+			)
+		}
+		expiry := int32(minutes)
+		options.defaultExpireInMinutes = &expiry
+		return nil
+	}
+}
+
+// TokenTTLPolicy chooses the token lifetime of calls with a nil `expireInMinutes` by their scopes,
+// see [WithTokenTTLPolicy]. All values are minutes within the range accepted by ACS.
+type TokenTTLPolicy struct {
+	// used when no requested scope has an override, zero keeps the default of the client
+	DefaultMinutes int32
+	// the lowest override of the requested scopes is applied
+	PerScope map[Scope]int32
+}
+
+// WithTokenTTLPolicy applies p to every call with a nil `expireInMinutes`, e.g. to issue
+// short-lived VoIP tokens and longer-lived chat tokens. The policy takes precedence over
+// [WithDefaultExpirationDuration], which still applies if p.DefaultMinutes is zero.
+func WithTokenTTLPolicy(p TokenTTLPolicy) ClientOption {
+	return func(options *clientOptions) error {
+		if p.DefaultMinutes != 0 {
+			if err := validateTTLMinutes("DefaultMinutes", p.DefaultMinutes); err != nil {
+				return err
+			}
+		}
+		perScope := make(map[Scope]int32, len(p.PerScope))
+		for scope, minutes := range p.PerScope {
+			if err := validateTTLMinutes(fmt.Sprintf("PerScope[%s]", scope), minutes); err != nil {
+				return err
+			}
+			perScope[scope] = minutes
+		}
+		p.PerScope = perScope
+		options.ttlPolicy = &p
+		return nil
+	}
+}
+
+func validateTTLMinutes(field string, minutes int32) error {
+	if minutes < MinTokenExpiryMinutes || minutes > MaxTokenExpiryMinutes {
+		return &ValidationError{
+			Field: field,
+			Value: strconv.Itoa(int(minutes)),
+			Err: fmt.Errorf(
+				"token lifetime must be between %d and %d minutes",
+				MinTokenExpiryMinutes,
+				MaxTokenExpiryMinutes,
+			),
+		}
+	}
+	return nil
+}
+
+// validateExpireInMinutes checks an explicit expiry, nil leaves the lifetime to the defaults
+func validateExpireInMinutes(expireInMinutes *int32) error {
+	if expireInMinutes == nil {
+		return nil
+	}
+	return validateTTLMinutes("expireInMinutes", *expireInMinutes)
+}
+
+// expiryOrDefault substitutes the configured policy or default for a nil expiry
+func (client CommunicationIdentityClient) expiryOrDefault(
+	scope []string,
+	expireInMinutes *int32,
+) *int32 {
+	if expireInMinutes != nil {
+		return expireInMinutes
+	}
+	if policy := client.options.ttlPolicy; policy != nil {
+		var lowest *int32
+		for _, s := range scope {
+			minutes, found := policy.PerScope[Scope(s)]
+			if found && (lowest == nil || minutes < *lowest) {
+				lowest = &minutes
+			}
+		}
+		if lowest != nil {
+			return lowest
+		}
+		if policy.DefaultMinutes != 0 {
+			return &policy.DefaultMinutes
+		}
+	}
+	return client.options.defaultExpireInMinutes
+}
+
+// local lifetime of tokens issued by IssueShortLivedAccessToken
+const shortLivedTokenLifetime = 5 * time.Second
+
+// IssueShortLivedAccessToken issues a token for identityID whose ExpiresOn is only 5 seconds in
+// the future, to exercise expiry and refresh logic in tests without waiting for an hour.
+//
+// NOTE: the expiry is local only. ACS issues the token with its minimum lifetime of 60 minutes,
+// the token stays valid on the ACS side for that long and must be treated as a credential.
+func (client CommunicationIdentityClient) IssueShortLivedAccessToken(
+	ctx context.Context,
+	identityID string,
+	scopes []Scope,
+	opts ...CallOption,
+) (CommunicationIdentityAccessToken, error) {
+	if err := validateScopes("scopes", scopes); err != nil {
+		return CommunicationIdentityAccessToken{}, err
+	}
+	expiry := int32(MinTokenExpiryMinutes)
+	token, err := client.issueAccessToken(
+		ctx,
+		identityID,
+		scopeStrings(scopes),
+		&expiry,
+		applyCallOptions(opts),
+	)
+	if err != nil {
+		return CommunicationIdentityAccessToken{}, err
+	}
+	token.ExpiresOn = client.timeSource().Now().Add(shortLivedTokenLifetime)
+	return client.secureToken(token), nil
+}
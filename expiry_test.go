@@ -0,0 +1,209 @@
+package communicationidentity_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+	"github.com/jls-ch/azure-communication-identity-go/communicationidentitytest"
+)
+
+func captureExpiryHandler(received **int32) http.HandlerFunc {
+	handler := createIdentityHandler(new(atomic.Int32))
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Expire *int32 `json:"expiresInMinutes"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		*received = body.Expire
+		handler(w, r)
+	}
+}
+
+func TestWithDefaultExpirationDuration(t *testing.T) {
+	var received *int32
+	client := newTestClient(
+		t,
+		captureExpiryHandler(&received),
+		ci.WithDefaultExpirationDuration(8*time.Hour),
+	)
+	ctx := context.Background()
+
+	if _, err := client.CreateCommunicationIdentity(ctx, []string{"chat"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if received == nil || *received != 480 {
+		t.Errorf("expected default expiry of 480 minutes, got %v", received)
+	}
+
+	explicit := int32(60)
+	if _, err := client.CreateCommunicationIdentity(ctx, []string{"chat"}, &explicit); err != nil {
+		t.Fatal(err)
+	}
+	if received == nil || *received != 60 {
+		t.Errorf("expected explicit expiry to win, got %v", received)
+	}
+}
+
+func TestWithoutDefaultExpirationDurationOmitsExpiry(t *testing.T) {
+	var received *int32
+	client := newTestClient(t, captureExpiryHandler(&received))
+	if _, err := client.CreateCommunicationIdentity(
+		context.Background(), []string{"chat"}, nil,
+	); err != nil {
+		t.Fatal(err)
+	}
+	if received != nil {
+		t.Errorf("expected no expiry to be sent, got %v", *received)
+	}
+}
+
+func TestWithDefaultExpirationDurationValidation(t *testing.T) {
+	for _, d := range []time.Duration{
+		59 * time.Minute,
+		1441 * time.Minute,
+		90*time.Minute + time.Second,
+	} {
+		_, err := ci.New(nil, testAccessKey, "", ci.WithDefaultExpirationDuration(d))
+		if err == nil {
+			t.Errorf("expected %v to be rejected", d)
+		}
+	}
+}
+
+func TestIssueShortLivedAccessToken(t *testing.T) {
+	var path string
+	var received *int32
+	issue := issueTokenHandler(&path)
+	clock := communicationidentitytest.NewMockClock(time.Now())
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Expire *int32 `json:"expiresInMinutes"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		received = body.Expire
+		issue(w, r)
+	}, ci.WithTimeSource(clock))
+
+	token, err := client.IssueShortLivedAccessToken(
+		context.Background(),
+		testIdentityID,
+		[]ci.Scope{ci.ScopeChat},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if received == nil || *received != 60 {
+		t.Errorf("expected the ACS minimum lifetime to be requested, got %v", received)
+	}
+	if want := clock.Now().Add(5 * time.Second); !token.ExpiresOn.Equal(want) {
+		t.Errorf("expected local expiry %v, got %v", want, token.ExpiresOn)
+	}
+}
+
+func TestWithTokenTTLPolicy(t *testing.T) {
+	var received *int32
+	client := newTestClient(t, captureExpiryHandler(&received),
+		ci.WithDefaultExpirationDuration(8*time.Hour),
+		ci.WithTokenTTLPolicy(ci.TokenTTLPolicy{
+			DefaultMinutes: 720,
+			PerScope:       map[ci.Scope]int32{ci.ScopeVoIP: 60, ci.ScopeChat: 240},
+		}),
+	)
+	explicit := int32(90)
+	for _, c := range []struct {
+		scopes []string
+		expiry *int32
+		want   int32
+	}{
+		{[]string{"chat"}, nil, 240},
+		{[]string{"chat", "voip"}, nil, 60},
+		{[]string{"chat.join"}, nil, 720},
+		{[]string{"voip"}, &explicit, 90},
+	} {
+		_, err := client.CreateCommunicationIdentity(context.Background(), c.scopes, c.expiry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if received == nil || *received != c.want {
+			t.Errorf("expected expiry %d for %v, got %v", c.want, c.scopes, received)
+		}
+	}
+}
+
+func TestWithTokenTTLPolicyFallsBackToDefaultExpiration(t *testing.T) {
+	var received *int32
+	client := newTestClient(t, captureExpiryHandler(&received),
+		ci.WithDefaultExpirationDuration(8*time.Hour),
+		ci.WithTokenTTLPolicy(ci.TokenTTLPolicy{PerScope: map[ci.Scope]int32{ci.ScopeVoIP: 60}}),
+	)
+	_, err := client.CreateCommunicationIdentity(context.Background(), []string{"chat"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if received == nil || *received != 480 {
+		t.Errorf("expected default expiration of 480 minutes, got %v", received)
+	}
+}
+
+func TestWithTokenTTLPolicyValidation(t *testing.T) {
+	for name, policy := range map[string]ci.TokenTTLPolicy{
+		"default too short":   {DefaultMinutes: 30},
+		"per scope too long":  {PerScope: map[ci.Scope]int32{ci.ScopeChat: 1441}},
+		"per scope too short": {PerScope: map[ci.Scope]int32{ci.ScopeVoIP: 0}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ci.New(nil, testAccessKey, "", ci.WithTokenTTLPolicy(policy))
+			var validationErr *ci.ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Errorf("expected ValidationError, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestExpireInMinutesValidation(t *testing.T) {
+	var path string
+	create := createIdentityHandler(new(atomic.Int32))
+	issue := issueTokenHandler(&path)
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/identities" {
+			create(w, r)
+			return
+		}
+		issue(w, r)
+	})
+	ctx := context.Background()
+
+	tests := []struct {
+		minutes int32
+		valid   bool
+	}{
+		{0, false},
+		{-60, false},
+		{ci.MinTokenExpiryMinutes - 1, false},
+		{ci.MinTokenExpiryMinutes, true},
+		{ci.MaxTokenExpiryMinutes, true},
+		{ci.MaxTokenExpiryMinutes + 1, false},
+	}
+	for _, test := range tests {
+		_, createErr := client.CreateCommunicationIdentity(ctx, []string{"chat"}, &test.minutes)
+		_, issueErr := client.IssueAccessToken(ctx, testIdentityID, []string{"chat"}, &test.minutes)
+		for _, err := range []error{createErr, issueErr} {
+			var validationErr *ci.ValidationError
+			switch {
+			case test.valid && err != nil:
+				t.Errorf("expected %d minutes to be accepted, got: %v", test.minutes, err)
+			case !test.valid && !errors.As(err, &validationErr):
+				t.Errorf("expected a ValidationError for %d minutes, got: %v", test.minutes, err)
+			case !test.valid && validationErr.Field != "expireInMinutes":
+				t.Errorf("unexpected validation error content: %+v", validationErr)
+			}
+		}
+	}
+}
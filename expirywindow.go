@@ -0,0 +1,76 @@
+package communicationidentity
+
+import (
+	"slices"
+	"time"
+)
+
+// GroupByExpiryWindow assigns each token to the smallest of windows its remaining lifetime fits
+// into, already expired tokens are assigned to window 0. Tokens outliving the largest window are
+// left out.
+func GroupByExpiryWindow(
+	tokens []CommunicationIdentityAccessToken,
+	windows []time.Duration,
+) map[time.Duration][]CommunicationIdentityAccessToken {
+	sorted := sortedWindows(windows)
+	now := time.Now()
+	groups := make(map[time.Duration][]CommunicationIdentityAccessToken)
+	for _, token := range tokens {
+		if window, found := expiryWindow(token, sorted, now); found {
+			groups[window] = append(groups[window], token)
+		}
+	}
+	return groups
+}
+
+// Number of tokens per expiry window, see [GroupByExpiryWindow]
+type ExpiryWindowStats struct {
+	// windows without tokens are present with a count of 0, expired tokens are counted for 0
+	Counts map[time.Duration]int
+	// tokens outliving the largest window
+	Beyond int
+}
+
+// NewExpiryWindowStats counts tokens like [GroupByExpiryWindow] without collecting them
+func NewExpiryWindowStats(
+	tokens []CommunicationIdentityAccessToken,
+	windows []time.Duration,
+) ExpiryWindowStats {
+	sorted := sortedWindows(windows)
+	stats := ExpiryWindowStats{Counts: map[time.Duration]int{0: 0}}
+	for _, window := range sorted {
+		stats.Counts[window] = 0
+	}
+	now := time.Now()
+	for _, token := range tokens {
+		if window, found := expiryWindow(token, sorted, now); found {
+			stats.Counts[window]++
+		} else {
+			stats.Beyond++
+		}
+	}
+	return stats
+}
+
+func sortedWindows(windows []time.Duration) []time.Duration {
+	sorted := slices.Clone(windows)
+	slices.Sort(sorted)
+	return sorted
+}
+
+func expiryWindow(
+	token CommunicationIdentityAccessToken,
+	sortedWindows []time.Duration,
+	now time.Time,
+) (time.Duration, bool) {
+	remaining := token.ExpiresOn.Sub(now)
+	if remaining <= 0 {
+		return 0, true
+	}
+	for _, window := range sortedWindows {
+		if remaining <= window {
+			return window, true
+		}
+	}
+	return 0, false
+}
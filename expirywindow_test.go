@@ -0,0 +1,51 @@
+package communicationidentity_test
+
+import (
+	"testing"
+	"time"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+)
+
+func tokensExpiringIn(durations ...time.Duration) []ci.CommunicationIdentityAccessToken {
+	tokens := make([]ci.CommunicationIdentityAccessToken, len(durations))
+	for i, d := range durations {
+		tokens[i] = ci.CommunicationIdentityAccessToken{
+			Token:     d.String(),
+			ExpiresOn: time.Now().Add(d),
+		}
+	}
+	return tokens
+}
+
+var testExpiryWindows = []time.Duration{10 * time.Minute, time.Minute, 5 * time.Minute}
+
+func TestGroupByExpiryWindow(t *testing.T) {
+	tokens := tokensExpiringIn(-time.Minute, 30*time.Second, 3*time.Minute, 4*time.Minute, time.Hour)
+	groups := ci.GroupByExpiryWindow(tokens, testExpiryWindows)
+
+	want := map[time.Duration]int{0: 1, time.Minute: 1, 5 * time.Minute: 2}
+	if len(groups) != len(want) {
+		t.Fatalf("unexpected windows: %v", groups)
+	}
+	for window, count := range want {
+		if len(groups[window]) != count {
+			t.Errorf("expected %d tokens in window %s, got %v", count, window, groups[window])
+		}
+	}
+}
+
+func TestNewExpiryWindowStats(t *testing.T) {
+	tokens := tokensExpiringIn(-time.Minute, 3*time.Minute, time.Hour)
+	stats := ci.NewExpiryWindowStats(tokens, testExpiryWindows)
+
+	want := map[time.Duration]int{0: 1, time.Minute: 0, 5 * time.Minute: 1, 10 * time.Minute: 0}
+	for window, count := range want {
+		if stats.Counts[window] != count {
+			t.Errorf("expected %d tokens in window %s, got %d", count, window, stats.Counts[window])
+		}
+	}
+	if stats.Beyond != 1 {
+		t.Errorf("expected 1 token beyond all windows, got %d", stats.Beyond)
+	}
+}
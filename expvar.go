@@ -0,0 +1,71 @@
+package communicationidentity
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"sync"
+)
+
+// serializes lookups and publications of expvars by WithExpvar
+var expvarMu sync.Mutex
+
+// CommunicationIdentityClientMetrics exposes the [ClientStats] of a client as [expvar.Var]
+type CommunicationIdentityClientMetrics struct {
+	mu     sync.RWMutex
+	client CommunicationIdentityClient
+}
+
+var _ expvar.Var = (*CommunicationIdentityClientMetrics)(nil)
+
+// String returns the current ClientStats as JSON
+func (metrics *CommunicationIdentityClientMetrics) String() string {
+	metrics.mu.RLock()
+	client := metrics.client
+	metrics.mu.RUnlock()
+	encoded, err := json.Marshal(client.Stats())
+	if err != nil {
+		// ClientStats only holds plain values, this does not happen
+		return "{}"
+	}
+	return string(encoded)
+}
+
+// Metrics returns the metrics of the client, e.g. to publish them in a custom expvar.Map
+func (client CommunicationIdentityClient) Metrics() *CommunicationIdentityClientMetrics {
+	return &CommunicationIdentityClientMetrics{client: client}
+}
+
+// WithExpvar publishes the metrics of the client under name in the default expvar map, which is
+// served at /debug/vars once the expvar package is imported. As expvars can not be removed, a
+// client created later with the same name replaces the earlier client in the published metrics.
+// [New] fails if name is taken by an expvar not published by this option.
+func WithExpvar(name string) ClientOption {
+	return func(options *clientOptions) error {
+		if name == "" {
+			return fmt.Errorf("expvar name can not be empty")
+		}
+		options.expvarName = name
+		return nil
+	}
+}
+
+func (client CommunicationIdentityClient) publishExpvar() error {
+	name := client.options.expvarName
+	if name == "" {
+		return nil
+	}
+	expvarMu.Lock()
+	defer expvarMu.Unlock()
+	switch published := expvar.Get(name).(type) {
+	case nil:
+		expvar.Publish(name, client.Metrics())
+	case *CommunicationIdentityClientMetrics:
+		published.mu.Lock()
+		published.client = client
+		published.mu.Unlock()
+	default:
+		return fmt.Errorf("expvar %q is already published", name)
+	}
+	return nil
+}
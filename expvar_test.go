@@ -0,0 +1,43 @@
+package communicationidentity_test
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"testing"
+	"time"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+)
+
+func TestWithExpvar(t *testing.T) {
+	// expvars can not be removed, a fixed name would fail repeated test runs
+	name := fmt.Sprintf("test_communication_identity_client_%d", time.Now().UnixNano())
+	if _, err := ci.New(nil, testAccessKey, "", ci.WithExpvar(name)); err != nil {
+		t.Fatal(err)
+	}
+
+	published := expvar.Get(name)
+	if published == nil {
+		t.Fatal("expected metrics to be published")
+	}
+	var stats ci.ClientStats
+	if err := json.Unmarshal([]byte(published.String()), &stats); err != nil {
+		t.Fatalf("expected ClientStats JSON, got %q: %v", published.String(), err)
+	}
+
+	if _, err := ci.New(nil, testAccessKey, "", ci.WithExpvar(name)); err != nil {
+		t.Errorf("expected a recreated client to take over the metrics, got %v", err)
+	}
+	if expvar.Get(name) != published {
+		t.Error("expected the published metrics to be reused")
+	}
+}
+
+func TestWithExpvarRejectsForeignExpvar(t *testing.T) {
+	name := fmt.Sprintf("test_communication_identity_foreign_%d", time.Now().UnixNano())
+	expvar.NewInt(name)
+	if _, err := ci.New(nil, testAccessKey, "", ci.WithExpvar(name)); err == nil {
+		t.Error("expected a name taken by another expvar to be rejected")
+	}
+}
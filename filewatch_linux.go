@@ -0,0 +1,43 @@
+//go:build linux
+
+package communicationidentity
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// watchFile reports changes of the file at path through inotify. The directory is watched, as
+// secret managers often replace files, e.g. Kubernetes swaps a symlink of the secret directory.
+// The channel is closed once ctx is done or inotify fails.
+func watchFile(ctx context.Context, path string) (<-chan struct{}, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize inotify: %w", err)
+	}
+	const mask = syscall.IN_CLOSE_WRITE | syscall.IN_CREATE | syscall.IN_MOVED_TO |
+		syscall.IN_DELETE
+	if _, err := syscall.InotifyAddWatch(fd, filepath.Dir(path), mask); err != nil {
+		_ = syscall.Close(fd)
+		return nil, fmt.Errorf("failed to watch %q: %w", filepath.Dir(path), err)
+	}
+	// a non-blocking descriptor is served by the runtime poller, so Close unblocks Read
+	events := os.NewFile(uintptr(fd), "inotify")
+	context.AfterFunc(ctx, func() { _ = events.Close() })
+
+	changes := make(chan struct{}, 1)
+	go func() {
+		defer close(changes)
+		buffer := make([]byte, 4096)
+		for {
+			if _, err := events.Read(buffer); err != nil {
+				return
+			}
+			notifyChange(changes)
+		}
+	}()
+	return changes, nil
+}
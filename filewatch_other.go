@@ -0,0 +1,13 @@
+//go:build !linux
+
+package communicationidentity
+
+import (
+	"context"
+	"errors"
+)
+
+// watchFile is only implemented on Linux, other platforms poll the file
+func watchFile(context.Context, string) (<-chan struct{}, error) {
+	return nil, errors.ErrUnsupported
+}
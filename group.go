@@ -0,0 +1,76 @@
+package communicationidentity
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// Named set of clients sharing the same options, e.g. one client per ACS resource of the
+// dev, staging and prod environments. Safe for concurrent use.
+type CommunicationIdentityClientGroup struct {
+	mu         sync.RWMutex
+	sharedOpts []ClientOption
+	clients    map[string]CommunicationIdentityClient
+}
+
+// NewClientGroup creates an empty group, sharedOpts are applied to every client created by
+// [CommunicationIdentityClientGroup.AddFromConnectionString]
+func NewClientGroup(sharedOpts ...ClientOption) *CommunicationIdentityClientGroup {
+	return &CommunicationIdentityClientGroup{
+		sharedOpts: slices.Clone(sharedOpts),
+		clients:    make(map[string]CommunicationIdentityClient),
+	}
+}
+
+// Add registers client under name, replacing any client previously registered under that name
+func (group *CommunicationIdentityClientGroup) Add(
+	name string,
+	client CommunicationIdentityClient,
+) {
+	group.mu.Lock()
+	defer group.mu.Unlock()
+	group.clients[name] = client
+}
+
+// AddFromConnectionString creates a client with the group's shared options and registers it
+// under name, the endpoint must be an HTTPS URL as for [NewFromConnectionString].
+//
+// NOTE: connection strings carry no app registration ID, use
+// [CommunicationIdentityClientGroup.Add] for clients calling
+// [CommunicationIdentityClient.TokenForTeamsUser]
+func (group *CommunicationIdentityClientGroup) AddFromConnectionString(
+	name string,
+	connStr string,
+) error {
+	client, err := NewFromConnectionString(connStr, "", group.sharedOpts...)
+	if err != nil {
+		return fmt.Errorf("failed to create client %q: %w", name, err)
+	}
+	group.Add(name, client)
+	return nil
+}
+
+func (group *CommunicationIdentityClientGroup) Get(
+	name string,
+) (CommunicationIdentityClient, bool) {
+	group.mu.RLock()
+	defer group.mu.RUnlock()
+	client, found := group.clients[name]
+	return client, found
+}
+
+// Close closes all clients of the group and removes them, see [CommunicationIdentityClient.Close]
+func (group *CommunicationIdentityClientGroup) Close() error {
+	group.mu.Lock()
+	defer group.mu.Unlock()
+	var errs []error
+	for name, client := range group.clients {
+		if err := client.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close client %q: %w", name, err))
+		}
+	}
+	clear(group.clients)
+	return errors.Join(errs...)
+}
@@ -0,0 +1,61 @@
+package communicationidentity_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+)
+
+func TestClientGroup(t *testing.T) {
+	group := ci.NewClientGroup(ci.WithMaxCacheSize(10))
+
+	err := group.AddFromConnectionString(
+		"prod",
+		"Endpoint=https://prod.communication.azure.com/;AccessKey="+testAccessKey,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prod, found := group.Get("prod")
+	if !found {
+		t.Error("expected client to be registered")
+	}
+	if _, found := group.Get("dev"); found {
+		t.Error("expected unknown name not to be found")
+	}
+
+	for name, connStr := range map[string]string{
+		"missing key":      "endpoint=https://dev.communication.azure.com/",
+		"missing endpoint": "accesskey=" + testAccessKey,
+		"plain http":       "endpoint=http://dev.communication.azure.com/;accesskey=" + testAccessKey,
+		"invalid key":      "endpoint=https://dev.communication.azure.com/;accesskey=not base64",
+	} {
+		if err := group.AddFromConnectionString("dev", connStr); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+
+	if err := group.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, found := group.Get("prod"); found {
+		t.Error("expected group to be empty after Close")
+	}
+	_, err = prod.CreateCommunicationIdentity(context.Background(), []string{"chat"}, nil)
+	if !errors.Is(err, ci.ErrClientClosed) {
+		t.Errorf("expected the clients of the group to be closed, got %v", err)
+	}
+}
+
+func TestClientGroupSharedOptionsAreValidated(t *testing.T) {
+	group := ci.NewClientGroup(ci.WithMaxCacheSize(0))
+	err := group.AddFromConnectionString(
+		"prod",
+		"endpoint=https://prod.communication.azure.com/;accesskey="+testAccessKey,
+	)
+	if err == nil {
+		t.Error("expected invalid shared option to be reported")
+	}
+}
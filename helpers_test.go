@@ -0,0 +1,53 @@
+package communicationidentity_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+)
+
+// base64 of "secret"
+const testAccessKey = "c2VjcmV0"
+
+func newTestClient(
+	t testing.TB,
+	handler http.HandlerFunc,
+	opts ...ci.ClientOption,
+) ci.CommunicationIdentityClient {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	endpoint, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := ci.New(endpoint, testAccessKey, "test-client-id", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+// createIdentityHandler answers every request with a new identity and counts the requests
+func createIdentityHandler(calls *atomic.Int32) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(ci.CommunicationIdentityAccessTokenResult{
+			AccessToken: ci.CommunicationIdentityAccessToken{
+				Token:     fmt.Sprintf("token-%d", n),
+				ExpiresOn: time.Now().Add(time.Hour).UTC(),
+			},
+			Identity: ci.CommunicationIdentity{ID: fmt.Sprintf("identity-%d", n)},
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package communicationidentity
+
+import (
+	"errors"
+	"net/url"
+	"sync"
+)
+
+// initStep is blocking work of New that is independent of all other steps, e.g. decoding the
+// access key or resolving the endpoint host. acsEndpoint is the endpoint of the client, nil if
+// none is set. Steps must only write state they own.
+type initStep func(acsEndpoint *url.URL) error
+
+// WithConcurrentInitialization runs the independent initialization steps of [New] in parallel
+// instead of one after another, i.e. decoding the access key and resolving the endpoint host
+// with [WithEndpointResolution]. Options themselves are still applied in order, since later
+// options override earlier ones. All steps run to completion, any error aborts the construction.
+func WithConcurrentInitialization() ClientOption {
+	return func(options *clientOptions) error {
+		options.concurrentInit = true
+		return nil
+	}
+}
+
+// addInitStep registers a step run by New after all options have been applied
+func (options *clientOptions) addInitStep(step initStep) {
+	options.initSteps = append(options.initSteps, step)
+}
+
+// runInitSteps returns the joined errors of all failed steps
+func runInitSteps(steps []initStep, acsEndpoint *url.URL, concurrent bool) error {
+	errs := make([]error, len(steps))
+	if !concurrent {
+		for i, step := range steps {
+			if errs[i] = step(acsEndpoint); errs[i] != nil {
+				break
+			}
+		}
+		return errors.Join(errs...)
+	}
+
+	var wg sync.WaitGroup
+	for i, step := range steps {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = step(acsEndpoint)
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
@@ -0,0 +1,58 @@
+package communicationidentity
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestRunInitStepsConcurrently(t *testing.T) {
+	// each step waits for the other one, this only completes if both run at the same time
+	first, second := make(chan struct{}), make(chan struct{})
+	waitFor := func(done chan struct{}, other chan struct{}) initStep {
+		return func(*url.URL) error {
+			close(done)
+			select {
+			case <-other:
+				return nil
+			case <-time.After(time.Second):
+				return errors.New("steps did not run concurrently")
+			}
+		}
+	}
+	steps := []initStep{waitFor(first, second), waitFor(second, first)}
+	if err := runInitSteps(steps, nil, true); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunInitStepsJoinsErrors(t *testing.T) {
+	errFirst, errSecond := errors.New("first"), errors.New("second")
+	steps := []initStep{
+		func(*url.URL) error { return errFirst },
+		func(*url.URL) error { return errSecond },
+	}
+
+	err := runInitSteps(steps, nil, true)
+	if !errors.Is(err, errFirst) || !errors.Is(err, errSecond) {
+		t.Errorf("expected both errors, got %v", err)
+	}
+	err = runInitSteps(steps, nil, false)
+	if !errors.Is(err, errFirst) || errors.Is(err, errSecond) {
+		t.Errorf("expected sequential steps to stop at the first error, got %v", err)
+	}
+}
+
+func TestNewWithConcurrentInitializationRejectsInvalidKey(t *testing.T) {
+	if _, err := New(nil, "not base64!", "", WithConcurrentInitialization()); err == nil {
+		t.Error("expected invalid access key to abort construction")
+	}
+	client, err := New(nil, "c2VjcmV0", "", WithConcurrentInitialization())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(client.decodedAcsSecret) != "secret" {
+		t.Errorf("unexpected decoded key: %q", client.decodedAcsSecret)
+	}
+}
@@ -0,0 +1,113 @@
+package communicationidentity
+
+import (
+	"context"
+	"fmt"
+)
+
+// UnaryInterceptor intercepts every ACS operation of the client like its gRPC counterpart.
+// method is the name of the operation, e.g. "CreateCommunicationIdentity", req a pointer to its
+// request struct (e.g. [*CreateCommunicationIdentityRequest]) and reply a pointer to its result,
+// filled by invoker. Interceptors may change the request in place before calling invoker.
+type UnaryInterceptor func(
+	ctx context.Context,
+	method string,
+	req, reply any,
+	invoker func(context.Context, string, any, any) error,
+) error
+
+// WithInterceptors adds interceptors to all ACS operations, the first interceptor is the
+// outermost one. Operations served without contacting ACS, e.g. by the token cache, are not
+// intercepted.
+func WithInterceptors(interceptors ...UnaryInterceptor) ClientOption {
+	return func(options *clientOptions) error {
+		for i, interceptor := range interceptors {
+			if interceptor == nil {
+				return fmt.Errorf("interceptor at index %d is nil", i)
+			}
+		}
+		options.interceptors = append(options.interceptors, interceptors...)
+		return nil
+	}
+}
+
+// Request of the "CreateCommunicationIdentity" operation
+type CreateCommunicationIdentityRequest struct {
+	Scopes          []string
+	ExpireInMinutes *int32
+}
+
+// Request of the "TokenForTeamsUser" and "TokenForTeamsUserStream" operations, the reply of the
+// latter is a *struct{} as the response is written to the caller's writer
+type TokenForTeamsUserRequest struct {
+	UserOID   string
+	MSALToken string
+}
+
+// Request of the "IssueAccessToken" operation
+type IssueAccessTokenRequest struct {
+	IdentityID      string
+	Scopes          []string
+	ExpireInMinutes *int32
+}
+
+// Request of the "DeleteCommunicationIdentity" operation, its reply is a *struct{}
+type DeleteCommunicationIdentityRequest struct {
+	IdentityID string
+}
+
+// Request of the "RevokeAccessTokens" operation, its reply is a *struct{}
+type RevokeAccessTokensRequest struct {
+	IdentityID string
+}
+
+// intercept runs call through the configured interceptors, within the span of the operation
+func intercept[Req, Reply any](
+	client CommunicationIdentityClient,
+	ctx context.Context,
+	method string,
+	req *Req,
+	call func(context.Context, *Req) (Reply, error),
+) (Reply, error) {
+	ctx, span := client.startSpan(withOperation(ctx, method), method)
+	reply, err := runInterceptors(client, ctx, method, req, call)
+	endSpan(span, err)
+	return reply, err
+}
+
+func runInterceptors[Req, Reply any](
+	client CommunicationIdentityClient,
+	ctx context.Context,
+	method string,
+	req *Req,
+	call func(context.Context, *Req) (Reply, error),
+) (Reply, error) {
+	interceptors := client.options.interceptors
+	if len(interceptors) == 0 {
+		return call(ctx, req)
+	}
+
+	invoker := func(ctx context.Context, method string, req, reply any) error {
+		typedReq, ok := req.(*Req)
+		if !ok {
+			return fmt.Errorf("interceptor passed %T as request of %s, expected %T", req, method, typedReq)
+		}
+		typedReply, ok := reply.(*Reply)
+		if !ok {
+			return fmt.Errorf("interceptor passed %T as reply of %s, expected %T", reply, method, typedReply)
+		}
+		result, err := call(ctx, typedReq)
+		*typedReply = result
+		return err
+	}
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		next, interceptor := invoker, interceptors[i]
+		invoker = func(ctx context.Context, method string, req, reply any) error {
+			return interceptor(ctx, method, req, reply, next)
+		}
+	}
+
+	var reply Reply
+	err := invoker(ctx, method, req, &reply)
+	return reply, err
+}
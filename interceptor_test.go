@@ -0,0 +1,76 @@
+package communicationidentity_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"sync/atomic"
+	"testing"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+)
+
+func TestWithInterceptors(t *testing.T) {
+	var calls []string
+	recording := func(name string) ci.UnaryInterceptor {
+		return func(
+			ctx context.Context,
+			method string,
+			req, reply any,
+			invoker func(context.Context, string, any, any) error,
+		) error {
+			calls = append(calls, name+":"+method)
+			return invoker(ctx, method, req, reply)
+		}
+	}
+	client := newTestClient(t, createIdentityHandler(new(atomic.Int32)),
+		ci.WithInterceptors(recording("outer"), recording("inner")),
+	)
+
+	result, err := client.CreateCommunicationIdentity(context.Background(), []string{"chat"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Identity.ID != "identity-1" {
+		t.Errorf("expected reply of the invoker to be returned, got %+v", result)
+	}
+	want := []string{"outer:CreateCommunicationIdentity", "inner:CreateCommunicationIdentity"}
+	if !slices.Equal(calls, want) {
+		t.Errorf("expected calls %v, got %v", want, calls)
+	}
+}
+
+func TestInterceptorModifiesRequest(t *testing.T) {
+	var scopes []string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Scopes []string `json:"createTokenWithScopes"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		scopes = body.Scopes
+		createIdentityHandler(new(atomic.Int32))(w, r)
+	}, ci.WithInterceptors(func(
+		ctx context.Context,
+		method string,
+		req, reply any,
+		invoker func(context.Context, string, any, any) error,
+	) error {
+		req.(*ci.CreateCommunicationIdentityRequest).Scopes = []string{"voip"}
+		return invoker(ctx, method, req, reply)
+	}))
+
+	_, err := client.CreateCommunicationIdentity(context.Background(), []string{"chat"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(scopes, []string{"voip"}) {
+		t.Errorf("expected intercepted scopes to be sent, got %v", scopes)
+	}
+}
+
+func TestWithInterceptorsRejectsNil(t *testing.T) {
+	if _, err := ci.New(nil, testAccessKey, "", ci.WithInterceptors(nil)); err == nil {
+		t.Error("expected nil interceptor to be rejected")
+	}
+}
@@ -0,0 +1,106 @@
+package communicationidentity
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// JitterStrategy randomizes the retry delays of [WithRetry], so clients failing at the same time do
+// not retry in lockstep, see [WithRetryJitter]. The exponential delay is min(cap, base*2^n) for
+// the n-th retry, with cap set through [WithRetryMaxDelay].
+type JitterStrategy int
+
+const (
+	// a random delay between zero and the exponential delay, used by default
+	JitterStrategyFull JitterStrategy = iota
+	// half of the exponential delay plus a random delay up to the other half
+	JitterStrategyEqual
+	// the exponential delay as is
+	JitterStrategyNone
+)
+
+func (strategy JitterStrategy) String() string {
+	switch strategy {
+	case JitterStrategyFull:
+		return "Full"
+	case JitterStrategyEqual:
+		return "Equal"
+	case JitterStrategyNone:
+		return "None"
+	default:
+		return fmt.Sprintf("JitterStrategy(%d)", int(strategy))
+	}
+}
+
+// apply returns the jittered delay for an exponential delay of d
+func (strategy JitterStrategy) apply(d time.Duration, random *lockedRand) time.Duration {
+	switch strategy {
+	case JitterStrategyEqual:
+		half := d / 2
+		return half + random.duration(d-half)
+	case JitterStrategyNone:
+		return d
+	default:
+		return random.duration(d)
+	}
+}
+
+// WithRetryJitter selects how the retry delays of [WithRetry] are randomized, defaults to
+// [JitterStrategyFull]
+func WithRetryJitter(strategy JitterStrategy) ClientOption {
+	return func(options *clientOptions) error {
+		switch strategy {
+		case JitterStrategyFull, JitterStrategyEqual, JitterStrategyNone:
+			options.retry.jitter = strategy
+			return nil
+		default:
+			return fmt.Errorf("unknown jitter strategy: %v", strategy)
+		}
+	}
+}
+
+// WithRetryMaxDelay caps the exponential retry delay of [WithRetry], defaults to 30 seconds
+func WithRetryMaxDelay(d time.Duration) ClientOption {
+	return func(options *clientOptions) error {
+		if d <= 0 {
+			return fmt.Errorf("retry max delay must be positive, got %v", d)
+		}
+		options.retry.maxDelay = d
+		return nil
+	}
+}
+
+// WithRetrySeed seeds the random source of the retry jitter, e.g. for deterministic delays in
+// tests. By default the source is seeded randomly.
+func WithRetrySeed(seed uint64) ClientOption {
+	return func(options *clientOptions) error {
+		options.retry.seed = &seed
+		return nil
+	}
+}
+
+// lockedRand is a random source safe for concurrent use
+type lockedRand struct {
+	mu     sync.Mutex
+	random *rand.Rand
+}
+
+func newLockedRand(seed *uint64) *lockedRand {
+	s := rand.Uint64()
+	if seed != nil {
+		s = *seed
+	}
+	return &lockedRand{random: rand.New(rand.NewPCG(s, s))}
+}
+
+// duration returns a random duration in [0, d]
+func (random *lockedRand) duration(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	random.mu.Lock()
+	defer random.mu.Unlock()
+	return time.Duration(random.random.Int64N(int64(d) + 1))
+}
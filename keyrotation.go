@@ -0,0 +1,130 @@
+package communicationidentity
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+)
+
+// number of successful requests signed with the secondary key after which it is promoted
+const secondaryKeyPromotionThreshold = 3
+
+// RotatingKeyManager holds the primary and secondary access key of an ACS resource for zero
+// downtime key rotation, see [WithRotatingKeyManager]. Requests are signed with the primary key,
+// if ACS rejects it with 401 the request is retried once with the secondary key. After
+// 3 successful requests with the secondary key, it is promoted to primary automatically.
+//
+// The zero value holds no keys and is ready to use, a manager must not be copied after first use.
+type RotatingKeyManager struct {
+	mu                 sync.Mutex
+	primary            []byte
+	secondary          []byte
+	secondarySuccesses int
+	// changes whenever the secondary key is replaced
+	secondaryGeneration int
+}
+
+// SetPrimaryKey replaces the primary key, key is the base64 encoded key of the Azure portal
+func (manager *RotatingKeyManager) SetPrimaryKey(key string) error {
+	decoded, err := decodeAccessKey(key)
+	if err != nil {
+		return err
+	}
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+	manager.primary = decoded
+	return nil
+}
+
+// SetSecondaryKey replaces the secondary key, key is the base64 encoded key of the Azure portal
+func (manager *RotatingKeyManager) SetSecondaryKey(key string) error {
+	decoded, err := decodeAccessKey(key)
+	if err != nil {
+		return err
+	}
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+	manager.secondary = decoded
+	manager.secondarySuccesses = 0
+	manager.secondaryGeneration++
+	return nil
+}
+
+// PromoteSecondaryToPrimary makes the secondary key the primary one and clears the secondary key
+func (manager *RotatingKeyManager) PromoteSecondaryToPrimary() error {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+	return manager.promoteLocked()
+}
+
+func (manager *RotatingKeyManager) promoteLocked() error {
+	if manager.secondary == nil {
+		return fmt.Errorf("no secondary key to promote")
+	}
+	manager.primary = manager.secondary
+	manager.secondary = nil
+	manager.secondarySuccesses = 0
+	manager.secondaryGeneration++
+	return nil
+}
+
+// initPrimary sets a copy of decoded as primary key if none is set yet, the manager may be shared
+// by clients that zero their own key on Close
+func (manager *RotatingKeyManager) initPrimary(decoded []byte) {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+	if manager.primary == nil {
+		manager.primary = bytes.Clone(decoded)
+	}
+}
+
+func (manager *RotatingKeyManager) primaryKey() []byte {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+	return manager.primary
+}
+
+func (manager *RotatingKeyManager) secondaryKey() ([]byte, int) {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+	return manager.secondary, manager.secondaryGeneration
+}
+
+// recordSecondarySuccess counts a successful request signed with the secondary key of generation
+// and reports whether the secondary key was promoted because of it
+func (manager *RotatingKeyManager) recordSecondarySuccess(generation int) bool {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+	// the keys may have been replaced while the request was in flight
+	if manager.secondary == nil || manager.secondaryGeneration != generation {
+		return false
+	}
+	manager.secondarySuccesses++
+	if manager.secondarySuccesses < secondaryKeyPromotionThreshold {
+		return false
+	}
+	return manager.promoteLocked() == nil
+}
+
+// WithRotatingKeyManager signs requests with the keys of manager instead of the access key passed
+// to [New]. If manager has no primary key yet, it is initialised with the access key of [New].
+func WithRotatingKeyManager(manager *RotatingKeyManager) ClientOption {
+	return func(options *clientOptions) error {
+		if manager == nil {
+			return fmt.Errorf("rotating key manager can not be nil")
+		}
+		options.keyManager = manager
+		return nil
+	}
+}
+
+// accessKey returns the key requests are signed with
+func (client CommunicationIdentityClient) accessKey() []byte {
+	if client.options.keyManager != nil {
+		return client.options.keyManager.primaryKey()
+	}
+	if rotated := client.state.rotatedKey.Load(); rotated != nil {
+		return *rotated
+	}
+	return client.decodedAcsSecret
+}
@@ -0,0 +1,101 @@
+package communicationidentity_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+)
+
+// exposes the signing key in the Authorization header
+type keySigner struct{}
+
+func (keySigner) AlgorithmName() string { return "HMAC-SHA256" }
+
+func (keySigner) Sign(_ string, key []byte) (string, error) { return string(key), nil }
+
+// accepts only requests signed with the "new" key
+func rotatedKeyHandler(rejected *atomic.Int32) http.HandlerFunc {
+	created := createIdentityHandler(new(atomic.Int32))
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.Header.Get("Authorization"), "Signature=new") {
+			rejected.Add(1)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":{"code":"Denied","message":"invalid key"}}`))
+			return
+		}
+		created(w, r)
+	}
+}
+
+func TestRotatingKeyManagerRetriesWithSecondaryKey(t *testing.T) {
+	var rejected atomic.Int32
+	manager := &ci.RotatingKeyManager{}
+	if err := manager.SetPrimaryKey("b2xk"); err != nil { // "old"
+		t.Fatal(err)
+	}
+	if err := manager.SetSecondaryKey("bmV3"); err != nil { // "new"
+		t.Fatal(err)
+	}
+	events := make(chan ci.ClientEvent, 8)
+	client := newTestClient(t, rotatedKeyHandler(&rejected),
+		ci.WithSigner(keySigner{}),
+		ci.WithRotatingKeyManager(manager),
+		ci.WithEventEmitter(ci.NewChannelEventEmitter(events, 0)),
+	)
+
+	ctx := context.Background()
+	for i := range 4 {
+		if _, err := client.CreateCommunicationIdentity(ctx, []string{"chat"}, nil); err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+	}
+	if rejected.Load() != 3 {
+		t.Errorf("expected the primary key to be promoted after 3 retries, got %d rejections",
+			rejected.Load())
+	}
+	if err := manager.PromoteSecondaryToPrimary(); err == nil {
+		t.Error("expected no secondary key to be left after promotion")
+	}
+
+	var fallbacks int
+	for len(events) > 0 {
+		event := <-events
+		if data, ok := event.Data.(ci.PrimaryKeyRejectedEvent); ok &&
+			event.Kind == ci.ClientEventPrimaryKeyRejected && data.Method == http.MethodPost {
+			fallbacks++
+		}
+	}
+	if fallbacks != 3 {
+		t.Errorf("expected an event per fallback to the secondary key, got %d", fallbacks)
+	}
+}
+
+func TestRotatingKeyManagerWithoutSecondaryKey(t *testing.T) {
+	var rejected atomic.Int32
+	client := newTestClient(t, rotatedKeyHandler(&rejected),
+		ci.WithSigner(keySigner{}),
+		ci.WithRotatingKeyManager(&ci.RotatingKeyManager{}),
+	)
+	_, err := client.CreateCommunicationIdentity(context.Background(), []string{"chat"}, nil)
+	if err == nil {
+		t.Fatal("expected request signed with the access key of New to be rejected")
+	}
+	if rejected.Load() != 1 {
+		t.Errorf("expected no retry without secondary key, got %d rejections", rejected.Load())
+	}
+}
+
+func TestRotatingKeyManagerRejectsInvalidKeys(t *testing.T) {
+	manager := &ci.RotatingKeyManager{}
+	if err := manager.SetPrimaryKey("not base64!"); err == nil {
+		t.Error("expected invalid primary key to be rejected")
+	}
+	if err := manager.SetSecondaryKey("not base64!"); err == nil {
+		t.Error("expected invalid secondary key to be rejected")
+	}
+}
@@ -0,0 +1,55 @@
+package communicationidentity
+
+import (
+	"fmt"
+	"log/slog"
+	"net/url"
+)
+
+// WithLogger writes the log output of the client to l instead of [slog.Default]. Requests and
+// responses are logged at debug level, retries at info level and failures that are not returned
+// to the caller, e.g. closing a response body, as warnings. The configured endpoint is logged at
+// info level once the client is created, with the access key masked by [MaskConnectionString].
+func WithLogger(l *slog.Logger) ClientOption {
+	return func(options *clientOptions) error {
+		if l == nil {
+			return fmt.Errorf("logger can not be nil")
+		}
+		options.logger = l
+		return nil
+	}
+}
+
+func (client CommunicationIdentityClient) logger() *slog.Logger {
+	if client.options.logger == nil {
+		return slog.Default()
+	}
+	return client.options.logger
+}
+
+// logStartup logs the configured endpoint and masked access key, only to a logger of WithLogger
+func (client CommunicationIdentityClient) logStartup(accessKey string) {
+	if client.options.logger == nil {
+		return
+	}
+	connStr := MaskConnectionString(fmt.Sprintf(
+		"%s=%s;%s=%s",
+		connectionStringEndpoint, logURL(client.acsEndpoint),
+		connectionStringAccessKey, accessKey,
+	))
+	client.options.logger.Info("created ACS identity client", "connection_string", connStr.String())
+}
+
+// logURL returns u without query, fragment and user info for log attributes
+func logURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	stripped := *u
+	stripped.User = nil
+	stripped.RawQuery = ""
+	stripped.ForceQuery = false
+	stripped.Fragment = ""
+	stripped.RawFragment = ""
+	return stripped.String()
+}
@@ -0,0 +1,101 @@
+package communicationidentity_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+)
+
+type logRecord struct {
+	Message string `json:"msg"`
+	Method  string `json:"method"`
+	URL     string `json:"url"`
+	Status  int    `json:"status"`
+	Attempt int    `json:"attempt"`
+}
+
+func TestWithLogger(t *testing.T) {
+	var out bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&out, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	var keys []string
+	client := newTestClient(t, flakyHandler(http.StatusServiceUnavailable, 1, &keys),
+		ci.WithLogger(logger),
+		ci.WithRetry(2, time.Millisecond),
+	)
+	out.Reset()
+	if _, err := client.CreateCommunicationIdentity(
+		context.Background(), []string{"chat"}, nil,
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	var messages []string
+	var statuses []int
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var record logRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatal(err)
+		}
+		if record.Method != http.MethodPost || !strings.HasSuffix(record.URL, "/identities") {
+			t.Errorf("expected method and URL without query, got %s", line)
+		}
+		switch record.Message {
+		case "received ACS response":
+			statuses = append(statuses, record.Status)
+		case "retrying ACS request":
+			if record.Attempt != 1 || record.Status != http.StatusServiceUnavailable {
+				t.Errorf("expected the failed first attempt, got %s", line)
+			}
+		}
+		messages = append(messages, record.Message)
+	}
+	want := []string{
+		"sending ACS request", "received ACS response", "retrying ACS request",
+		"sending ACS request", "received ACS response",
+	}
+	if strings.Join(messages, ",") != strings.Join(want, ",") {
+		t.Errorf("expected %v, got %v", want, messages)
+	}
+	if len(statuses) != 2 || statuses[0] != http.StatusServiceUnavailable ||
+		statuses[1] != http.StatusCreated {
+		t.Errorf("expected the status of both attempts, got %v", statuses)
+	}
+}
+
+func TestWithLoggerLogsMaskedStartup(t *testing.T) {
+	var out bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&out, nil))
+	endpoint, _ := url.Parse("https://example.communication.azure.com")
+	if _, err := ci.New(endpoint, testAccessKey, "", ci.WithLogger(logger)); err != nil {
+		t.Fatal(err)
+	}
+
+	var record struct {
+		Message          string `json:"msg"`
+		ConnectionString string `json:"connection_string"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &record); err != nil {
+		t.Fatalf("expected a single startup line, got %q: %v", out.String(), err)
+	}
+	want := "endpoint=https://example.communication.azure.com;accesskey=<redacted>"
+	if record.Message != "created ACS identity client" || record.ConnectionString != want {
+		t.Errorf("expected the masked connection string %q, got %+v", want, record)
+	}
+	if strings.Contains(out.String(), testAccessKey) {
+		t.Errorf("expected the access key not to be logged, got %s", out.String())
+	}
+}
+
+func TestWithLoggerRejectsNil(t *testing.T) {
+	if _, err := ci.New(nil, testAccessKey, "", ci.WithLogger(nil)); err == nil {
+		t.Error("expected a nil logger to be rejected")
+	}
+}
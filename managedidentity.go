@@ -0,0 +1,49 @@
+package communicationidentity
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+)
+
+// NewFromManagedIdentity creates a client authenticating with Entra ID tokens of the system
+// assigned managed identity of the Azure service it runs on, e.g. App Service, AKS or ACI. No
+// access key is needed, azClientId and opts are passed to [New].
+//
+// Other azidentity credentials, e.g. of a user assigned managed identity, can be adapted for
+// [NewWithTokenCredential].
+func NewFromManagedIdentity(
+	endpoint *url.URL,
+	azClientId string,
+	opts ...ClientOption,
+) (CommunicationIdentityClient, error) {
+	cred, err := azidentity.NewManagedIdentityCredential(nil)
+	if err != nil {
+		return CommunicationIdentityClient{}, fmt.Errorf(
+			"failed to create managed identity credential: %w",
+			err,
+		)
+	}
+	opts = append([]ClientOption{WithTokenCredential(azureTokenCredential{cred})}, opts...)
+	return New(endpoint, "", azClientId, opts...)
+}
+
+// azureTokenCredential adapts an azcore credential to TokenCredential
+type azureTokenCredential struct {
+	cred azcore.TokenCredential
+}
+
+func (adapter azureTokenCredential) GetToken(
+	ctx context.Context,
+	opts TokenRequestOptions,
+) (AccessToken, error) {
+	token, err := adapter.cred.GetToken(ctx, policy.TokenRequestOptions{Scopes: opts.Scopes})
+	if err != nil {
+		return AccessToken{}, err
+	}
+	return AccessToken{Token: token.Token, ExpiresOn: token.ExpiresOn}, nil
+}
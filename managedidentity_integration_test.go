@@ -0,0 +1,41 @@
+//go:build integration
+
+package communicationidentity_test
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"testing"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+)
+
+// Runs in CI on an Azure host whose managed identity has access to the ACS resource at
+// ACS_ENDPOINT: go test -tags integration -run TestManagedIdentityIntegration
+func TestManagedIdentityIntegration(t *testing.T) {
+	rawEndpoint := os.Getenv(ci.EnvACSEndpoint)
+	if rawEndpoint == "" {
+		t.Skipf("%s is not set", ci.EnvACSEndpoint)
+	}
+	endpoint, err := url.Parse(rawEndpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := ci.NewFromManagedIdentity(endpoint, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	result, err := client.CreateCommunicationIdentity(ctx, []string{string(ci.ScopeChat)}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.AccessToken.Token == "" {
+		t.Error("expected a token for the new identity")
+	}
+	if err := client.DeleteCommunicationIdentity(ctx, result.Identity.ID); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,123 @@
+package communicationidentity
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// bucket boundaries of the request duration histogram, the ones recommended by the semantic
+// conventions for `http.client.request.duration` converted to milliseconds
+var requestDurationBuckets = []float64{
+	5, 10, 25, 50, 75, 100, 250, 500, 750, 1000, 2500, 5000, 7500, 10000,
+}
+
+// WithMeterProvider records metrics of every request sent to ACS with mp:
+//
+//   - `communication_identity.request.duration`, a histogram of the durations in milliseconds
+//   - `communication_identity.request.count`, a counter of the requests
+//
+// Both carry the ACS operation as `method`, the status class (e.g. "2xx", "error" for transport
+// failures) as `status_class` and the service name of [WithServiceName] as `service.name`, along
+// with `http.request.method`, `http.response.status_code` and `server.address` of the semantic
+// conventions for HTTP clients. Without this option the global provider of
+// [otel.GetMeterProvider] is used.
+func WithMeterProvider(mp metric.MeterProvider) ClientOption {
+	return func(options *clientOptions) error {
+		if mp == nil {
+			return fmt.Errorf("meter provider can not be nil")
+		}
+		options.meterProvider = mp
+		return nil
+	}
+}
+
+// WithServiceName sets the `service.name` attribute of the metrics of [WithMeterProvider]
+func WithServiceName(name string) ClientOption {
+	return func(options *clientOptions) error {
+		if name == "" {
+			return fmt.Errorf("service name can not be empty")
+		}
+		options.serviceName = name
+		return nil
+	}
+}
+
+type requestMetrics struct {
+	duration metric.Float64Histogram
+	count    metric.Int64Counter
+	service  attribute.KeyValue
+}
+
+func newRequestMetrics(options clientOptions) (*requestMetrics, error) {
+	provider := options.meterProvider
+	if provider == nil {
+		provider = otel.GetMeterProvider()
+	}
+	meter := provider.Meter(instrumentationName)
+	duration, err := meter.Float64Histogram(
+		"communication_identity.request.duration",
+		metric.WithUnit("ms"),
+		metric.WithDescription("Duration of requests sent to ACS"),
+		metric.WithExplicitBucketBoundaries(requestDurationBuckets...),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request duration histogram: %w", err)
+	}
+	count, err := meter.Int64Counter(
+		"communication_identity.request.count",
+		metric.WithUnit("{request}"),
+		metric.WithDescription("Number of requests sent to ACS"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request counter: %w", err)
+	}
+	return &requestMetrics{
+		duration: duration,
+		count:    count,
+		service:  attribute.String("service.name", options.serviceName),
+	}, nil
+}
+
+// the ACS operation of a request, set by intercept
+type operationKey struct{}
+
+func withOperation(ctx context.Context, method string) context.Context {
+	return context.WithValue(ctx, operationKey{}, method)
+}
+
+// recordRequest records a request sent to ACS, response is nil for transport failures
+func (client CommunicationIdentityClient) recordRequest(
+	request *http.Request,
+	response *http.Response,
+	duration time.Duration,
+) {
+	if client.state == nil || client.state.metrics == nil {
+		return
+	}
+	metrics := client.state.metrics
+	ctx := request.Context()
+	operation, _ := ctx.Value(operationKey{}).(string)
+	attrs := []attribute.KeyValue{
+		attribute.String("method", operation),
+		metrics.service,
+		attribute.String("http.request.method", request.Method),
+		attribute.String("server.address", request.URL.Hostname()),
+	}
+	if response == nil {
+		attrs = append(attrs, attribute.String("status_class", "error"))
+	} else {
+		attrs = append(attrs,
+			attribute.String("status_class", fmt.Sprintf("%dxx", response.StatusCode/100)),
+			attribute.Int("http.response.status_code", response.StatusCode),
+		)
+	}
+	set := metric.WithAttributeSet(attribute.NewSet(attrs...))
+	metrics.duration.Record(ctx, float64(duration)/float64(time.Millisecond), set)
+	metrics.count.Add(ctx, 1, set)
+}
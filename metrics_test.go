@@ -0,0 +1,119 @@
+package communicationidentity_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+)
+
+func collectMetrics(t *testing.T, reader sdkmetric.Reader) map[string]metricdata.Metrics {
+	t.Helper()
+	var data metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &data); err != nil {
+		t.Fatal(err)
+	}
+	metrics := map[string]metricdata.Metrics{}
+	for _, scope := range data.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			metrics[m.Name] = m
+		}
+	}
+	return metrics
+}
+
+func TestWithMeterProvider(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	var keys []string
+	client := newTestClient(t, flakyHandler(http.StatusServiceUnavailable, 1, &keys),
+		ci.WithMeterProvider(provider),
+		ci.WithServiceName("checkout"),
+		ci.WithRetry(2, time.Millisecond),
+	)
+	if _, err := client.CreateCommunicationIdentity(
+		context.Background(), []string{"chat"}, nil,
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	metrics := collectMetrics(t, reader)
+	count, ok := metrics["communication_identity.request.count"].Data.(metricdata.Sum[int64])
+	if !ok {
+		t.Fatalf("expected the request counter, got %v", metrics)
+	}
+	counts := map[string]int64{}
+	for _, point := range count.DataPoints {
+		method, _ := point.Attributes.Value("method")
+		service, _ := point.Attributes.Value("service.name")
+		status, _ := point.Attributes.Value("status_class")
+		if method.AsString() != "CreateCommunicationIdentity" || service.AsString() != "checkout" {
+			t.Errorf("unexpected attributes %v", point.Attributes.ToSlice())
+		}
+		counts[status.AsString()] += point.Value
+	}
+	if counts["2xx"] != 1 || counts["5xx"] != 1 {
+		t.Errorf("expected one request per status class, got %v", counts)
+	}
+
+	duration, ok := metrics["communication_identity.request.duration"].
+		Data.(metricdata.Histogram[float64])
+	if !ok || metrics["communication_identity.request.duration"].Unit != "ms" {
+		t.Fatalf("expected the duration histogram in milliseconds, got %v", metrics)
+	}
+	var recorded uint64
+	for _, point := range duration.DataPoints {
+		recorded += point.Count
+		code, _ := point.Attributes.Value(attribute.Key("http.response.status_code"))
+		if code.AsInt64() == 0 {
+			t.Errorf("expected the status code attribute, got %v", point.Attributes.ToSlice())
+		}
+	}
+	if recorded != 2 {
+		t.Errorf("expected the duration of both attempts, got %d", recorded)
+	}
+}
+
+func TestWithMeterProviderRecordsTransportErrors(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			_ = conn.Close()
+		}
+	}, ci.WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))))
+	if _, err := client.CreateCommunicationIdentity(
+		context.Background(), []string{"chat"}, nil,
+	); err == nil {
+		t.Fatal("expected the closed connection to fail the request")
+	}
+
+	count := collectMetrics(t, reader)["communication_identity.request.count"].
+		Data.(metricdata.Sum[int64])
+	if len(count.DataPoints) != 1 {
+		t.Fatalf("expected a single data point, got %v", count.DataPoints)
+	}
+	status, _ := count.DataPoints[0].Attributes.Value("status_class")
+	if status.AsString() != "error" {
+		t.Errorf("expected the error status class, got %v", status.AsString())
+	}
+}
+
+func TestMetricsOptionValidation(t *testing.T) {
+	for name, opt := range map[string]ci.ClientOption{
+		"nil provider":       ci.WithMeterProvider(nil),
+		"empty service name": ci.WithServiceName(""),
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ci.New(nil, testAccessKey, "", opt); err == nil {
+				t.Error("expected option to be rejected")
+			}
+		})
+	}
+}
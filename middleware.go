@@ -0,0 +1,59 @@
+package communicationidentity
+
+import "context"
+
+// Operations offered by [CommunicationIdentityClient], allows decorating the client with
+// middlewares (see [MiddlewareChain]) and replacing it in tests
+type IdentityClient interface {
+	TokenForTeamsUser(
+		ctx context.Context,
+		userOid string,
+		teamsScopeMSALToken string,
+		opts ...CallOption,
+	) (CommunicationIdentityAccessToken, error)
+	CreateCommunicationIdentity(
+		ctx context.Context,
+		scope []string,
+		expireInMinutes *int32,
+		opts ...CallOption,
+	) (CommunicationIdentityAccessTokenResult, error)
+	IssueAccessToken(
+		ctx context.Context,
+		identityID string,
+		scopes []string,
+		expireInMinutes *int32,
+		opts ...CallOption,
+	) (CommunicationIdentityAccessToken, error)
+	DeleteCommunicationIdentity(ctx context.Context, identityID string, opts ...CallOption) error
+	RevokeAccessTokens(ctx context.Context, identityID string, opts ...CallOption) error
+}
+
+var _ IdentityClient = CommunicationIdentityClient{}
+
+// Decorates an [IdentityClient], e.g. with logging or retries
+type IdentityClientMiddleware func(IdentityClient) IdentityClient
+
+// Composes multiple [IdentityClientMiddleware]s, the zero value is an empty chain
+type MiddlewareChain struct {
+	middlewares []IdentityClientMiddleware
+}
+
+// Use appends middlewares to the chain, nil middlewares are ignored
+func (chain *MiddlewareChain) Use(mw ...IdentityClientMiddleware) *MiddlewareChain {
+	for _, middleware := range mw {
+		if middleware != nil {
+			chain.middlewares = append(chain.middlewares, middleware)
+		}
+	}
+	return chain
+}
+
+// Then wraps inner with all middlewares of the chain in declaration order,
+// the first middleware passed to [MiddlewareChain.Use] is the outermost one
+func (chain *MiddlewareChain) Then(inner IdentityClient) IdentityClient {
+	client := inner
+	for i := len(chain.middlewares) - 1; i >= 0; i-- {
+		client = chain.middlewares[i](client)
+	}
+	return client
+}
@@ -0,0 +1,61 @@
+package communicationidentity_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+)
+
+type recordingClient struct {
+	ci.IdentityClient
+	name  string
+	calls *[]string
+}
+
+func (client recordingClient) CreateCommunicationIdentity(
+	ctx context.Context,
+	scope []string,
+	expireInMinutes *int32,
+	opts ...ci.CallOption,
+) (ci.CommunicationIdentityAccessTokenResult, error) {
+	*client.calls = append(*client.calls, client.name)
+	if client.IdentityClient == nil {
+		return ci.CommunicationIdentityAccessTokenResult{}, nil
+	}
+	return client.IdentityClient.CreateCommunicationIdentity(ctx, scope, expireInMinutes, opts...)
+}
+
+func TestMiddlewareChainOrder(t *testing.T) {
+	var calls []string
+	recording := func(name string) ci.IdentityClientMiddleware {
+		return func(inner ci.IdentityClient) ci.IdentityClient {
+			return recordingClient{inner, name, &calls}
+		}
+	}
+
+	var chain ci.MiddlewareChain
+	client := chain.
+		Use(recording("outer"), nil).
+		Use(recording("inner")).
+		Then(recordingClient{nil, "client", &calls})
+
+	if _, err := client.CreateCommunicationIdentity(
+		context.Background(), []string{"chat"}, nil,
+	); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"outer", "inner", "client"}; !slices.Equal(calls, want) {
+		t.Errorf("expected call order %v, got %v", want, calls)
+	}
+}
+
+func TestEmptyMiddlewareChainReturnsInner(t *testing.T) {
+	var calls []string
+	inner := recordingClient{nil, "client", &calls}
+	var chain ci.MiddlewareChain
+	if client := chain.Then(inner); client != ci.IdentityClient(inner) {
+		t.Error("expected empty chain to return the inner client unchanged")
+	}
+}
@@ -0,0 +1,140 @@
+package communicationidentity
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Optional configuration passed to [New]. Options are applied in the order they are given,
+// an option returning an error aborts the construction of the client.
+type ClientOption func(*clientOptions) error
+
+type clientOptions struct {
+	identityIDValidator  IdentityIDValidator
+	timeSource           TimeSource
+	signer               Signer
+	signingHeaderBuilder SigningHeaderBuilder
+	maxCacheSize         int
+	evictionPolicy       EvictionPolicy
+	// substituted for nil expiries, see [WithDefaultExpirationDuration]
+	defaultExpireInMinutes *int32
+	ttlPolicy              *TokenTTLPolicy
+	// called for token lifecycle events, see [TokenIssuanceRecord]
+	tokenIssuanceRecorder  func(TokenIssuanceRecord)
+	teamsUserExchangeCache *TeamsUserExchangeCache
+	tokenCache             TokenCache
+	autoRefresh            *autoRefreshPolicy
+	auditSink              AuditSink
+	baseTransport          http.RoundTripper
+	fastStart              bool
+	keyManager             *RotatingKeyManager
+	rateLimitHandler       RateLimitHandler
+	concurrentInit         bool
+	initSteps              []initStep
+	connectionEndpoint     *url.URL
+	connectionAccessKey    string
+	interceptors           []UnaryInterceptor
+	expvarName             string
+	secureTokens           bool
+	sessionID              string
+	eventEmitter           EventEmitter
+	customHTTPClient       *http.Client
+	userAgent              string
+	apiVersion             azAPIVersion
+	retry                  retryPolicy
+	timeout                time.Duration
+	logger                 *slog.Logger
+	tracerProvider         trace.TracerProvider
+	meterProvider          metric.MeterProvider
+	serviceName            string
+	// called per call without client request id, see WithDefaultClientRequestID
+	clientRequestIDGenerator func() string
+	// replaces signing with the access key, see WithTokenCredential
+	tokenCredential TokenCredential
+	tokenScope      string
+	requestSigner   RequestSigner
+	// nil for the default limit, see WithMaxResponseBodySize
+	maxResponseBodySize *int64
+	circuitBreaker      *circuitBreakerPolicy
+	// assembled after all options were applied
+	httpClient *http.Client
+}
+
+func applyClientOptions(opts []ClientOption) (clientOptions, error) {
+	var options clientOptions
+	for i, opt := range opts {
+		if opt == nil {
+			return clientOptions{}, fmt.Errorf("client option at index %d is nil", i)
+		}
+		if err := opt(&options); err != nil {
+			return clientOptions{}, fmt.Errorf("failed to apply client option: %w", err)
+		}
+	}
+	options.httpClient = options.buildHTTPClient()
+	return options, nil
+}
+
+// WithUserAgent sends userAgent as 'User-Agent' header with every request, e.g. to identify the
+// calling application in ACS diagnostics
+func WithUserAgent(userAgent string) ClientOption {
+	return func(options *clientOptions) error {
+		if userAgent == "" {
+			return fmt.Errorf("user agent can not be empty")
+		}
+		options.userAgent = userAgent
+		return nil
+	}
+}
+
+// WithTimeout limits every call to ACS to d including retries and reading the response, on top
+// of the deadline of the caller's context: a shorter caller deadline still wins. Zero disables
+// the timeout, [WithCallTimeout] replaces it for a single call.
+func WithTimeout(d time.Duration) ClientOption {
+	return func(options *clientOptions) error {
+		if d < 0 {
+			return fmt.Errorf("timeout can not be negative")
+		}
+		options.timeout = d
+		return nil
+	}
+}
+
+// WithAPIVersion sends requests with API version v instead of [DefaultAPIVersion], e.g. to test a
+// preview version or to pin an older stable one. Exchanges of ADAL tokens keep their own version,
+// see [CommunicationIdentityClient.TokenForTeamsUserWithADAL].
+func WithAPIVersion(v string) ClientOption {
+	return func(options *clientOptions) error {
+		if v == "" {
+			return fmt.Errorf("api version can not be empty")
+		}
+		options.apiVersion = azAPIVersion(v)
+		return nil
+	}
+}
+
+func (client CommunicationIdentityClient) apiVersion() azAPIVersion {
+	if client.options.apiVersion == "" {
+		return DefaultAPIVersion
+	}
+	return client.options.apiVersion
+}
+
+// WithIdentityIDValidator registers a validator that is run against every identity ID passed to
+// the client, before any HTTP call is made. Failing validation results in a [ValidationError].
+//
+// see also: [DefaultIdentityIDValidator]
+func WithIdentityIDValidator(v IdentityIDValidator) ClientOption {
+	return func(options *clientOptions) error {
+		if v == nil {
+			return fmt.Errorf("identity ID validator can not be nil")
+		}
+		options.identityIDValidator = v
+		return nil
+	}
+}
@@ -0,0 +1,114 @@
+package communicationidentity_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+)
+
+func TestWithUserAgent(t *testing.T) {
+	var userAgent string
+	handler := createIdentityHandler(new(atomic.Int32))
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+		handler(w, r)
+	}, ci.WithUserAgent("my-app/1.0"))
+
+	if _, err := client.CreateCommunicationIdentity(
+		context.Background(), []string{"chat"}, nil,
+	); err != nil {
+		t.Fatal(err)
+	}
+	if userAgent != "my-app/1.0" {
+		t.Errorf("expected user agent my-app/1.0, got %q", userAgent)
+	}
+}
+
+func TestWithUserAgentRejectsEmpty(t *testing.T) {
+	if _, err := ci.New(nil, testAccessKey, "", ci.WithUserAgent("")); err == nil {
+		t.Error("expected error for empty user agent")
+	}
+}
+
+func TestWithAPIVersion(t *testing.T) {
+	var apiVersion string
+	client := newTestClient(t, teamsTokenHandler(&apiVersion), ci.WithAPIVersion("2026-01-01-preview"))
+
+	if _, err := client.TokenForTeamsUser(context.Background(), "oid", "msal-token"); err != nil {
+		t.Fatal(err)
+	}
+	if apiVersion != "2026-01-01-preview" {
+		t.Errorf("expected configured api-version, got %q", apiVersion)
+	}
+}
+
+func TestWithoutAPIVersionUsesDefault(t *testing.T) {
+	var apiVersion string
+	client := newTestClient(t, teamsTokenHandler(&apiVersion))
+
+	if _, err := client.TokenForTeamsUser(context.Background(), "oid", "msal-token"); err != nil {
+		t.Fatal(err)
+	}
+	if apiVersion != ci.DefaultAPIVersion || ci.DefaultAPIVersion != "2025-06-30" {
+		t.Errorf("expected api-version %s, got %q", ci.DefaultAPIVersion, apiVersion)
+	}
+}
+
+func TestWithAPIVersionRejectsEmpty(t *testing.T) {
+	if _, err := ci.New(nil, testAccessKey, "", ci.WithAPIVersion("")); err == nil {
+		t.Error("expected error for empty api version")
+	}
+}
+
+// answers after delay unless the request is cancelled first
+func slowHandler(delay time.Duration) http.HandlerFunc {
+	created := createIdentityHandler(new(atomic.Int32))
+	return func(w http.ResponseWriter, r *http.Request) {
+		// the server only notices a closed connection once the body is read
+		_, _ = io.Copy(io.Discard, r.Body)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(delay):
+			created(w, r)
+		}
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	client := newTestClient(t, slowHandler(time.Minute), ci.WithTimeout(20*time.Millisecond))
+	_, err := client.CreateCommunicationIdentity(context.Background(), []string{"chat"}, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the client timeout to expire, got %v", err)
+	}
+
+	fast := newTestClient(t, slowHandler(0), ci.WithTimeout(time.Minute))
+	if _, err := fast.CreateCommunicationIdentity(
+		context.Background(), []string{"chat"}, nil,
+	); err != nil {
+		t.Errorf("expected the response to be read within the timeout, got %v", err)
+	}
+}
+
+func TestWithTimeoutShorterCallerDeadlineWins(t *testing.T) {
+	for name, timeout := range map[string]time.Duration{"disabled": 0, "longer": time.Hour} {
+		client := newTestClient(t, slowHandler(time.Minute), ci.WithTimeout(timeout))
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		_, err := client.CreateCommunicationIdentity(ctx, []string{"chat"}, nil)
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("%s: expected the caller deadline to expire, got %v", name, err)
+		}
+	}
+}
+
+func TestWithTimeoutRejectsNegative(t *testing.T) {
+	if _, err := ci.New(nil, testAccessKey, "", ci.WithTimeout(-time.Second)); err == nil {
+		t.Error("expected negative timeout to be rejected")
+	}
+}
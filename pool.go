@@ -0,0 +1,369 @@
+package communicationidentity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	// consecutive failures after which a region of an IdentityClientPool is skipped
+	poolCircuitFailureThreshold = 5
+	poolCircuitCooldown         = 30 * time.Second
+	// latencies kept per region for RegionMetrics.P50LatencyMs
+	poolLatencySamples = 128
+)
+
+// Metrics of a single region of an [IdentityClientPool]
+type RegionMetrics struct {
+	Requests int64
+	Errors   int64
+	// median of the most recent requests, zero without requests
+	P50LatencyMs float64
+}
+
+// IdentityClientPool distributes calls across clients of ACS resources in multiple regions by
+// weighted round-robin and implements [IdentityClient] itself. A region whose calls fail
+// 5 times in a row with transport errors, 5xx or 429 is skipped for 30 seconds (circuit open).
+// Calls failing because their context is done do not count as failures. The cooldown and the
+// latencies of a [CommunicationIdentityClient] are measured on its [TimeSource].
+//
+// The zero value is an empty pool ready to use.
+type IdentityClientPool struct {
+	mu      sync.Mutex
+	regions []*poolRegion
+}
+
+var _ IdentityClient = (*IdentityClientPool)(nil)
+
+type poolRegion struct {
+	name   string
+	weight int
+	client IdentityClient
+	clock  TimeSource
+	// smooth weighted round-robin state
+	current             int
+	requests            int64
+	errors              int64
+	latencies           []time.Duration
+	nextLatency         int
+	consecutiveFailures int
+	openUntil           time.Time
+}
+
+// Add registers client for region, replacing an existing client of the region. Regions with a
+// weight below 1 are only used through ForRegion or as fallback.
+func (pool *IdentityClientPool) Add(region string, weight int, client IdentityClient) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	pool.regions = slices.DeleteFunc(pool.regions, func(existing *poolRegion) bool {
+		return existing.name == region
+	})
+	clock := TimeSource(systemTimeSource{})
+	if timed, ok := client.(interface{ timeSource() TimeSource }); ok {
+		clock = timed.timeSource()
+	}
+	pool.regions = append(pool.regions, &poolRegion{
+		name:   region,
+		weight: weight,
+		client: client,
+		clock:  clock,
+	})
+}
+
+// ForRegion returns the client of region. If its circuit is open, the available region with the
+// highest weight is returned instead. Reports false if region is unknown or no region is available.
+func (pool *IdentityClientPool) ForRegion(region string) (IdentityClient, bool) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	index := slices.IndexFunc(pool.regions, func(r *poolRegion) bool { return r.name == region })
+	if index < 0 {
+		return nil, false
+	}
+	preferred := pool.regions[index]
+	if pool.availableLocked(preferred) {
+		return pooledClient{pool, preferred}, true
+	}
+	var fallback *poolRegion
+	for _, r := range pool.regions {
+		if pool.availableLocked(r) && (fallback == nil || r.weight > fallback.weight) {
+			fallback = r
+		}
+	}
+	if fallback == nil {
+		return nil, false
+	}
+	return pooledClient{pool, fallback}, true
+}
+
+// PoolMetrics returns the metrics of all regions by name
+func (pool *IdentityClientPool) PoolMetrics() map[string]RegionMetrics {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	metrics := make(map[string]RegionMetrics, len(pool.regions))
+	for _, r := range pool.regions {
+		metrics[r.name] = RegionMetrics{
+			Requests:     r.requests,
+			Errors:       r.errors,
+			P50LatencyMs: medianMs(r.latencies),
+		}
+	}
+	return metrics
+}
+
+// TokenForTeamsUser calls the next region in round-robin order
+func (pool *IdentityClientPool) TokenForTeamsUser(
+	ctx context.Context,
+	userOid string,
+	teamsScopeMSALToken string,
+	opts ...CallOption,
+) (CommunicationIdentityAccessToken, error) {
+	client, err := pool.next()
+	if err != nil {
+		return CommunicationIdentityAccessToken{}, err
+	}
+	return client.TokenForTeamsUser(ctx, userOid, teamsScopeMSALToken, opts...)
+}
+
+// CreateCommunicationIdentity calls the next region in round-robin order
+func (pool *IdentityClientPool) CreateCommunicationIdentity(
+	ctx context.Context,
+	scope []string,
+	expireInMinutes *int32,
+	opts ...CallOption,
+) (CommunicationIdentityAccessTokenResult, error) {
+	client, err := pool.next()
+	if err != nil {
+		return CommunicationIdentityAccessTokenResult{}, err
+	}
+	return client.CreateCommunicationIdentity(ctx, scope, expireInMinutes, opts...)
+}
+
+// IssueAccessToken is sent to the available regions in order of their weight until one succeeds,
+// identities only exist in the ACS resource that created them
+func (pool *IdentityClientPool) IssueAccessToken(
+	ctx context.Context,
+	identityID string,
+	scopes []string,
+	expireInMinutes *int32,
+	opts ...CallOption,
+) (token CommunicationIdentityAccessToken, err error) {
+	err = pool.eachRegion(func(client pooledClient) error {
+		token, err = client.IssueAccessToken(ctx, identityID, scopes, expireInMinutes, opts...)
+		return err
+	})
+	return token, err
+}
+
+// DeleteCommunicationIdentity is sent to the available regions in order of their weight until one
+// succeeds
+func (pool *IdentityClientPool) DeleteCommunicationIdentity(
+	ctx context.Context,
+	identityID string,
+	opts ...CallOption,
+) error {
+	return pool.eachRegion(func(client pooledClient) error {
+		return client.DeleteCommunicationIdentity(ctx, identityID, opts...)
+	})
+}
+
+// RevokeAccessTokens is sent to the available regions in order of their weight until one succeeds
+func (pool *IdentityClientPool) RevokeAccessTokens(
+	ctx context.Context,
+	identityID string,
+	opts ...CallOption,
+) error {
+	return pool.eachRegion(func(client pooledClient) error {
+		return client.RevokeAccessTokens(ctx, identityID, opts...)
+	})
+}
+
+// eachRegion calls call with the available regions by descending weight until it succeeds, the
+// errors of all regions are returned if none succeeds
+func (pool *IdentityClientPool) eachRegion(call func(pooledClient) error) error {
+	pool.mu.Lock()
+	var regions []*poolRegion
+	for _, r := range pool.regions {
+		if pool.availableLocked(r) {
+			regions = append(regions, r)
+		}
+	}
+	pool.mu.Unlock()
+	slices.SortStableFunc(regions, func(a, b *poolRegion) int { return b.weight - a.weight })
+	if len(regions) == 0 {
+		return fmt.Errorf("no ACS region available in client pool")
+	}
+
+	var errs []error
+	for _, r := range regions {
+		err := call(pooledClient{pool, r})
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, fmt.Errorf("region %s: %w", r.name, err))
+	}
+	return errors.Join(errs...)
+}
+
+// next picks an available region by smooth weighted round-robin
+func (pool *IdentityClientPool) next() (pooledClient, error) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	var selected *poolRegion
+	total := 0
+	for _, r := range pool.regions {
+		if r.weight < 1 || !pool.availableLocked(r) {
+			continue
+		}
+		r.current += r.weight
+		total += r.weight
+		if selected == nil || r.current > selected.current {
+			selected = r
+		}
+	}
+	if selected == nil {
+		return pooledClient{}, fmt.Errorf("no ACS region available in client pool")
+	}
+	selected.current -= total
+	return pooledClient{pool, selected}, nil
+}
+
+func (pool *IdentityClientPool) availableLocked(r *poolRegion) bool {
+	return !r.clock.Now().Before(r.openUntil)
+}
+
+func (pool *IdentityClientPool) record(
+	ctx context.Context,
+	r *poolRegion,
+	start time.Time,
+	err error,
+) {
+	latency := r.clock.Now().Sub(start)
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	r.requests++
+	if len(r.latencies) < poolLatencySamples {
+		r.latencies = append(r.latencies, latency)
+	} else {
+		r.latencies[r.nextLatency] = latency
+		r.nextLatency = (r.nextLatency + 1) % poolLatencySamples
+	}
+	if err != nil {
+		r.errors++
+	}
+	if !isRegionFailure(ctx, err) {
+		r.consecutiveFailures = 0
+		return
+	}
+	r.consecutiveFailures++
+	if r.consecutiveFailures >= poolCircuitFailureThreshold {
+		r.openUntil = r.clock.Now().Add(poolCircuitCooldown)
+		r.consecutiveFailures = 0
+	}
+}
+
+// isRegionFailure reports errors caused by the region rather than the request or its caller
+func isRegionFailure(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return false
+	}
+	var identityErr *CommunicationIdentityError
+	if errors.As(err, &identityErr) && identityErr.StatusCode != 0 {
+		return identityErr.StatusCode >= http.StatusInternalServerError ||
+			identityErr.StatusCode == http.StatusTooManyRequests
+	}
+	return true
+}
+
+func medianMs(latencies []time.Duration) float64 {
+	if len(latencies) == 0 {
+		return 0
+	}
+	sorted := slices.Clone(latencies)
+	slices.Sort(sorted)
+	return float64(sorted[len(sorted)/2]) / float64(time.Millisecond)
+}
+
+// client of a single pool region recording its metrics
+type pooledClient struct {
+	pool   *IdentityClientPool
+	region *poolRegion
+}
+
+func (client pooledClient) TokenForTeamsUser(
+	ctx context.Context,
+	userOid string,
+	teamsScopeMSALToken string,
+	opts ...CallOption,
+) (CommunicationIdentityAccessToken, error) {
+	start := client.region.clock.Now()
+	token, err := client.region.client.TokenForTeamsUser(ctx, userOid, teamsScopeMSALToken, opts...)
+	client.pool.record(ctx, client.region, start, err)
+	return token, err
+}
+
+func (client pooledClient) CreateCommunicationIdentity(
+	ctx context.Context,
+	scope []string,
+	expireInMinutes *int32,
+	opts ...CallOption,
+) (CommunicationIdentityAccessTokenResult, error) {
+	start := client.region.clock.Now()
+	result, err := client.region.client.CreateCommunicationIdentity(
+		ctx,
+		scope,
+		expireInMinutes,
+		opts...,
+	)
+	client.pool.record(ctx, client.region, start, err)
+	return result, err
+}
+
+func (client pooledClient) IssueAccessToken(
+	ctx context.Context,
+	identityID string,
+	scopes []string,
+	expireInMinutes *int32,
+	opts ...CallOption,
+) (CommunicationIdentityAccessToken, error) {
+	start := client.region.clock.Now()
+	token, err := client.region.client.IssueAccessToken(
+		ctx,
+		identityID,
+		scopes,
+		expireInMinutes,
+		opts...,
+	)
+	client.pool.record(ctx, client.region, start, err)
+	return token, err
+}
+
+func (client pooledClient) DeleteCommunicationIdentity(
+	ctx context.Context,
+	identityID string,
+	opts ...CallOption,
+) error {
+	start := client.region.clock.Now()
+	err := client.region.client.DeleteCommunicationIdentity(ctx, identityID, opts...)
+	client.pool.record(ctx, client.region, start, err)
+	return err
+}
+
+func (client pooledClient) RevokeAccessTokens(
+	ctx context.Context,
+	identityID string,
+	opts ...CallOption,
+) error {
+	start := client.region.clock.Now()
+	err := client.region.client.RevokeAccessTokens(ctx, identityID, opts...)
+	client.pool.record(ctx, client.region, start, err)
+	return err
+}
@@ -0,0 +1,168 @@
+package communicationidentity_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+	"github.com/jls-ch/azure-communication-identity-go/communicationidentitytest"
+)
+
+// answers with its region as identity, or fails with err
+type regionClient struct {
+	ci.IdentityClient
+	region string
+	err    error
+}
+
+func (client regionClient) CreateCommunicationIdentity(
+	context.Context,
+	[]string,
+	*int32,
+	...ci.CallOption,
+) (ci.CommunicationIdentityAccessTokenResult, error) {
+	if client.err != nil {
+		return ci.CommunicationIdentityAccessTokenResult{}, client.err
+	}
+	return ci.CommunicationIdentityAccessTokenResult{
+		Identity: ci.CommunicationIdentity{ID: client.region},
+	}, nil
+}
+
+// only the region owning the identity deletes it
+func (client regionClient) DeleteCommunicationIdentity(
+	_ context.Context,
+	id string,
+	_ ...ci.CallOption,
+) error {
+	if id != client.region+"-identity" {
+		return &ci.CommunicationIdentityError{StatusCode: http.StatusNotFound}
+	}
+	return nil
+}
+
+func TestIdentityClientPoolIdentityOperationsTryAllRegions(t *testing.T) {
+	var pool ci.IdentityClientPool
+	pool.Add("westeurope", 2, regionClient{region: "westeurope"})
+	pool.Add("eastus", 1, regionClient{region: "eastus"})
+
+	if err := pool.DeleteCommunicationIdentity(context.Background(), "eastus-identity"); err != nil {
+		t.Fatalf("expected the owning region to delete the identity, got: %v", err)
+	}
+	err := pool.DeleteCommunicationIdentity(context.Background(), "unknown")
+	if !errors.Is(err, &ci.CommunicationIdentityError{StatusCode: http.StatusNotFound}) {
+		t.Errorf("expected not found errors of all regions, got: %v", err)
+	}
+	if metrics := pool.PoolMetrics(); metrics["westeurope"].Requests != 2 {
+		t.Errorf("expected the higher weight region to be tried first, got %+v", metrics)
+	}
+}
+
+func TestIdentityClientPoolWeightedRoundRobin(t *testing.T) {
+	var pool ci.IdentityClientPool
+	pool.Add("westeurope", 2, regionClient{region: "westeurope"})
+	pool.Add("eastus", 1, regionClient{region: "eastus"})
+
+	counts := map[string]int{}
+	for range 6 {
+		result, err := pool.CreateCommunicationIdentity(context.Background(), []string{"chat"}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts[result.Identity.ID]++
+	}
+	if counts["westeurope"] != 4 || counts["eastus"] != 2 {
+		t.Errorf("expected calls split by weight, got %v", counts)
+	}
+	if metrics := pool.PoolMetrics(); metrics["westeurope"].Requests != 4 {
+		t.Errorf("unexpected metrics: %+v", metrics)
+	}
+}
+
+func TestIdentityClientPoolFallsBackWhenCircuitOpen(t *testing.T) {
+	unavailable := &ci.CommunicationIdentityError{StatusCode: http.StatusServiceUnavailable}
+	var pool ci.IdentityClientPool
+	pool.Add("westeurope", 1, regionClient{region: "westeurope", err: unavailable})
+	pool.Add("eastus", 1, regionClient{region: "eastus"})
+	pool.Add("brazilsouth", 3, regionClient{region: "brazilsouth"})
+
+	client, ok := pool.ForRegion("westeurope")
+	if !ok {
+		t.Fatal("expected region to be known")
+	}
+	for range 5 {
+		if _, err := client.CreateCommunicationIdentity(
+			context.Background(), []string{"chat"}, nil,
+		); err == nil {
+			t.Fatal("expected failing region to fail")
+		}
+	}
+	if metrics := pool.PoolMetrics()["westeurope"]; metrics.Errors != 5 {
+		t.Errorf("expected 5 errors, got %+v", metrics)
+	}
+
+	fallback, ok := pool.ForRegion("westeurope")
+	if !ok {
+		t.Fatal("expected a fallback region")
+	}
+	result, err := fallback.CreateCommunicationIdentity(context.Background(), []string{"chat"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Identity.ID != "brazilsouth" {
+		t.Errorf("expected fallback to the highest weight region, got %s", result.Identity.ID)
+	}
+}
+
+func TestIdentityClientPoolUnknownRegion(t *testing.T) {
+	var pool ci.IdentityClientPool
+	if _, ok := pool.ForRegion("westeurope"); ok {
+		t.Error("expected unknown region to be reported")
+	}
+	if _, err := pool.CreateCommunicationIdentity(
+		context.Background(), []string{"chat"}, nil,
+	); err == nil {
+		t.Error("expected empty pool to fail")
+	}
+}
+
+func TestIdentityClientPoolIgnoresCancelledCalls(t *testing.T) {
+	var pool ci.IdentityClientPool
+	pool.Add("westeurope", 1, regionClient{region: "westeurope", err: context.Canceled})
+	pool.Add("eastus", 1, regionClient{region: "eastus"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	client, _ := pool.ForRegion("westeurope")
+	for range 5 {
+		_, _ = client.CreateCommunicationIdentity(ctx, []string{"chat"}, nil)
+	}
+	client, _ = pool.ForRegion("westeurope")
+	_, _ = client.CreateCommunicationIdentity(ctx, []string{"chat"}, nil)
+	if metrics := pool.PoolMetrics(); metrics["westeurope"].Requests != 6 {
+		t.Errorf("expected cancelled calls not to open the circuit, got %+v", metrics)
+	}
+}
+
+func TestIdentityClientPoolCooldownUsesClientTimeSource(t *testing.T) {
+	clock := communicationidentitytest.NewMockClock(time.Now())
+	failing := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}, ci.WithTimeSource(clock))
+	var pool ci.IdentityClientPool
+	pool.Add("westeurope", 1, failing)
+
+	for range 5 {
+		_, _ = pool.CreateCommunicationIdentity(context.Background(), []string{"chat"}, nil)
+	}
+	if _, ok := pool.ForRegion("westeurope"); ok {
+		t.Fatal("expected the circuit to be open")
+	}
+	clock.Advance(time.Minute)
+	if _, ok := pool.ForRegion("westeurope"); !ok {
+		t.Error("expected the circuit to close after the cooldown on the client's clock")
+	}
+}
@@ -0,0 +1,96 @@
+package communicationidentity
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// upper bound of proxied request bodies, ACS requests are tiny
+const maxProxyRequestBytes = 1 << 20
+
+// NewHTTPProxy exposes client as HTTP handler for sidecars holding the ACS access key on behalf of
+// other services. Request and response bodies use the ACS JSON format:
+//
+//   - POST /tokens with a create identity body calls CreateCommunicationIdentity
+//   - POST /teams-tokens with a 'Teams user' exchange body calls TokenForTeamsUser, its appId
+//     is ignored in favour of the one client is configured with
+//
+// Errors returned by ACS are passed on with their status code and body, errors of the proxy
+// itself use the same format. Authentication of callers is left to the deployment.
+func NewHTTPProxy(client IdentityClient) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /tokens", func(w http.ResponseWriter, r *http.Request) {
+		var body createAndReturnTokenRequest
+		if !decodeProxyRequest(w, r, &body) {
+			return
+		}
+		result, err := client.CreateCommunicationIdentity(r.Context(), body.Scope, body.Expire)
+		result.AccessToken = result.AccessToken.plain()
+		writeProxyResponse(w, result, err)
+	})
+	mux.HandleFunc("POST /teams-tokens", func(w http.ResponseWriter, r *http.Request) {
+		var body teamsUserExchangeTokenRequest
+		if !decodeProxyRequest(w, r, &body) {
+			return
+		}
+		if body.Token == "" || body.UserId == "" {
+			writeProxyError(w, http.StatusBadRequest, &CommunicationError{
+				Code:    "BadRequest",
+				Message: "token and userId are required",
+			})
+			return
+		}
+		token, err := client.TokenForTeamsUser(r.Context(), body.UserId, body.Token)
+		writeProxyResponse(w, token.plain(), err)
+	})
+	return mux
+}
+
+func decodeProxyRequest(w http.ResponseWriter, r *http.Request, body any) bool {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxProxyRequestBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(body); err != nil {
+		writeProxyError(w, http.StatusBadRequest, &CommunicationError{
+			Code:    "BadRequest",
+			Message: fmt.Sprintf("invalid request body: %v", err),
+		})
+		return false
+	}
+	return true
+}
+
+func writeProxyResponse(w http.ResponseWriter, result any, err error) {
+	if err == nil {
+		writeProxyJSON(w, http.StatusOK, result)
+		return
+	}
+
+	var identityErr *CommunicationIdentityError
+	var validationErr *ValidationError
+	switch {
+	case errors.As(err, &identityErr) && identityErr.ACSError != nil:
+		writeProxyError(w, identityErr.StatusCode, identityErr.ACSError)
+	case errors.As(err, &validationErr):
+		writeProxyError(w, http.StatusBadRequest, &CommunicationError{
+			Code:    "BadRequest",
+			Message: validationErr.Error(),
+		})
+	default:
+		writeProxyError(w, http.StatusBadGateway, &CommunicationError{
+			Code:    "BadGateway",
+			Message: err.Error(),
+		})
+	}
+}
+
+func writeProxyError(w http.ResponseWriter, status int, err *CommunicationError) {
+	writeProxyJSON(w, status, communicationErrorResponse{Error: *err})
+}
+
+func writeProxyJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,83 @@
+package communicationidentity_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+)
+
+func serveProxy(
+	t *testing.T,
+	proxy http.Handler,
+	method, path, body string,
+) *httptest.ResponseRecorder {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	proxy.ServeHTTP(recorder, httptest.NewRequest(method, path, strings.NewReader(body)))
+	return recorder
+}
+
+func TestHTTPProxyTokens(t *testing.T) {
+	proxy := ci.NewHTTPProxy(newTestClient(t, createIdentityHandler(new(atomic.Int32))))
+
+	response := serveProxy(t, proxy, http.MethodPost, "/tokens", `{"createTokenWithScopes":["chat"]}`)
+	if response.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", response.Code, response.Body)
+	}
+	var result ci.CommunicationIdentityAccessTokenResult
+	if err := json.NewDecoder(response.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Identity.ID != "identity-1" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+}
+
+func TestHTTPProxyTeamsTokens(t *testing.T) {
+	var apiVersion string
+	proxy := ci.NewHTTPProxy(newTestClient(t, teamsTokenHandler(&apiVersion)))
+
+	response := serveProxy(t, proxy, http.MethodPost, "/teams-tokens",
+		`{"token":"msal-token","userId":"oid"}`)
+	if response.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", response.Code, response.Body)
+	}
+	var token ci.CommunicationIdentityAccessToken
+	if err := json.NewDecoder(response.Body).Decode(&token); err != nil {
+		t.Fatal(err)
+	}
+	if token.Token != "acs-token" {
+		t.Errorf("unexpected token: %+v", token)
+	}
+}
+
+func TestHTTPProxyErrors(t *testing.T) {
+	forbidden := errorHandler(http.StatusForbidden, "", `{"error":{"code":"Forbidden"}}`)
+	proxy := ci.NewHTTPProxy(newTestClient(t, forbidden))
+	cases := []struct {
+		name, method, path, body string
+		status                   int
+	}{
+		{
+			"acs error", http.MethodPost, "/tokens", `{"createTokenWithScopes":["chat"]}`,
+			http.StatusForbidden,
+		},
+		{"invalid json", http.MethodPost, "/tokens", `{`, http.StatusBadRequest},
+		{"unknown field", http.MethodPost, "/tokens", `{"scopes":[]}`, http.StatusBadRequest},
+		{"missing user id", http.MethodPost, "/teams-tokens", `{"token":"t"}`, http.StatusBadRequest},
+		{"wrong method", http.MethodGet, "/tokens", ``, http.StatusMethodNotAllowed},
+		{"unknown path", http.MethodPost, "/identities", `{}`, http.StatusNotFound},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if response := serveProxy(t, proxy, c.method, c.path, c.body); response.Code != c.status {
+				t.Errorf("expected status %d, got %d: %s", c.status, response.Code, response.Body)
+			}
+		})
+	}
+}
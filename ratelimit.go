@@ -0,0 +1,55 @@
+package communicationidentity
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RateLimitHandler is called when ACS rejects a request with 429, retryAfter is zero if ACS did
+// not send a Retry-After header. Returning nil continues with the default handling, an error is
+// returned to the caller instead, e.g. to shed load in the application layer.
+type RateLimitHandler func(retryAfter time.Duration) error
+
+// WithOnRateLimitExceeded calls h for every response with status 429
+func WithOnRateLimitExceeded(h RateLimitHandler) ClientOption {
+	return func(options *clientOptions) error {
+		if h == nil {
+			return fmt.Errorf("rate limit handler can not be nil")
+		}
+		options.rateLimitHandler = h
+		return nil
+	}
+}
+
+// retryAfter parses the Retry-After header in seconds or as HTTP date, zero if absent or invalid
+func retryAfter(response *http.Response, now time.Time) time.Duration {
+	value := response.Header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
+
+// handleRateLimit passes 429 responses to the configured RateLimitHandler
+func (client CommunicationIdentityClient) handleRateLimit(
+	response *http.Response,
+	callOpts callOptions,
+) error {
+	if client.options.rateLimitHandler == nil || response.StatusCode != http.StatusTooManyRequests {
+		return nil
+	}
+	wait := retryAfter(response, client.timeSource().Now())
+	if err := client.options.rateLimitHandler(wait); err != nil {
+		client.discardResponse(response, callOpts)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package communicationidentity_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+)
+
+func rateLimitedHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Retry-After", "7")
+	w.WriteHeader(http.StatusTooManyRequests)
+	_, _ = w.Write([]byte(`{"error":{"code":"TooManyRequests","message":"slow down"}}`))
+}
+
+func TestOnRateLimitExceededSurfacesHandlerError(t *testing.T) {
+	errOverloaded := errors.New("overloaded")
+	var waited time.Duration
+	client := newTestClient(t, http.HandlerFunc(rateLimitedHandler),
+		ci.WithOnRateLimitExceeded(func(retryAfter time.Duration) error {
+			waited = retryAfter
+			return errOverloaded
+		}),
+	)
+
+	_, err := client.CreateCommunicationIdentity(context.Background(), []string{"chat"}, nil)
+	if !errors.Is(err, errOverloaded) {
+		t.Errorf("expected handler error, got %v", err)
+	}
+	if waited != 7*time.Second {
+		t.Errorf("expected Retry-After of 7s, got %s", waited)
+	}
+}
+
+func TestOnRateLimitExceededContinuesOnNil(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(rateLimitedHandler),
+		ci.WithOnRateLimitExceeded(func(time.Duration) error { return nil }),
+	)
+
+	_, err := client.CreateCommunicationIdentity(context.Background(), []string{"chat"}, nil)
+	var identityErr *ci.CommunicationIdentityError
+	if !errors.As(err, &identityErr) || identityErr.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("expected the ACS 429 error, got %v", err)
+	}
+}
+
+// records the requested delays and fires right away
+type delayRecorder struct {
+	delays []time.Duration
+}
+
+func (recorder *delayRecorder) Now() time.Time { return time.Now() }
+
+func (recorder *delayRecorder) After(d time.Duration) <-chan time.Time {
+	recorder.delays = append(recorder.delays, d)
+	fired := make(chan time.Time, 1)
+	fired <- time.Now()
+	return fired
+}
+
+// answers the first request with 429 and retryAfter, later ones with a new identity
+func rateLimitedOnceHandler(retryAfter string, calls *atomic.Int32) http.HandlerFunc {
+	create := createIdentityHandler(new(atomic.Int32))
+	return func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", retryAfter)
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		create(w, r)
+	}
+}
+
+func TestWithRetryHonorsRetryAfter(t *testing.T) {
+	for name, c := range map[string]struct {
+		header   string
+		min, max time.Duration
+	}{
+		"seconds": {"3", 3 * time.Second, 3 * time.Second},
+		"HTTP date": {
+			time.Now().Add(10 * time.Second).UTC().Format(http.TimeFormat),
+			8 * time.Second,
+			10 * time.Second,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var calls atomic.Int32
+			recorder := &delayRecorder{}
+			client := newTestClient(t, rateLimitedOnceHandler(c.header, &calls),
+				ci.WithRetry(2, time.Millisecond),
+				ci.WithTimeSource(recorder),
+			)
+			ctx := context.Background()
+			if _, err := client.CreateCommunicationIdentity(ctx, []string{"chat"}, nil); err != nil {
+				t.Fatal(err)
+			}
+			if len(recorder.delays) != 1 ||
+				recorder.delays[0] < c.min || recorder.delays[0] > c.max {
+				t.Errorf("expected a delay between %v and %v, got %v", c.min, c.max, recorder.delays)
+			}
+		})
+	}
+}
+
+func TestWithRetryReturnsRateLimitBeyondDeadline(t *testing.T) {
+	var calls atomic.Int32
+	client := newTestClient(t, rateLimitedOnceHandler("60", &calls),
+		ci.WithRetry(3, time.Millisecond),
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.CreateCommunicationIdentity(ctx, []string{"chat"}, nil)
+	if !ci.IsRateLimited(err) {
+		t.Errorf("expected a rate limit error, got: %v", err)
+	}
+	if calls.Load() != 1 || time.Since(start) > time.Second {
+		t.Errorf("expected to return without waiting, got %d calls after %v",
+			calls.Load(), time.Since(start))
+	}
+}
+
+func TestIsRateLimited(t *testing.T) {
+	for err, want := range map[error]bool{
+		&ci.CommunicationIdentityError{StatusCode: http.StatusTooManyRequests}: true,
+		fmt.Errorf("wrapped: %w", &ci.CommunicationIdentityError{
+			StatusCode: http.StatusTooManyRequests,
+		}): true,
+		&ci.CommunicationIdentityError{StatusCode: http.StatusServiceUnavailable}: false,
+		errors.New("overloaded"): false,
+		nil:                      false,
+	} {
+		if got := ci.IsRateLimited(err); got != want {
+			t.Errorf("IsRateLimited(%v) = %v, expected %v", err, got, want)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package communicationidentity
+
+import (
+	"fmt"
+	"time"
+)
+
+// Kinds of [TokenIssuanceRecord]
+const (
+	// a token was dropped from the full token cache, see [WithMaxCacheSize]
+	TokenIssuanceKindEvicted = "evicted"
+)
+
+// Lifecycle event of a token handled by the client, for monitoring e.g. cache pressure
+type TokenIssuanceRecord struct {
+	Kind       string
+	IdentityID string
+	ExpiresOn  time.Time
+	Timestamp  time.Time
+}
+
+// WithTokenIssuanceRecorder registers a callback receiving a [TokenIssuanceRecord] for token
+// lifecycle events. The callback is invoked synchronously and must not block.
+func WithTokenIssuanceRecorder(record func(TokenIssuanceRecord)) ClientOption {
+	return func(options *clientOptions) error {
+		if record == nil {
+			return fmt.Errorf("token issuance recorder can not be nil")
+		}
+		options.tokenIssuanceRecorder = record
+		return nil
+	}
+}
+
+func (client CommunicationIdentityClient) recordTokenIssuance(
+	kind string,
+	result CommunicationIdentityAccessTokenResult,
+) {
+	if client.options.tokenIssuanceRecorder == nil {
+		return
+	}
+	client.options.tokenIssuanceRecorder(TokenIssuanceRecord{
+		Kind:       kind,
+		IdentityID: result.Identity.ID,
+		ExpiresOn:  result.AccessToken.ExpiresOn,
+		Timestamp:  client.timeSource().Now(),
+	})
+}
@@ -0,0 +1,161 @@
+package communicationidentity
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Outcome of a scheduled token refresh, exactly one of Token and Err is set
+type RefreshResult struct {
+	Token CommunicationIdentityAccessToken
+	Err   error
+}
+
+// ScheduleTokenRefresh issues a new token for identityID at refreshAt, e.g. at a time the device
+// is known to be online and charging. The returned channel receives exactly one result and is
+// closed afterwards. If ctx is done before refreshAt, the refresh is cancelled and the channel
+// receives the context error instead.
+func (client CommunicationIdentityClient) ScheduleTokenRefresh(
+	ctx context.Context,
+	identityID string,
+	scopes []Scope,
+	refreshAt time.Time,
+	opts ...CallOption,
+) (<-chan RefreshResult, error) {
+	if err := client.validateIdentityID(identityID); err != nil {
+		return nil, err
+	}
+	if err := validateScopes("scopes", scopes); err != nil {
+		return nil, err
+	}
+
+	clock := client.timeSource()
+	results := make(chan RefreshResult, 1)
+	timer := clock.After(refreshAt.Sub(clock.Now()))
+	go func() {
+		defer close(results)
+		select {
+		case <-ctx.Done():
+			results <- RefreshResult{Err: ctx.Err()}
+		case <-timer:
+			token, err := client.issueAccessToken(
+				ctx,
+				identityID,
+				scopeStrings(scopes),
+				nil,
+				applyCallOptions(opts),
+			)
+			results <- RefreshResult{Token: client.secureToken(token), Err: err}
+		}
+	}()
+	return results, nil
+}
+
+// delay before retrying a failed refresh of an AutoRefreshingToken, also the minimum delay
+// between two refreshes
+const autoRefreshRetryInterval = 10 * time.Second
+
+// AutoRefreshingToken is a token of an identity that is reissued in the background before it
+// expires, see [CommunicationIdentityClient.CreateCommunicationIdentityWithTTLRefresh]
+type AutoRefreshingToken struct {
+	mu       sync.RWMutex
+	identity CommunicationIdentity
+	current  CommunicationIdentityAccessToken
+	// wraps tokens returned by Current with WithSecureTokenHandling
+	secure func(CommunicationIdentityAccessToken) CommunicationIdentityAccessToken
+	stop   context.CancelFunc
+	done   chan struct{}
+}
+
+// Current returns the most recently issued token
+func (token *AutoRefreshingToken) Current() CommunicationIdentityAccessToken {
+	token.mu.RLock()
+	defer token.mu.RUnlock()
+	return token.secure(token.current)
+}
+
+// Identity returns the identity tokens are issued for
+func (token *AutoRefreshingToken) Identity() CommunicationIdentity {
+	return token.identity
+}
+
+// Stop ends the background refresh and waits until it has terminated, it is safe to call Stop
+// multiple times
+func (token *AutoRefreshingToken) Stop() {
+	token.stop()
+	<-token.done
+}
+
+// CreateCommunicationIdentityWithTTLRefresh creates an identity and issues a new token for it
+// leadTime before the current one expires, until Stop is called or ctx is done. Failed refreshes
+// are retried every 10 seconds, Current keeps returning the last token meanwhile.
+//
+// leadTime must be positive and shorter than the minimum token lifetime of 60 minutes.
+func (client CommunicationIdentityClient) CreateCommunicationIdentityWithTTLRefresh(
+	ctx context.Context,
+	scopes []Scope,
+	leadTime time.Duration,
+	opts ...CallOption,
+) (*AutoRefreshingToken, error) {
+	if leadTime <= 0 || leadTime >= MinTokenExpiryMinutes*time.Minute {
+		return nil, &ValidationError{
+			Field: "leadTime",
+			Value: leadTime.String(),
+			Err: fmt.Errorf(
+				"lead time must be positive and shorter than %d minutes",
+				MinTokenExpiryMinutes,
+			),
+		}
+	}
+	result, err := client.CreateCommunicationIdentity(ctx, scopeStrings(scopes), nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Current hands out a buffer of its own on every call
+	current := result.AccessToken.plain()
+	zeroResult(result)
+
+	refreshCtx, stop := context.WithCancel(ctx)
+	token := &AutoRefreshingToken{
+		identity: result.Identity,
+		current:  current,
+		secure:   client.secureToken,
+		stop:     stop,
+		done:     make(chan struct{}),
+	}
+	go client.autoRefresh(refreshCtx, token, scopeStrings(scopes), leadTime, applyCallOptions(opts))
+	return token, nil
+}
+
+func (client CommunicationIdentityClient) autoRefresh(
+	ctx context.Context,
+	token *AutoRefreshingToken,
+	scopes []string,
+	leadTime time.Duration,
+	callOpts callOptions,
+) {
+	defer close(token.done)
+	clock := client.timeSource()
+	wait := token.Current().ExpiresOn.Add(-leadTime).Sub(clock.Now())
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-clock.After(wait):
+		}
+
+		refreshed, err := client.issueAccessToken(ctx, token.identity.ID, scopes, nil, callOpts)
+		if err != nil {
+			wait = autoRefreshRetryInterval
+			continue
+		}
+		token.mu.Lock()
+		token.current = refreshed
+		token.mu.Unlock()
+		// clock skew to ACS must not turn into a refresh loop
+		wait = max(refreshed.ExpiresOn.Add(-leadTime).Sub(clock.Now()), autoRefreshRetryInterval)
+	}
+}
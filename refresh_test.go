@@ -0,0 +1,189 @@
+package communicationidentity_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+	"github.com/jls-ch/azure-communication-identity-go/communicationidentitytest"
+)
+
+const testIdentityID = "8:acs:b6aada1f-0b1d-47ac-866f-91aae00a1d01_" +
+	"00000005-4ad5-d0b4-6a0b-343a0d00ab6c"
+
+func issueTokenHandler(path *string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		*path = r.URL.EscapedPath()
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(ci.CommunicationIdentityAccessToken{
+			Token:     "refreshed-token",
+			ExpiresOn: time.Now().Add(time.Hour).UTC(),
+		})
+	}
+}
+
+func TestScheduleTokenRefresh(t *testing.T) {
+	clock := communicationidentitytest.NewMockClock(time.Now())
+	var path string
+	client := newTestClient(t, issueTokenHandler(&path), ci.WithTimeSource(clock))
+
+	results, err := client.ScheduleTokenRefresh(
+		context.Background(),
+		testIdentityID,
+		[]ci.Scope{ci.ScopeChat},
+		clock.Now().Add(time.Hour),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case result := <-results:
+		t.Fatalf("refresh fired before its time: %+v", result)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	clock.Advance(time.Hour)
+	select {
+	case result := <-results:
+		if result.Err != nil {
+			t.Fatal(result.Err)
+		}
+		if result.Token.Token != "refreshed-token" {
+			t.Errorf("unexpected token: %+v", result.Token)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("refresh did not fire")
+	}
+	if want := "/identities/" + testIdentityID + "/:issueAccessToken"; path != want {
+		t.Errorf("expected request to %q, got %q", want, path)
+	}
+	if _, open := <-results; open {
+		t.Error("expected channel to be closed after the result")
+	}
+}
+
+func TestScheduleTokenRefreshCancelled(t *testing.T) {
+	clock := communicationidentitytest.NewMockClock(time.Now())
+	var path string
+	client := newTestClient(t, issueTokenHandler(&path), ci.WithTimeSource(clock))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	results, err := client.ScheduleTokenRefresh(
+		ctx,
+		testIdentityID,
+		[]ci.Scope{ci.ScopeChat},
+		clock.Now().Add(time.Hour),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+
+	result := <-results
+	if !errors.Is(result.Err, context.Canceled) {
+		t.Errorf("expected cancellation error, got %+v", result)
+	}
+	if path != "" {
+		t.Errorf("expected no request after cancellation, got %q", path)
+	}
+}
+
+func TestScheduleTokenRefreshRequiresScopes(t *testing.T) {
+	client := newTestClient(t, issueTokenHandler(new(string)))
+	_, err := client.ScheduleTokenRefresh(context.Background(), testIdentityID, nil, time.Now())
+	if err == nil {
+		t.Error("expected error without scopes")
+	}
+}
+
+func TestCreateCommunicationIdentityWithTTLRefresh(t *testing.T) {
+	clock := communicationidentitytest.NewMockClock(time.Now())
+	var issued atomic.Int32
+	create := createIdentityHandler(new(atomic.Int32))
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/identities" {
+			create(w, r)
+			return
+		}
+		issued.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(ci.CommunicationIdentityAccessToken{
+			Token:     "refreshed-token",
+			ExpiresOn: clock.Now().Add(time.Hour),
+		})
+	}, ci.WithTimeSource(clock))
+
+	token, err := client.CreateCommunicationIdentityWithTTLRefresh(
+		context.Background(),
+		[]ci.Scope{ci.ScopeChat},
+		5*time.Minute,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Identity().ID != "identity-1" || token.Current().Token != "token-1" {
+		t.Fatalf("unexpected initial token: %+v %+v", token.Identity(), token.Current())
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for token.Current().Token != "refreshed-token" {
+		if time.Now().After(deadline) {
+			t.Fatal("token was not refreshed")
+		}
+		clock.Advance(time.Minute)
+		time.Sleep(time.Millisecond)
+	}
+
+	// Stop only returns once the refresh goroutine has terminated
+	token.Stop()
+	token.Stop()
+	requests := issued.Load()
+	clock.Advance(24 * time.Hour)
+	time.Sleep(10 * time.Millisecond)
+	if issued.Load() != requests {
+		t.Error("expected no refresh after Stop")
+	}
+}
+
+func TestCreateCommunicationIdentityWithTTLRefreshRejectsLeadTime(t *testing.T) {
+	client := newTestClient(t, createIdentityHandler(new(atomic.Int32)))
+	for _, leadTime := range []time.Duration{0, time.Hour} {
+		_, err := client.CreateCommunicationIdentityWithTTLRefresh(
+			context.Background(),
+			[]ci.Scope{ci.ScopeChat},
+			leadTime,
+		)
+		var validationErr *ci.ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Errorf("expected ValidationError for lead time %v, got %v", leadTime, err)
+		}
+	}
+}
+
+func TestIssueAccessToken(t *testing.T) {
+	var path string
+	client := newTestClient(t, issueTokenHandler(&path))
+
+	token, err := client.IssueAccessToken(context.Background(), testIdentityID, []string{"chat"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Token != "refreshed-token" {
+		t.Errorf("unexpected token: %+v", token)
+	}
+	if want := "/identities/" + testIdentityID + "/:issueAccessToken"; path != want {
+		t.Errorf("expected path %s, got %s", want, path)
+	}
+
+	_, err = client.IssueAccessToken(context.Background(), testIdentityID, nil, nil)
+	var validationErr *ci.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Errorf("expected ValidationError for empty scopes, got %v", err)
+	}
+}
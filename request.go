@@ -0,0 +1,331 @@
+package communicationidentity
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// send dispatches a signed request with the per call options applied
+func (client CommunicationIdentityClient) send(
+	ctx context.Context,
+	request *http.Request,
+	callOpts callOptions,
+) (*http.Response, error) {
+	callOpts.applyHeaders(request)
+	client.setClientRequestID(ctx, request, callOpts)
+	if client.options.userAgent != "" {
+		request.Header.Set("User-Agent", client.options.userAgent)
+	}
+	if client.options.sessionID != "" {
+		request.Header.Set(msSessionAffinityHeader, client.options.sessionID)
+	}
+	if callOpts.requestLog != nil {
+		ctx = callOpts.requestLog.trace(ctx, client.timeSource())
+	}
+	timeout := client.options.timeout
+	if callOpts.timeout > 0 {
+		timeout = callOpts.timeout
+	}
+	cancel := context.CancelFunc(func() {})
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	}
+	request = request.WithContext(ctx)
+
+	response, err := client.sendWithRetries(ctx, request, callOpts)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	// the timeout covers reading the body as well
+	response.Body = cancelOnClose{response.Body, cancel}
+	if limit := client.maxResponseBodySize(); limit > 0 {
+		response.Body = newLimitedBody(response.Body, limit)
+	}
+	return response, nil
+}
+
+// doRequest signs and sends a request with body to url and decodes the response of
+// successStatus into T, 204 responses are not decoded. ACS errors are decoded for responses of
+// any other status. The request id of the response is set on results implementing
+// requestIDSetter.
+func doRequest[T any](
+	client CommunicationIdentityClient,
+	ctx context.Context,
+	method string,
+	url *url.URL,
+	body []byte,
+	successStatus int,
+	callOpts callOptions,
+) (result T, err error) {
+	request, err := client.buildSignedRequest(ctx, method, url, body)
+	if err != nil {
+		return result, newTransportError("failed to create signed request: %w", err)
+	}
+	response, err := client.send(ctx, request, callOpts)
+	if err != nil {
+		return result, newTransportError("failed to send request to ACS: %w", err)
+	}
+	defer client.closeResponse(response, callOpts, &err)
+
+	if response.StatusCode != successStatus {
+		var errorResponse communicationErrorResponse
+		if err := json.NewDecoder(response.Body).Decode(&errorResponse); err != nil {
+			return result, newBodyError(response, "response body was not parseable", err)
+		}
+		return result, newResponseError(response, &errorResponse.Error, nil)
+	}
+	var decoded T
+	if successStatus != http.StatusNoContent {
+		if err := json.NewDecoder(response.Body).Decode(&decoded); err != nil {
+			return result, newBodyError(response, "failed to parse response body", err)
+		}
+	}
+	if setter, ok := any(&decoded).(requestIDSetter); ok {
+		setter.setRequestID(response.Header.Get(msRequestIDHeader))
+	}
+	return decoded, nil
+}
+
+// newBodyError reports a response body that could not be read or decoded, e.g. as it exceeded
+// the size limit
+func newBodyError(
+	response *http.Response,
+	message string,
+	err error,
+) *CommunicationIdentityError {
+	if errors.Is(err, ErrResponseBodyTooLarge) {
+		return newResponseError(response, nil, err)
+	}
+	return newResponseError(response, nil, fmt.Errorf("%s: %w", message, err))
+}
+
+// requestIDSetter is implemented by results carrying the request id of their response
+type requestIDSetter interface {
+	setRequestID(requestID string)
+}
+
+// cancelOnClose releases the context of a request once its response body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (body cancelOnClose) Close() error {
+	defer body.cancel()
+	return body.ReadCloser.Close()
+}
+
+func (client CommunicationIdentityClient) sendWithRetries(
+	ctx context.Context,
+	request *http.Request,
+	callOpts callOptions,
+) (*http.Response, error) {
+	maxAttempts := max(1, client.options.retry.maxAttempts)
+	for attempt := 1; ; attempt++ {
+		response, err := client.guardedAttempt(ctx, request, callOpts)
+		if attempt >= maxAttempts || !isRetryable(ctx, response, err) {
+			return response, err
+		}
+		delay, exceedsDeadline := client.retryDelay(ctx, response, attempt)
+		if exceedsDeadline {
+			// the 429 is returned to the caller instead of waiting in vain
+			return response, nil
+		}
+		client.logRetry(request, response, err, attempt, delay)
+		if response != nil {
+			client.discardResponse(response, callOpts)
+		}
+		if err := client.waitForRetry(ctx, delay); err != nil {
+			return nil, err
+		}
+		if request, err = client.resignRequest(request, client.requestSigner); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// attempt sends request once, including the retry with the secondary key of a RotatingKeyManager
+func (client CommunicationIdentityClient) attempt(
+	request *http.Request,
+	callOpts callOptions,
+) (*http.Response, error) {
+	response, err := client.do(request, callOpts)
+	if err == nil && response.StatusCode == http.StatusUnauthorized {
+		response, err = client.retryWithSecondaryKey(request, response, callOpts)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := client.handleRateLimit(response, callOpts); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func (client CommunicationIdentityClient) do(
+	request *http.Request,
+	callOpts callOptions,
+) (*http.Response, error) {
+	ctx := request.Context()
+	logger := client.logger()
+	logger.DebugContext(ctx, "sending ACS request",
+		"method", request.Method,
+		"url", logURL(request.URL),
+	)
+	start := client.timeSource().Now()
+	response, err := client.options.httpClient.Do(request)
+	client.audit(request, start, response, err)
+	duration := client.timeSource().Now().Sub(start)
+	if err != nil {
+		traceRequest(request, nil)
+		client.recordRequest(request, nil, duration)
+		logger.DebugContext(ctx, "ACS request failed",
+			"method", request.Method,
+			"url", logURL(request.URL),
+			"duration", duration,
+			"error", err,
+		)
+	} else {
+		traceRequest(request, response)
+		client.recordRequest(request, response, duration)
+		logger.DebugContext(ctx, "received ACS response",
+			"method", request.Method,
+			"url", logURL(request.URL),
+			"status", response.StatusCode,
+			"duration", duration,
+		)
+	}
+	if callOpts.requestLog != nil && err == nil {
+		callOpts.requestLog.record(
+			client.timeSource(),
+			RequestEventResponseReceived,
+			map[string]string{"status": response.Status},
+		)
+	}
+	return response, err
+}
+
+// retryWithSecondaryKey resends a request rejected with 401, signed with the secondary key of the
+// RotatingKeyManager. Without a secondary key, the original response is returned.
+func (client CommunicationIdentityClient) retryWithSecondaryKey(
+	request *http.Request,
+	rejected *http.Response,
+	callOpts callOptions,
+) (*http.Response, error) {
+	manager := client.options.keyManager
+	if manager == nil || request.GetBody == nil {
+		return rejected, nil
+	}
+	secondary, generation := manager.secondaryKey()
+	if secondary == nil {
+		return rejected, nil
+	}
+	retry, err := client.resignRequest(request, client.hmacSigner().withKey(secondary))
+	if err != nil {
+		return rejected, nil
+	}
+	client.discardResponse(rejected, callOpts)
+
+	client.emit(ClientEventPrimaryKeyRejected, PrimaryKeyRejectedEvent{
+		Method: request.Method,
+		Path:   request.URL.Path,
+	})
+	response, err := client.do(retry, callOpts)
+	if err == nil && response.StatusCode < http.StatusBadRequest {
+		if manager.recordSecondarySuccess(generation) {
+			client.emit(ClientEventKeyRotated, KeyRotatedEvent{Reason: "SecondaryKeyPromoted"})
+		}
+	}
+	return response, err
+}
+
+// logRetry logs the failed attempt before it is retried after delay
+func (client CommunicationIdentityClient) logRetry(
+	request *http.Request,
+	response *http.Response,
+	err error,
+	attempt int,
+	delay time.Duration,
+) {
+	attrs := []any{
+		"method", request.Method,
+		"url", logURL(request.URL),
+		"attempt", attempt,
+		"delay", delay,
+	}
+	if err != nil {
+		attrs = append(attrs, "error", err)
+	} else {
+		attrs = append(attrs, "status", response.StatusCode)
+	}
+	client.logger().InfoContext(request.Context(), "retrying ACS request", attrs...)
+}
+
+// closeResponse drains and closes the body of response and joins a close error into *err, it is
+// deferred with the named error result of the caller. Draining the unread rest of the body, e.g.
+// after an error was decoded, lets the connection be reused.
+func (client CommunicationIdentityClient) closeResponse(
+	response *http.Response,
+	callOpts callOptions,
+	err *error,
+) {
+	// larger remainders are cheaper to drop together with the connection
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, defaultMaxResponseBodySize))
+	if closeErr := response.Body.Close(); closeErr != nil {
+		*err = errors.Join(*err, newTransportError("failed to close response body: %w", closeErr))
+	}
+	if callOpts.requestLog != nil {
+		callOpts.requestLog.record(client.timeSource(), RequestEventBodyClosed, nil)
+	}
+}
+
+// discardResponse closes the body of a response that is not returned to the caller, e.g. one
+// replaced by a retry, close errors are logged
+func (client CommunicationIdentityClient) discardResponse(
+	response *http.Response,
+	callOpts callOptions,
+) {
+	var err error
+	if client.closeResponse(response, callOpts, &err); err == nil {
+		return
+	}
+	attrs := []any{"status", response.StatusCode, "error", err}
+	if response.Request != nil {
+		attrs = append(attrs,
+			"method", response.Request.Method,
+			"url", logURL(response.Request.URL),
+		)
+	}
+	client.logger().Warn("failed to close ACS response body", attrs...)
+}
+
+func (client CommunicationIdentityClient) audit(
+	request *http.Request,
+	start time.Time,
+	response *http.Response,
+	err error,
+) {
+	if client.options.auditSink == nil {
+		return
+	}
+	event := OutgoingRequestEvent{
+		Timestamp: start,
+		Method:    request.Method,
+		Path:      request.URL.Path,
+		Latency:   client.timeSource().Now().Sub(start),
+	}
+	if err != nil {
+		event.Error = err.Error()
+	} else {
+		event.StatusCode = response.StatusCode
+		event.RequestID = response.Header.Get(msRequestIDHeader)
+	}
+	client.options.auditSink.RecordEvent(event)
+}
@@ -0,0 +1,121 @@
+package communicationidentity
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+type testResult struct {
+	Value     string `json:"value"`
+	requestID string
+}
+
+func (result *testResult) setRequestID(requestID string) {
+	result.requestID = requestID
+}
+
+// newRequestTestClient returns a client of a server answering with status and body, and the URL
+// to send requests to
+func newRequestTestClient(
+	t *testing.T,
+	status int,
+	body string,
+) (CommunicationIdentityClient, *url.URL) {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(msRequestIDHeader, "request-1")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	endpoint, _ := url.Parse(server.URL)
+	client, err := New(endpoint, "c2VjcmV0", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, client.buildEndpointURL("test", client.apiVersion())
+}
+
+func TestDoRequestDecodesSuccess(t *testing.T) {
+	client, url := newRequestTestClient(t, http.StatusOK, `{"value":"decoded"}`)
+	result, err := doRequest[testResult](
+		client, context.Background(), http.MethodPost, url, []byte("{}"), http.StatusOK, callOptions{},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Value != "decoded" || result.requestID != "request-1" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+}
+
+func TestDoRequestSkipsDecodingNoContent(t *testing.T) {
+	client, url := newRequestTestClient(t, http.StatusNoContent, "")
+	_, err := doRequest[struct{}](
+		client, context.Background(), http.MethodDelete, url, nil, http.StatusNoContent, callOptions{},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDoRequestErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		message string
+		acsCode string
+	}{
+		{"unparseable success", http.StatusOK, "not json", "failed to parse response body", ""},
+		{"unparseable error", http.StatusBadRequest, "not json", "was not parseable", ""},
+		{"acs error", http.StatusBadRequest, `{"error":{"code":"Invalid"}}`, "Invalid", "Invalid"},
+		{"unexpected status", http.StatusCreated, `{"value":"decoded"}`, "201", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client, url := newRequestTestClient(t, test.status, test.body)
+			result, err := doRequest[testResult](
+				client, context.Background(), http.MethodPost, url, nil, http.StatusOK, callOptions{},
+			)
+			var identityErr *CommunicationIdentityError
+			if !errors.As(err, &identityErr) || !strings.Contains(err.Error(), test.message) {
+				t.Fatalf("expected an error containing %q, got: %v", test.message, err)
+			}
+			if identityErr.StatusCode != test.status {
+				t.Errorf("expected status %d, got %d", test.status, identityErr.StatusCode)
+			}
+			acsErr := identityErr.ACSError
+			if test.acsCode != "" && (acsErr == nil || acsErr.Code != test.acsCode) {
+				t.Errorf("expected ACS error %q, got %v", test.acsCode, acsErr)
+			}
+			if result != (testResult{}) {
+				t.Errorf("expected no result with an error, got %+v", result)
+			}
+		})
+	}
+}
+
+func TestDoRequestTransportErrors(t *testing.T) {
+	client, url := newRequestTestClient(t, http.StatusOK, "")
+	ctx := context.Background()
+	if _, err := doRequest[testResult](
+		client, ctx, http.MethodPost, nil, nil, http.StatusOK, callOptions{},
+	); err == nil || !strings.Contains(err.Error(), "failed to create signed request") {
+		t.Errorf("expected a signing error, got: %v", err)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err := doRequest[testResult](
+		client, canceled, http.MethodPost, url, nil, http.StatusOK, callOptions{},
+	)
+	if !errors.Is(err, context.Canceled) || !strings.Contains(err.Error(), "failed to send") {
+		t.Errorf("expected a send error, got: %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package communicationidentity_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+)
+
+// answers like handler with the `x-ms-request-id` header set to requestID
+func withRequestID(requestID string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("x-ms-request-id", requestID)
+		handler(w, r)
+	}
+}
+
+func TestRequestIDOfResults(t *testing.T) {
+	var path string
+	create := createIdentityHandler(new(atomic.Int32))
+	issue := issueTokenHandler(&path)
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/identities" {
+			create(w, r)
+			return
+		}
+		issue(w, r)
+	}
+	client := newTestClient(t, withRequestID("request-1", handler))
+	ctx := context.Background()
+
+	result, err := client.CreateCommunicationIdentity(ctx, []string{"chat"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.RequestID != "request-1" || result.AccessToken.RequestID != "request-1" {
+		t.Errorf("expected the request id on the result and its token, got %+v", result)
+	}
+	token, err := client.IssueAccessToken(ctx, result.Identity.ID, []string{"chat"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.RequestID != "request-1" {
+		t.Errorf("expected the request id on the token, got %q", token.RequestID)
+	}
+}
+
+func TestRequestIDOfErrors(t *testing.T) {
+	for name, body := range map[string]string{
+		"parseable":   `{"error":{"code":"Forbidden","message":"denied"}}`,
+		"unparseable": "<html>",
+	} {
+		t.Run(name, func(t *testing.T) {
+			client := newTestClient(t, errorHandler(http.StatusForbidden, "request-2", body))
+			_, err := client.TokenForTeamsUser(context.Background(), "oid", "token")
+
+			var statusErr *ci.StatusError
+			if !errors.As(err, &statusErr) || statusErr.RequestID != "request-2" {
+				t.Errorf("expected the request id on the status error, got %v", err)
+			}
+		})
+	}
+}
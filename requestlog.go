@@ -0,0 +1,153 @@
+package communicationidentity
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptrace"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Names of [RequestEvent]s recorded in a [RequestLog]
+const (
+	RequestEventDNSStart             = "dns_start"
+	RequestEventDNSDone              = "dns_done"
+	RequestEventConnectStart         = "connect_start"
+	RequestEventConnectDone          = "connect_done"
+	RequestEventTLSHandshakeStart    = "tls_handshake_start"
+	RequestEventTLSHandshakeDone     = "tls_handshake_done"
+	RequestEventGotConn              = "got_conn"
+	RequestEventWroteRequest         = "wrote_request"
+	RequestEventGotFirstResponseByte = "got_first_response_byte"
+	RequestEventResponseReceived     = "response_received"
+	RequestEventBodyClosed           = "body_closed"
+)
+
+type RequestEvent struct {
+	Name      string
+	Timestamp time.Time
+	Metadata  map[string]string
+}
+
+// Chronological log of the phases of a single request, for post-mortem analysis of
+// intermittent failures, see [WithRequestLog]. Safe for concurrent use.
+type RequestLog struct {
+	mu     sync.Mutex
+	Events []RequestEvent
+}
+
+// WithRequestLog records the events of the call's request into a new [RequestLog], which is
+// returned by [CallWithResponse] once the call returns, including failed calls. Without
+// [CallWithResponse] the option has no effect.
+func WithRequestLog() CallOption {
+	return func(options *callOptions) {
+		options.recordRequestLog = true
+	}
+}
+
+// Response wraps the value returned by a client method together with the metadata of its request
+type Response[T any] struct {
+	Value T
+	// nil unless the call was made with [WithRequestLog]
+	RequestLog *RequestLog
+}
+
+// CallWithResponse invokes call with opts and wraps its result in a [Response], e.g.
+//
+//	response, err := CallWithResponse(
+//		func(opts ...CallOption) (CommunicationIdentityAccessToken, error) {
+//			return client.IssueAccessToken(ctx, identityID, scopes, nil, opts...)
+//		},
+//		WithRequestLog(),
+//	)
+//
+// The request log is returned on errors too, as far as the request got.
+func CallWithResponse[T any](
+	call func(opts ...CallOption) (T, error),
+	opts ...CallOption,
+) (Response[T], error) {
+	var log *RequestLog
+	if applyCallOptions(opts).recordRequestLog {
+		log = &RequestLog{}
+		opts = append(slices.Clip(opts), func(options *callOptions) {
+			options.requestLog = log
+		})
+	}
+	value, err := call(opts...)
+	return Response[T]{Value: value, RequestLog: log}, err
+}
+
+// Duration returns the time between the first events named from and to,
+// zero if either event was not recorded
+func (log *RequestLog) Duration(from, to string) time.Duration {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+
+	var start, end time.Time
+	for _, event := range log.Events {
+		if event.Name == from && start.IsZero() {
+			start = event.Timestamp
+		}
+		if event.Name == to && end.IsZero() {
+			end = event.Timestamp
+		}
+	}
+	if start.IsZero() || end.IsZero() {
+		return 0
+	}
+	return end.Sub(start)
+}
+
+func (log *RequestLog) record(clock TimeSource, name string, metadata map[string]string) {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	log.Events = append(log.Events, RequestEvent{name, clock.Now(), metadata})
+}
+
+func errorMetadata(err error) map[string]string {
+	if err == nil {
+		return nil
+	}
+	return map[string]string{"error": err.Error()}
+}
+
+func (log *RequestLog) trace(ctx context.Context, clock TimeSource) context.Context {
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		DNSStart: func(info httptrace.DNSStartInfo) {
+			log.record(clock, RequestEventDNSStart, map[string]string{"host": info.Host})
+		},
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			log.record(clock, RequestEventDNSDone, errorMetadata(info.Err))
+		},
+		ConnectStart: func(network, addr string) {
+			log.record(clock, RequestEventConnectStart, map[string]string{"addr": addr})
+		},
+		ConnectDone: func(network, addr string, err error) {
+			metadata := map[string]string{"addr": addr}
+			if err != nil {
+				metadata["error"] = err.Error()
+			}
+			log.record(clock, RequestEventConnectDone, metadata)
+		},
+		TLSHandshakeStart: func() {
+			log.record(clock, RequestEventTLSHandshakeStart, nil)
+		},
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			log.record(clock, RequestEventTLSHandshakeDone, errorMetadata(err))
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			reused := "false"
+			if info.Reused {
+				reused = "true"
+			}
+			log.record(clock, RequestEventGotConn, map[string]string{"reused": reused})
+		},
+		WroteRequest: func(info httptrace.WroteRequestInfo) {
+			log.record(clock, RequestEventWroteRequest, errorMetadata(info.Err))
+		},
+		GotFirstResponseByte: func() {
+			log.record(clock, RequestEventGotFirstResponseByte, nil)
+		},
+	})
+}
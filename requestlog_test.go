@@ -0,0 +1,74 @@
+package communicationidentity_test
+
+import (
+	"context"
+	"slices"
+	"sync/atomic"
+	"testing"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+)
+
+func TestWithRequestLog(t *testing.T) {
+	client := newTestClient(t, createIdentityHandler(new(atomic.Int32)))
+
+	response, err := ci.CallWithResponse(
+		func(opts ...ci.CallOption) (ci.CommunicationIdentityAccessTokenResult, error) {
+			return client.CreateCommunicationIdentity(context.Background(), []string{"chat"}, nil, opts...)
+		},
+		ci.WithRequestLog(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Value.Identity.ID == "" {
+		t.Errorf("expected the created identity in the response, got %+v", response.Value)
+	}
+	log := response.RequestLog
+	if log == nil {
+		t.Fatal("expected a request log")
+	}
+
+	var names []string
+	for _, event := range log.Events {
+		names = append(names, event.Name)
+	}
+	for _, want := range []string{
+		ci.RequestEventConnectStart,
+		ci.RequestEventGotConn,
+		ci.RequestEventWroteRequest,
+		ci.RequestEventGotFirstResponseByte,
+		ci.RequestEventResponseReceived,
+		ci.RequestEventBodyClosed,
+	} {
+		if !slices.Contains(names, want) {
+			t.Errorf("expected event %q in %v", want, names)
+		}
+	}
+	if names[len(names)-1] != ci.RequestEventBodyClosed {
+		t.Errorf("expected body close to be the last event, got %v", names)
+	}
+
+	if d := log.Duration(ci.RequestEventGotConn, ci.RequestEventBodyClosed); d < 0 {
+		t.Errorf("expected non-negative duration, got %v", d)
+	}
+	if d := log.Duration(ci.RequestEventDNSStart, ci.RequestEventBodyClosed); d != 0 {
+		t.Errorf("expected zero duration for unrecorded event, got %v", d)
+	}
+}
+
+func TestCallWithResponseWithoutRequestLog(t *testing.T) {
+	client := newTestClient(t, createIdentityHandler(new(atomic.Int32)))
+
+	response, err := ci.CallWithResponse(
+		func(opts ...ci.CallOption) (ci.CommunicationIdentityAccessTokenResult, error) {
+			return client.CreateCommunicationIdentity(context.Background(), []string{"chat"}, nil, opts...)
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.RequestLog != nil {
+		t.Errorf("expected no request log without WithRequestLog, got %+v", response.RequestLog)
+	}
+}
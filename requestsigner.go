@@ -0,0 +1,166 @@
+package communicationidentity
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+)
+
+// RequestSigner authenticates requests to ACS by setting their headers, body is the content of
+// the request. It is called again for every retried attempt, see [WithRequestSigner].
+type RequestSigner interface {
+	SignRequest(r *http.Request, body []byte) error
+}
+
+// WithRequestSigner authenticates requests with signer, e.g. for non-standard auth schemes. [New]
+// must then be called with an empty access key, and [WithTokenCredential] can not be used.
+func WithRequestSigner(signer RequestSigner) ClientOption {
+	return func(options *clientOptions) error {
+		if signer == nil {
+			return fmt.Errorf("request signer can not be nil")
+		}
+		options.requestSigner = signer
+		return nil
+	}
+}
+
+// HMACSigner signs requests with an ACS access key, the scheme of clients created by [New]. The
+// signer of a client follows its key rotation and its [WithSigner] and [WithCustomSigningHeader]
+// options.
+//
+// see: https://learn.microsoft.com/en-us/azure/communication-services/tutorials/hmac-header-tutorial?pivots=programming-language-csharp
+type HMACSigner struct {
+	accessKey     func() []byte
+	signer        Signer
+	headerBuilder SigningHeaderBuilder
+	timeSource    TimeSource
+}
+
+// NewHMACSigner creates a signer for the base64 encoded accessKey using HMAC-SHA256, e.g. to be
+// wrapped by a custom [RequestSigner]
+func NewHMACSigner(accessKey string) (*HMACSigner, error) {
+	key, err := decodeAccessKey(accessKey)
+	if err != nil {
+		return nil, err
+	}
+	return &HMACSigner{
+		accessKey:  func() []byte { return key },
+		signer:     hmacSigner{SigningAlgorithmHMACSHA256.String(), sha256.New},
+		timeSource: systemTimeSource{},
+	}, nil
+}
+
+// SignRequest sets the HMAC authentication headers of r, replacing existing ones
+func (signer *HMACSigner) SignRequest(r *http.Request, body []byte) error {
+	computeHash := func(content []byte) string {
+		hash := sha256.Sum256(content)
+		return base64.StdEncoding.EncodeToString(hash[:])
+	}
+
+	// DO NOT USE 'time.RFC1123' : https://github.com/golang/go/issues/13781
+	date := signer.timeSource.Now().UTC().Format(http.TimeFormat)
+	contentHash := computeHash(body)
+	url := r.URL
+	pathAndQuery := fmt.Sprintf("%s?%s", url.EscapedPath(), url.RawQuery)
+
+	r.Header.Set(msDateHeader, date)
+	r.Header.Set(msContentHashHeader, contentHash)
+
+	if builder := signer.headerBuilder; builder != nil {
+		name, value, err := builder(r.Method, pathAndQuery, date, url.Host, contentHash)
+		if err != nil {
+			return fmt.Errorf("failed to build custom signing header: %w", err)
+		}
+		if name == "" {
+			return fmt.Errorf("custom signing header name can not be empty")
+		}
+		r.Header.Set(name, value)
+		return nil
+	}
+
+	stringToSign := fmt.Sprintf(
+		"%s\n%s\n%s;%s;%s",
+		r.Method,
+		pathAndQuery,
+		date,
+		url.Host,
+		contentHash,
+	)
+	signature, err := signer.signer.Sign(stringToSign, signer.accessKey())
+	if err != nil {
+		return fmt.Errorf("failed to build request signature: %w", err)
+	}
+
+	authorization :=
+		fmt.Sprintf(
+			"%s SignedHeaders=x-ms-date;host;x-ms-content-sha256&Signature=%s",
+			signer.signer.AlgorithmName(),
+			signature,
+		)
+
+	r.Header.Set(msAuthHeader, authorization)
+	return nil
+}
+
+// withKey returns a copy of signer signing with accessKey, e.g. the secondary key of a
+// RotatingKeyManager
+func (signer *HMACSigner) withKey(accessKey []byte) *HMACSigner {
+	keyed := *signer
+	keyed.accessKey = func() []byte { return accessKey }
+	return &keyed
+}
+
+// BearerTokenSigner authenticates requests with Entra ID tokens of a [TokenCredential], the scheme
+// of clients created by [NewWithTokenCredential]
+type BearerTokenSigner struct {
+	cred  TokenCredential
+	scope string
+}
+
+// NewBearerTokenSigner creates a signer requesting the tokens of cred for scope, an empty scope
+// requests tokens for "https://communication.azure.com/.default"
+func NewBearerTokenSigner(cred TokenCredential, scope string) (*BearerTokenSigner, error) {
+	if cred == nil {
+		return nil, fmt.Errorf("token credential can not be nil")
+	}
+	if scope == "" {
+		scope = acsTokenScope
+	}
+	return &BearerTokenSigner{cred: cred, scope: scope}, nil
+}
+
+// SignRequest sets the Authorization header of r to a token of the credential, fetched with the
+// context of r
+func (signer *BearerTokenSigner) SignRequest(r *http.Request, _ []byte) error {
+	token, err := signer.cred.GetToken(
+		r.Context(),
+		TokenRequestOptions{Scopes: []string{signer.scope}},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get token from credential: %w", err)
+	}
+	r.Header.Set(msAuthHeader, "Bearer "+token.Token)
+	return nil
+}
+
+// newRequestSigner returns the signer of the authentication mode the client is configured with
+func (client CommunicationIdentityClient) newRequestSigner() RequestSigner {
+	switch options := client.options; {
+	case options.requestSigner != nil:
+		return options.requestSigner
+	case options.tokenCredential != nil:
+		return &BearerTokenSigner{cred: options.tokenCredential, scope: client.tokenScope()}
+	default:
+		return client.hmacSigner()
+	}
+}
+
+func (client CommunicationIdentityClient) hmacSigner() *HMACSigner {
+	return &HMACSigner{
+		accessKey:     client.accessKey,
+		signer:        client.signer(),
+		headerBuilder: client.options.signingHeaderBuilder,
+		timeSource:    client.timeSource(),
+	}
+}
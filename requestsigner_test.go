@@ -0,0 +1,108 @@
+package communicationidentity_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+)
+
+// headerSigner wraps an HMACSigner and adds a header to every request
+type headerSigner struct {
+	inner *ci.HMACSigner
+	calls atomic.Int32
+}
+
+func (signer *headerSigner) SignRequest(r *http.Request, body []byte) error {
+	signer.calls.Add(1)
+	r.Header.Set("x-custom-auth", "yes")
+	return signer.inner.SignRequest(r, body)
+}
+
+func TestWithRequestSigner(t *testing.T) {
+	var headers []http.Header
+	flaky := flakyHandler(http.StatusServiceUnavailable, 1, new([]string))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = append(headers, r.Header.Clone())
+		flaky(w, r)
+	}))
+	t.Cleanup(server.Close)
+	endpoint, _ := url.Parse(server.URL)
+
+	inner, err := ci.NewHMACSigner(testAccessKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := &headerSigner{inner: inner}
+	client, err := ci.New(endpoint, "", "",
+		ci.WithRequestSigner(signer),
+		ci.WithRetry(2, time.Millisecond),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CreateCommunicationIdentity(
+		context.Background(), []string{"chat"}, nil,
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	if signer.calls.Load() != 2 || len(headers) != 2 {
+		t.Fatalf("expected every attempt to be signed, got %d signatures for %d requests",
+			signer.calls.Load(), len(headers))
+	}
+	for _, header := range headers {
+		if header.Get("x-custom-auth") != "yes" ||
+			!strings.HasPrefix(header.Get("Authorization"), "HMAC-SHA256 ") {
+			t.Errorf("expected the headers of the custom signer, got %v", header)
+		}
+	}
+}
+
+func TestRequestSignerIsExclusive(t *testing.T) {
+	endpoint, _ := url.Parse("https://myresource.communication.azure.com")
+	signer, err := ci.NewHMACSigner(testAccessKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ci.New(endpoint, testAccessKey, "", ci.WithRequestSigner(signer)); err == nil {
+		t.Error("expected an error for an access key and a request signer")
+	}
+	_, err = ci.New(endpoint, "", "",
+		ci.WithRequestSigner(signer),
+		ci.WithTokenCredential(&staticCredential{token: "entra-token"}),
+	)
+	if err == nil {
+		t.Error("expected an error for a token credential and a request signer")
+	}
+	if _, err := ci.New(endpoint, "", "", ci.WithRequestSigner(nil)); err == nil {
+		t.Error("expected an error for a nil request signer")
+	}
+}
+
+func TestNewBearerTokenSigner(t *testing.T) {
+	cred := &staticCredential{token: "entra-token"}
+	signer, err := ci.NewBearerTokenSigner(cred, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	request, _ := http.NewRequest(http.MethodPost, "https://example.invalid/identities", nil)
+	if err := signer.SignRequest(request, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := request.Header.Get("Authorization"); got != "Bearer entra-token" {
+		t.Errorf("expected a Bearer token, got %q", got)
+	}
+	if len(cred.scopes) != 1 || cred.scopes[0][0] != "https://communication.azure.com/.default" {
+		t.Errorf("expected the default ACS scope, got %v", cred.scopes)
+	}
+	if _, err := ci.NewBearerTokenSigner(nil, ""); err == nil {
+		t.Error("expected an error for a nil credential")
+	}
+}
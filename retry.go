@@ -0,0 +1,175 @@
+package communicationidentity
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// default upper bound of a single retry delay of WithRetry, see WithRetryMaxDelay
+const maxRetryDelay = 30 * time.Second
+
+type retryPolicy struct {
+	maxAttempts int
+	baseDelay   time.Duration
+	// zero for maxRetryDelay
+	maxDelay time.Duration
+	jitter   JitterStrategy
+	// nil for a random seed
+	seed *uint64
+}
+
+// WithRetry resends requests failing with a network error or with status 429, 500 or 503, up to
+// maxAttempts attempts in total. The delay before a retry starts at baseDelay and doubles with
+// every attempt up to 30 seconds or the cap of [WithRetryMaxDelay], and is randomized by the
+// [JitterStrategy] of [WithRetryJitter]. Delays run on the clock of
+// [WithTimeSource] and stop as soon as the context of the call is done. Errors a retry can not
+// fix, e.g. an untrusted TLS certificate or a canceled context, are returned right away.
+//
+// A 429 response with a Retry-After header, in seconds or as HTTP date, is retried after the
+// indicated delay instead. If that delay ends after the deadline of the context, the 429 is
+// returned right away, see [IsRateLimited].
+//
+// Every retry is signed again with a fresh `x-ms-date` and the current access key, the body and
+// `x-ms-idempotency-key` (see [WithIdempotencyKey]) of the first attempt are kept. For 429
+// responses the [RateLimitHandler] of [WithOnRateLimitExceeded] is called before each retry;
+// returning an error stops retrying.
+func WithRetry(maxAttempts int, baseDelay time.Duration) ClientOption {
+	return func(options *clientOptions) error {
+		if maxAttempts < 1 {
+			return fmt.Errorf("retry max attempts must be at least 1, got %d", maxAttempts)
+		}
+		if baseDelay <= 0 {
+			return fmt.Errorf("retry base delay must be positive, got %v", baseDelay)
+		}
+		options.retry.maxAttempts = maxAttempts
+		options.retry.baseDelay = baseDelay
+		return nil
+	}
+}
+
+// delay returns the wait before the retry following attempt, attempts are counted from 1
+func (policy retryPolicy) delay(attempt int, random *lockedRand) time.Duration {
+	maxDelay := policy.maxDelay
+	if maxDelay == 0 {
+		maxDelay = maxRetryDelay
+	}
+	delay := maxDelay
+	// shifting by 62 or more overflows for any base delay of a nanosecond or more
+	if shift := attempt - 1; shift < 62 && policy.baseDelay <= maxDelay>>shift {
+		delay = policy.baseDelay << shift
+	}
+	return policy.jitter.apply(delay, random)
+}
+
+// retryDelay returns the wait before the retry following attempt. A Retry-After header of a 429
+// response replaces the backoff of the policy, exceedsDeadline reports that it ends after the
+// deadline of ctx.
+func (client CommunicationIdentityClient) retryDelay(
+	ctx context.Context,
+	response *http.Response,
+	attempt int,
+) (delay time.Duration, exceedsDeadline bool) {
+	if response == nil || response.StatusCode != http.StatusTooManyRequests {
+		return client.options.retry.delay(attempt, client.state.retryRand), false
+	}
+	wait := retryAfter(response, client.timeSource().Now())
+	if wait == 0 {
+		return client.options.retry.delay(attempt, client.state.retryRand), false
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+		return 0, true
+	}
+	return wait, false
+}
+
+// isRetryable reports whether an attempt failed on the network or with a transient status.
+// Errors of a canceled context, of TLS and of a RateLimitHandler are final.
+func isRetryable(ctx context.Context, response *http.Response, err error) bool {
+	if err != nil {
+		return ctx.Err() == nil && isRetryableTransportError(err)
+	}
+	return isRetryableStatus(response.StatusCode)
+}
+
+// isRetryableStatus reports ACS responses that may succeed when the request is sent again
+func isRetryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusServiceUnavailable:
+		return true
+	default:
+		return false
+	}
+}
+
+// isRetryableTransportError reports transport failures that may succeed on another attempt
+func isRetryableTransportError(err error) bool {
+	var urlErr *url.Error
+	return errors.As(err, &urlErr) && !isPermanentTransportError(err)
+}
+
+// waitForRetry blocks for delay on the clock of the client, or until ctx is done
+func (client CommunicationIdentityClient) waitForRetry(
+	ctx context.Context,
+	delay time.Duration,
+) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-client.timeSource().After(delay):
+		return nil
+	}
+}
+
+// isPermanentTransportError reports transport failures that fail the same way on every attempt
+func isPermanentTransportError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var (
+		verificationErr     *tls.CertificateVerificationError
+		recordHeaderErr     tls.RecordHeaderError
+		alertErr            tls.AlertError
+		unknownAuthorityErr x509.UnknownAuthorityError
+		invalidCertErr      x509.CertificateInvalidError
+		hostnameErr         x509.HostnameError
+	)
+	return errors.As(err, &verificationErr) ||
+		errors.As(err, &recordHeaderErr) ||
+		errors.As(err, &alertErr) ||
+		errors.As(err, &unknownAuthorityErr) ||
+		errors.As(err, &invalidCertErr) ||
+		errors.As(err, &hostnameErr)
+}
+
+// resignRequest copies request for another attempt signed by signer, the body is replayed from
+// GetBody
+func (client CommunicationIdentityClient) resignRequest(
+	request *http.Request,
+	signer RequestSigner,
+) (*http.Request, error) {
+	var content []byte
+	if request.GetBody != nil {
+		body, err := request.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("failed to replay request body: %w", err)
+		}
+		content, err = io.ReadAll(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to replay request body: %w", err)
+		}
+	}
+	retry := request.Clone(request.Context())
+	retry.Body = io.NopCloser(bytes.NewReader(content))
+	if err := signer.SignRequest(retry, content); err != nil {
+		return nil, fmt.Errorf("failed to sign retried request: %w", err)
+	}
+	return retry, nil
+}
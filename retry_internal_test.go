@@ -0,0 +1,82 @@
+package communicationidentity
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryDelayIsCapped(t *testing.T) {
+	random := newLockedRand(nil)
+	for _, policy := range []retryPolicy{
+		{maxAttempts: 100, baseDelay: time.Second},
+		{maxAttempts: 100, baseDelay: time.Second, jitter: JitterStrategyNone},
+		{maxAttempts: 100, baseDelay: time.Second, maxDelay: time.Minute},
+	} {
+		upper := max(policy.maxDelay, maxRetryDelay)
+		for _, attempt := range []int{6, 30, 31, 64, 100} {
+			if delay := policy.delay(attempt, random); delay < 0 || delay > upper {
+				t.Errorf("attempt %d: expected a delay of at most %v, got %v", attempt, upper, delay)
+			}
+		}
+	}
+}
+
+func TestRetryJitterStrategies(t *testing.T) {
+	random := newLockedRand(nil)
+	for attempt, exponential := range []time.Duration{
+		time.Second,
+		2 * time.Second,
+		4 * time.Second,
+	} {
+		attempt++
+		for range 100 {
+			full := retryPolicy{baseDelay: time.Second}.delay(attempt, random)
+			if full < 0 || full > exponential {
+				t.Errorf("attempt %d: expected a full jitter delay up to %v, got %v",
+					attempt, exponential, full)
+			}
+			equal := retryPolicy{baseDelay: time.Second, jitter: JitterStrategyEqual}.
+				delay(attempt, random)
+			if equal < exponential/2 || equal > exponential {
+				t.Errorf("attempt %d: expected an equal jitter delay from %v to %v, got %v",
+					attempt, exponential/2, exponential, equal)
+			}
+		}
+		none := retryPolicy{baseDelay: time.Second, jitter: JitterStrategyNone}.delay(attempt, random)
+		if none != exponential {
+			t.Errorf("attempt %d: expected a delay of %v without jitter, got %v",
+				attempt, exponential, none)
+		}
+	}
+}
+
+func TestWithRetrySeedMakesDelaysDeterministic(t *testing.T) {
+	delays := func() []time.Duration {
+		client, err := New(nil, "c2VjcmV0", "", WithRetry(5, time.Second), WithRetrySeed(42))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var delays []time.Duration
+		for attempt := 1; attempt < 5; attempt++ {
+			delays = append(delays, client.options.retry.delay(attempt, client.state.retryRand))
+		}
+		return delays
+	}
+	first, second := delays(), delays()
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("expected the same delays for the same seed, got %v and %v", first, second)
+		}
+	}
+}
+
+func TestRetryJitterOptionsValidation(t *testing.T) {
+	for _, opt := range []ClientOption{
+		WithRetryJitter(JitterStrategy(42)),
+		WithRetryMaxDelay(0),
+	} {
+		if _, err := New(nil, "c2VjcmV0", "", opt); err == nil {
+			t.Error("expected the retry option to be rejected")
+		}
+	}
+}
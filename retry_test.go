@@ -0,0 +1,196 @@
+package communicationidentity_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+)
+
+// fails the first failures requests with status, records the idempotency keys received
+func flakyHandler(status int, failures int32, keys *[]string) http.HandlerFunc {
+	var calls atomic.Int32
+	create := createIdentityHandler(new(atomic.Int32))
+	return func(w http.ResponseWriter, r *http.Request) {
+		*keys = append(*keys, r.Header.Get("x-ms-idempotency-key"))
+		if calls.Add(1) <= failures {
+			w.WriteHeader(status)
+			return
+		}
+		create(w, r)
+	}
+}
+
+func TestWithRetryRetriesTransientStatus(t *testing.T) {
+	for _, status := range []int{
+		http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusServiceUnavailable,
+	} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			var keys []string
+			client := newTestClient(t, flakyHandler(status, 2, &keys),
+				ci.WithRetry(3, time.Millisecond),
+			)
+			_, err := client.CreateCommunicationIdentity(
+				context.Background(),
+				[]string{"chat"},
+				nil,
+				ci.WithIdempotencyKey("key-1"),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(keys) != 3 || keys[0] != "key-1" || keys[1] != keys[0] || keys[2] != keys[0] {
+				t.Errorf("expected 3 attempts with the same idempotency key, got %v", keys)
+			}
+		})
+	}
+}
+
+func TestWithRetryReturnsLastError(t *testing.T) {
+	var keys []string
+	client := newTestClient(t, flakyHandler(http.StatusServiceUnavailable, 5, &keys),
+		ci.WithRetry(2, time.Millisecond),
+	)
+	_, err := client.CreateCommunicationIdentity(context.Background(), []string{"chat"}, nil)
+	var identityErr *ci.CommunicationIdentityError
+	if !errors.As(err, &identityErr) || identityErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected the 503 of the last attempt, got: %v", err)
+	}
+	if len(keys) != 2 {
+		t.Errorf("expected 2 attempts, got %d", len(keys))
+	}
+}
+
+func TestWithRetryDoesNotRetryClientErrors(t *testing.T) {
+	var keys []string
+	client := newTestClient(t, flakyHandler(http.StatusBadRequest, 1, &keys),
+		ci.WithRetry(3, time.Millisecond),
+	)
+	if _, err := client.CreateCommunicationIdentity(
+		context.Background(), []string{"chat"}, nil,
+	); err == nil {
+		t.Fatal("expected the 400 to be returned")
+	}
+	if len(keys) != 1 {
+		t.Errorf("expected a single attempt, got %d", len(keys))
+	}
+}
+
+func TestWithRetryRetriesNetworkErrors(t *testing.T) {
+	var calls atomic.Int32
+	create := createIdentityHandler(new(atomic.Int32))
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				_ = conn.Close()
+			}
+			return
+		}
+		create(w, r)
+	}, ci.WithRetry(2, time.Millisecond))
+
+	if _, err := client.CreateCommunicationIdentity(
+		context.Background(), []string{"chat"}, nil,
+	); err != nil {
+		t.Fatal(err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("expected a retry after the connection was closed, got %d calls", calls.Load())
+	}
+}
+
+func TestWithRetryStopsOnContextDone(t *testing.T) {
+	var keys []string
+	client := newTestClient(t, flakyHandler(http.StatusServiceUnavailable, 5, &keys),
+		ci.WithRetry(5, time.Hour),
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.CreateCommunicationIdentity(ctx, []string{"chat"}, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected waiting to stop with the context, took %v", elapsed)
+	}
+}
+
+func TestWithRetryValidation(t *testing.T) {
+	for name, opt := range map[string]ci.ClientOption{
+		"zero attempts": ci.WithRetry(0, time.Second),
+		"zero delay":    ci.WithRetry(3, 0),
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ci.New(nil, testAccessKey, "", opt); err == nil {
+				t.Error("expected option to be rejected")
+			}
+		})
+	}
+}
+
+func TestWithRetrySignsEveryAttempt(t *testing.T) {
+	var (
+		client     ci.CommunicationIdentityClient
+		signatures []string
+		calls      atomic.Int32
+	)
+	create := createIdentityHandler(new(atomic.Int32))
+	client = newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		signatures = append(signatures, r.Header.Get("Authorization"))
+		if calls.Add(1) == 1 {
+			if err := client.RotateAccessKey("bmV3"); err != nil { // "new"
+				t.Error(err)
+			}
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		create(w, r)
+	}, ci.WithSigner(keySigner{}), ci.WithRetry(2, time.Millisecond))
+
+	if _, err := client.CreateCommunicationIdentity(
+		context.Background(), []string{"chat"}, nil,
+	); err != nil {
+		t.Fatal(err)
+	}
+	if len(signatures) != 2 || !strings.HasSuffix(signatures[1], "Signature=new") {
+		t.Errorf("expected the retry to be signed with the rotated key, got %v", signatures)
+	}
+}
+
+func TestWithRetryDoesNotRetryTLSErrors(t *testing.T) {
+	server := httptest.NewTLSServer(createIdentityHandler(new(atomic.Int32)))
+	defer server.Close()
+	endpoint, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport := &countingTransport{inner: http.DefaultTransport}
+	client, err := ci.New(endpoint, testAccessKey, "",
+		ci.WithBaseTransport(transport),
+		ci.WithRetry(3, time.Millisecond),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.CreateCommunicationIdentity(
+		context.Background(), []string{"chat"}, nil,
+	); err == nil {
+		t.Fatal("expected the untrusted certificate to be rejected")
+	}
+	if transport.calls.Load() != 1 {
+		t.Errorf("expected a single attempt, got %d", transport.calls.Load())
+	}
+}
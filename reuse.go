@@ -0,0 +1,104 @@
+package communicationidentity
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// upper bound of identities remembered by CreateCommunicationIdentityOrReuse, expired identities
+// are dropped first, then the one expiring soonest
+const maxReusableIdentities = 1024
+
+// Coalesces concurrent calls with the same key into a single execution,
+// like golang.org/x/sync/singleflight but without the dependency
+type flightGroup[T any] struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall[T]
+}
+
+type flightCall[T any] struct {
+	done  chan struct{}
+	value T
+	err   error
+}
+
+// do runs fn unless a call for key is already in flight, in which case it waits for that call's
+// result and reports it as shared. Waiting stops early if ctx is done.
+func (group *flightGroup[T]) do(
+	ctx context.Context,
+	key string,
+	fn func() (T, error),
+) (value T, shared bool, err error) {
+	group.mu.Lock()
+	if group.calls == nil {
+		group.calls = make(map[string]*flightCall[T])
+	}
+	if call, found := group.calls[key]; found {
+		group.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.value, true, call.err
+		case <-ctx.Done():
+			return value, false, ctx.Err()
+		}
+	}
+	call := &flightCall[T]{done: make(chan struct{})}
+	group.calls[key] = call
+	group.mu.Unlock()
+
+	defer func() {
+		group.mu.Lock()
+		delete(group.calls, key)
+		group.mu.Unlock()
+		close(call.done)
+	}()
+	call.value, call.err = fn()
+	return call.value, false, call.err
+}
+
+// CreateCommunicationIdentityOrReuse creates an identity at most once per idempotencyKey.
+// Concurrent calls with the same key share a single request to ACS, later calls are served the
+// same identity as long as its token is valid. The returned bool reports whether an existing
+// identity was reused. Up to 1024 identities are remembered, once full expired identities are
+// dropped first and then the one whose token expires soonest.
+//
+// The key is also sent as idempotency key (see [WithIdempotencyKey]), so ACS deduplicates
+// requests from other processes using the same key.
+func (client CommunicationIdentityClient) CreateCommunicationIdentityOrReuse(
+	ctx context.Context,
+	idempotencyKey string,
+	scopes []Scope,
+	expiry *int32,
+	opts ...CallOption,
+) (CommunicationIdentityAccessTokenResult, bool, error) {
+	if idempotencyKey == "" {
+		return CommunicationIdentityAccessTokenResult{}, false, &ValidationError{
+			Field: "idempotencyKey",
+			Err:   fmt.Errorf("idempotency key can not be empty"),
+		}
+	}
+	if result, found := client.state.reusable.get(idempotencyKey); found {
+		return client.secureResult(result), true, nil
+	}
+
+	callOpts := applyCallOptions(opts)
+	callOpts.idempotencyKey = idempotencyKey
+	result, shared, err := client.state.creations.do(
+		ctx,
+		idempotencyKey,
+		func() (CommunicationIdentityAccessTokenResult, error) {
+			result, err := client.createCommunicationIdentity(
+				ctx,
+				scopeStrings(scopes),
+				expiry,
+				callOpts,
+			)
+			if err == nil {
+				client.state.reusable.add(idempotencyKey, "", result)
+			}
+			return result, err
+		},
+	)
+	return client.secureResult(result), shared, err
+}
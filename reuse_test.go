@@ -0,0 +1,82 @@
+package communicationidentity_test
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+)
+
+func TestCreateCommunicationIdentityOrReuseCoalescesConcurrentCalls(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	handler := createIdentityHandler(&calls)
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		handler(w, r)
+	})
+
+	const callers = 5
+	var wg sync.WaitGroup
+	var reusedCount atomic.Int32
+	ids := make([]string, callers)
+	for i := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, reused, err := client.CreateCommunicationIdentityOrReuse(
+				context.Background(),
+				"user-42",
+				[]ci.Scope{ci.ScopeChat},
+				nil,
+			)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if reused {
+				reusedCount.Add(1)
+			}
+			ids[i] = result.Identity.ID
+		}()
+	}
+	close(release)
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Errorf("expected a single creation request, got %d", calls.Load())
+	}
+	if reusedCount.Load() != callers-1 {
+		t.Errorf("expected %d reused results, got %d", callers-1, reusedCount.Load())
+	}
+	for _, id := range ids {
+		if id != ids[0] {
+			t.Errorf("expected all callers to get the same identity, got %v", ids)
+			break
+		}
+	}
+
+	_, reused, err := client.CreateCommunicationIdentityOrReuse(
+		context.Background(),
+		"user-42",
+		[]ci.Scope{ci.ScopeChat},
+		nil,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reused || calls.Load() != 1 {
+		t.Errorf("expected reuse without request, reused=%v calls=%d", reused, calls.Load())
+	}
+}
+
+func TestCreateCommunicationIdentityOrReuseRequiresKey(t *testing.T) {
+	client := newTestClient(t, createIdentityHandler(new(atomic.Int32)))
+	_, _, err := client.CreateCommunicationIdentityOrReuse(context.Background(), "", nil, nil)
+	if err == nil {
+		t.Error("expected error for empty idempotency key")
+	}
+}
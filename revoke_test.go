@@ -0,0 +1,44 @@
+package communicationidentity_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+)
+
+func TestRevokeAccessTokens(t *testing.T) {
+	var method, path, contentHash string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.EscapedPath()
+		contentHash = r.Header.Get("x-ms-content-sha256")
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	if err := client.RevokeAccessTokens(context.Background(), "tenant/user"); err != nil {
+		t.Fatal(err)
+	}
+	if method != http.MethodPost {
+		t.Errorf("expected POST, got %s", method)
+	}
+	if want := "/identities/tenant%2Fuser/:revokeAccessTokens"; path != want {
+		t.Errorf("expected path %s, got %s", want, path)
+	}
+	// SHA-256 of an empty body
+	if want := "47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="; contentHash != want {
+		t.Errorf("expected content hash of the empty body, got %s", contentHash)
+	}
+}
+
+func TestRevokeAccessTokensNotFound(t *testing.T) {
+	body := `{"error":{"code":"IdentityNotFound","message":"not found"}}`
+	client := newTestClient(t, errorHandler(http.StatusNotFound, "request-1", body))
+
+	err := client.RevokeAccessTokens(context.Background(), testIdentityID)
+	var acsErr *ci.CommunicationError
+	if !errors.As(err, &acsErr) || acsErr.Code != "IdentityNotFound" {
+		t.Errorf("expected IdentityNotFound CommunicationError, got %v", err)
+	}
+}
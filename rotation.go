@@ -0,0 +1,155 @@
+package communicationidentity
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// how often NewFromConnectionStringWithRotation checks the connection string file on platforms
+// without file watching
+const connectionStringPollInterval = 10 * time.Second
+
+// RotateAccessKey replaces the access key requests are signed with for the client and all of its
+// copies, key is the base64 encoded key of the Azure portal. With [WithRotatingKeyManager], the
+// primary key of the manager is replaced. Closed clients report [ErrClientClosed].
+func (client CommunicationIdentityClient) RotateAccessKey(key string) error {
+	if client.state.closed.Load() {
+		return ErrClientClosed
+	}
+	if client.options.keyManager != nil {
+		if err := client.options.keyManager.SetPrimaryKey(key); err != nil {
+			return err
+		}
+	} else {
+		decoded, err := decodeAccessKey(key)
+		if err != nil {
+			return err
+		}
+		client.state.rotatedKey.Store(&decoded)
+	}
+	client.emit(ClientEventKeyRotated, KeyRotatedEvent{Reason: "RotateAccessKey"})
+	return nil
+}
+
+// NewFromConnectionStringWithRotation creates a client from the connection string stored in the
+// file at connStrFilePath, e.g. by a secret manager, and rotates its access key whenever the file
+// changes until ctx is done or the client is closed. azClientId and opts are applied as for
+// [New]. On Linux the file is watched through inotify, elsewhere it is polled every 10 seconds on
+// the clock of [WithTimeSource]. A missing file is skipped silently, secret managers may replace
+// it by deleting it first.
+//
+// NOTE: endpoint changes are not applied, create a new client for a different ACS resource
+func NewFromConnectionStringWithRotation(
+	ctx context.Context,
+	connStrFilePath string,
+	azClientId string,
+	opts ...ClientOption,
+) (CommunicationIdentityClient, error) {
+	content, err := os.ReadFile(connStrFilePath)
+	if err != nil {
+		return CommunicationIdentityClient{}, fmt.Errorf("failed to read connection string: %w", err)
+	}
+	endpoint, accessKey, err := parseConnectionString(string(content))
+	if err != nil {
+		return CommunicationIdentityClient{}, fmt.Errorf("failed to parse connection string: %w", err)
+	}
+	client, err := New(endpoint, accessKey, azClientId, opts...)
+	if err != nil {
+		return CommunicationIdentityClient{}, err
+	}
+	// the watch stops when ctx is done or the client is closed, whichever happens first
+	watchCtx, cancel := context.WithCancel(ctx)
+	stopWatchOnClose := context.AfterFunc(client.state.backgroundCtx, cancel)
+	changes, err := watchFile(watchCtx, connStrFilePath)
+	if err != nil {
+		changes = pollFile(watchCtx, client.timeSource())
+	}
+	watcher := client.withoutFinalizer()
+	client.goBackground(func(context.Context) {
+		defer stopWatchOnClose()
+		defer cancel()
+		watcher.watchConnectionString(watchCtx, connStrFilePath, content, changes)
+	})
+	return client, nil
+}
+
+// pollFile reports a possible change every connectionStringPollInterval until ctx is done
+func pollFile(ctx context.Context, clock TimeSource) <-chan struct{} {
+	changes := make(chan struct{}, 1)
+	go func() {
+		defer close(changes)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-clock.After(connectionStringPollInterval):
+				notifyChange(changes)
+			}
+		}
+	}()
+	return changes
+}
+
+// notifyChange coalesces changes that are not yet handled into one
+func notifyChange(changes chan<- struct{}) {
+	select {
+	case changes <- struct{}{}:
+	default:
+	}
+}
+
+func (client CommunicationIdentityClient) watchConnectionString(
+	ctx context.Context,
+	path string,
+	last []byte,
+	changes <-chan struct{},
+) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-changes:
+			if !ok {
+				if ctx.Err() != nil {
+					return
+				}
+				client.logger().Warn("failed to watch ACS connection string, polling instead", "path", path)
+				changes = pollFile(ctx, client.timeSource())
+				continue
+			}
+		}
+
+		content, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			client.logger().Warn("failed to read rotated ACS connection string", "path", path, "error", err)
+			continue
+		}
+		if bytes.Equal(content, last) {
+			continue
+		}
+		last = content
+
+		endpoint, accessKey, err := parseConnectionString(string(content))
+		if err != nil {
+			client.logger().Warn("failed to parse rotated ACS connection string", "path", path, "error", err)
+			continue
+		}
+		if endpoint.String() != client.acsEndpoint.String() {
+			client.logger().Warn("ignoring rotated ACS connection string of another endpoint", "path", path)
+			continue
+		}
+		if err := client.RotateAccessKey(accessKey); err != nil {
+			client.logger().Warn("failed to apply rotated ACS access key", "path", path, "error", err)
+			continue
+		}
+		client.logger().Info("rotated ACS access key", "path", path)
+	}
+}
@@ -0,0 +1,99 @@
+package communicationidentity
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fires timers only when told to
+type manualTimeSource struct {
+	fire chan time.Time
+}
+
+func (clock manualTimeSource) Now() time.Time { return time.Now() }
+
+func (clock manualTimeSource) After(time.Duration) <-chan time.Time { return clock.fire }
+
+func TestPollFileReportsChanges(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	clock := manualTimeSource{fire: make(chan time.Time)}
+	changes := pollFile(ctx, clock)
+
+	clock.fire <- time.Now()
+	if _, ok := <-changes; !ok {
+		t.Fatal("expected a change after the poll interval")
+	}
+	cancel()
+	for range changes {
+	}
+}
+
+func TestWatchConnectionStringIgnoresOtherEndpoints(t *testing.T) {
+	endpoint, _ := url.Parse("https://example.communication.azure.com")
+	client, err := New(endpoint, "b2xk", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "connection-string")
+	content := []byte("endpoint=https://other.communication.azure.com;accesskey=bmV3")
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changes := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		client.watchConnectionString(ctx, path, nil, changes)
+	}()
+	// the second send only completes once the first change was handled
+	changes <- struct{}{}
+	changes <- struct{}{}
+	cancel()
+	<-done
+
+	if client.state.rotatedKey.Load() != nil {
+		t.Error("expected the access key of another endpoint not to be applied")
+	}
+}
+
+func TestCloseStopsConnectionStringRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "connection-string")
+	write := func(accessKey string) {
+		content := "endpoint=https://example.communication.azure.com;accesskey=" + accessKey
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("b2xk") // "old"
+	clock := manualTimeSource{fire: make(chan time.Time)}
+	client, err := NewFromConnectionStringWithRotation(
+		context.Background(), path, "", WithTimeSource(clock),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// returns only once the watch stopped
+	if err := client.Close(); err != nil {
+		t.Fatal(err)
+	}
+	write("bmV3") // "new"
+	select {
+	case clock.fire <- time.Now():
+		t.Error("expected no poll after Close")
+	case <-time.After(10 * time.Millisecond):
+	}
+	if client.state.rotatedKey.Load() != nil {
+		t.Error("expected no key rotation after Close")
+	}
+	if err := client.RotateAccessKey("bmV3"); !errors.Is(err, ErrClientClosed) {
+		t.Errorf("expected ErrClientClosed, got %v", err)
+	}
+}
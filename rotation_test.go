@@ -0,0 +1,82 @@
+package communicationidentity_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+	"github.com/jls-ch/azure-communication-identity-go/communicationidentitytest"
+)
+
+func TestRotateAccessKey(t *testing.T) {
+	client := newTestClient(t, rotatedKeyHandler(new(atomic.Int32)), ci.WithSigner(keySigner{}))
+	ctx := context.Background()
+	if _, err := client.CreateCommunicationIdentity(ctx, []string{"chat"}, nil); err == nil {
+		t.Fatal("expected the initial key to be rejected")
+	}
+	if err := client.RotateAccessKey("bmV3"); err != nil { // "new"
+		t.Fatal(err)
+	}
+	if _, err := client.CreateCommunicationIdentity(ctx, []string{"chat"}, nil); err != nil {
+		t.Errorf("expected the rotated key to be used, got %v", err)
+	}
+	if err := client.RotateAccessKey("not base64!"); err == nil {
+		t.Error("expected invalid key to be rejected")
+	}
+}
+
+func TestNewFromConnectionStringWithRotation(t *testing.T) {
+	server := httptest.NewServer(rotatedKeyHandler(new(atomic.Int32)))
+	t.Cleanup(server.Close)
+	path := filepath.Join(t.TempDir(), "connection-string")
+	write := func(accessKey string) {
+		content := "endpoint=" + server.URL + ";accesskey=" + accessKey
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("b2xk") // "old"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	clock := communicationidentitytest.NewMockClock(time.Now())
+	client, err := ci.NewFromConnectionStringWithRotation(ctx, path, "",
+		ci.WithSigner(keySigner{}),
+		ci.WithTimeSource(clock),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a missing file during rotation keeps the current key
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Minute)
+	write("bmV3") // "new"
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		clock.Advance(time.Minute)
+		_, err := client.CreateCommunicationIdentity(ctx, []string{"chat"}, nil)
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("rotated key was not picked up: %v", err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestNewFromConnectionStringWithRotationMissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing")
+	if _, err := ci.NewFromConnectionStringWithRotation(context.Background(), path, ""); err == nil {
+		t.Error("expected missing initial file to fail")
+	}
+}
@@ -0,0 +1,241 @@
+package communicationidentity
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// creators remembered by a CommunicationIdentityClientProxy, least recently used ones are dropped
+const maxRoutedIdentities = 10000
+
+// Routes the calls of a [CommunicationIdentityClientProxy] whose scopes match to Client
+type RoutingRule struct {
+	Matcher func(scopes []Scope) bool
+	Client  IdentityClient
+}
+
+// CommunicationIdentityClientProxy routes calls to different clients, e.g. to bill chat and VoIP
+// identities to separate ACS resources, and implements [IdentityClient] itself. Teams user token
+// exchanges carry no scopes and are always sent to the fallback client.
+//
+// The client that created an identity is remembered for the 10000 most recently used identities,
+// calls for other identities are sent to all clients.
+type CommunicationIdentityClientProxy struct {
+	rules    []RoutingRule
+	fallback IdentityClient
+
+	mu sync.Mutex
+	// the client that created an identity, for deletes and revocations
+	creators map[string]*list.Element
+	// routedIdentity values, most recently used first
+	order *list.List
+}
+
+type routedIdentity struct {
+	identityID string
+	creator    IdentityClient
+}
+
+var _ IdentityClient = (*CommunicationIdentityClientProxy)(nil)
+
+// NewRoutingProxy creates a proxy evaluating rules in order, calls are sent to the client of the
+// first matching rule or to fallback if no rule matches. fallback may be nil, in which case calls
+// matching no rule fail.
+func NewRoutingProxy(
+	rules []RoutingRule,
+	fallback IdentityClient,
+) *CommunicationIdentityClientProxy {
+	return &CommunicationIdentityClientProxy{
+		rules:    rules,
+		fallback: fallback,
+		creators: map[string]*list.Element{},
+		order:    list.New(),
+	}
+}
+
+func (proxy *CommunicationIdentityClientProxy) rememberCreator(
+	identityID string,
+	creator IdentityClient,
+) {
+	proxy.mu.Lock()
+	defer proxy.mu.Unlock()
+	if element, found := proxy.creators[identityID]; found {
+		proxy.order.Remove(element)
+	}
+	proxy.creators[identityID] = proxy.order.PushFront(&routedIdentity{identityID, creator})
+	if proxy.order.Len() > maxRoutedIdentities {
+		oldest := proxy.order.Remove(proxy.order.Back()).(*routedIdentity)
+		delete(proxy.creators, oldest.identityID)
+	}
+}
+
+func (proxy *CommunicationIdentityClientProxy) creator(identityID string) (IdentityClient, bool) {
+	proxy.mu.Lock()
+	defer proxy.mu.Unlock()
+	element, found := proxy.creators[identityID]
+	if !found {
+		return nil, false
+	}
+	proxy.order.MoveToFront(element)
+	return element.Value.(*routedIdentity).creator, true
+}
+
+func (proxy *CommunicationIdentityClientProxy) forgetCreator(identityID string) {
+	proxy.mu.Lock()
+	defer proxy.mu.Unlock()
+	if element, found := proxy.creators[identityID]; found {
+		proxy.order.Remove(element)
+		delete(proxy.creators, identityID)
+	}
+}
+
+func (proxy *CommunicationIdentityClientProxy) route(scopes []Scope) (IdentityClient, error) {
+	for _, rule := range proxy.rules {
+		if rule.Matcher != nil && rule.Matcher(scopes) {
+			return rule.Client, nil
+		}
+	}
+	if proxy.fallback == nil {
+		return nil, fmt.Errorf("no routing rule matches scopes %v and no fallback is set", scopes)
+	}
+	return proxy.fallback, nil
+}
+
+// TokenForTeamsUser is sent to the fallback client
+func (proxy *CommunicationIdentityClientProxy) TokenForTeamsUser(
+	ctx context.Context,
+	userOid string,
+	teamsScopeMSALToken string,
+	opts ...CallOption,
+) (CommunicationIdentityAccessToken, error) {
+	if proxy.fallback == nil {
+		return CommunicationIdentityAccessToken{},
+			fmt.Errorf("teams user token exchanges require a fallback client")
+	}
+	return proxy.fallback.TokenForTeamsUser(ctx, userOid, teamsScopeMSALToken, opts...)
+}
+
+// CreateCommunicationIdentity is sent to the client of the first rule matching scope, the client
+// is remembered for deletes and revocations of the created identity
+func (proxy *CommunicationIdentityClientProxy) CreateCommunicationIdentity(
+	ctx context.Context,
+	scope []string,
+	expireInMinutes *int32,
+	opts ...CallOption,
+) (CommunicationIdentityAccessTokenResult, error) {
+	scopes := make([]Scope, len(scope))
+	for i, s := range scope {
+		scopes[i] = Scope(s)
+	}
+	client, err := proxy.route(scopes)
+	if err != nil {
+		return CommunicationIdentityAccessTokenResult{}, err
+	}
+	result, err := client.CreateCommunicationIdentity(ctx, scope, expireInMinutes, opts...)
+	if err != nil {
+		return result, err
+	}
+	proxy.rememberCreator(result.Identity.ID, client)
+	return result, nil
+}
+
+// IssueAccessToken is sent to the client that created the identity. For identities not created
+// through the proxy the clients are tried in order until one succeeds.
+func (proxy *CommunicationIdentityClientProxy) IssueAccessToken(
+	ctx context.Context,
+	identityID string,
+	scopes []string,
+	expireInMinutes *int32,
+	opts ...CallOption,
+) (CommunicationIdentityAccessToken, error) {
+	if creator, known := proxy.creator(identityID); known {
+		return creator.IssueAccessToken(ctx, identityID, scopes, expireInMinutes, opts...)
+	}
+
+	var errs []error
+	for _, client := range proxy.clients() {
+		token, err := client.IssueAccessToken(ctx, identityID, scopes, expireInMinutes, opts...)
+		if err == nil {
+			return token, nil
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) == 0 {
+		return CommunicationIdentityAccessToken{},
+			fmt.Errorf("no client to route identity %q to", identityID)
+	}
+	return CommunicationIdentityAccessToken{}, errors.Join(errs...)
+}
+
+// DeleteCommunicationIdentity is sent to the client that created the identity. Identities not
+// created through the proxy are deleted on all clients, which succeeds if any client succeeds.
+func (proxy *CommunicationIdentityClientProxy) DeleteCommunicationIdentity(
+	ctx context.Context,
+	identityID string,
+	opts ...CallOption,
+) error {
+	err := proxy.forIdentity(identityID, func(client IdentityClient) error {
+		return client.DeleteCommunicationIdentity(ctx, identityID, opts...)
+	})
+	if err == nil {
+		proxy.forgetCreator(identityID)
+	}
+	return err
+}
+
+// RevokeAccessTokens is sent to the client that created the identity. For identities not created
+// through the proxy the tokens are revoked on all clients, which succeeds if any client succeeds.
+func (proxy *CommunicationIdentityClientProxy) RevokeAccessTokens(
+	ctx context.Context,
+	identityID string,
+	opts ...CallOption,
+) error {
+	return proxy.forIdentity(identityID, func(client IdentityClient) error {
+		return client.RevokeAccessTokens(ctx, identityID, opts...)
+	})
+}
+
+// forIdentity calls call with the creator of identityID, or with every client if it is unknown
+func (proxy *CommunicationIdentityClientProxy) forIdentity(
+	identityID string,
+	call func(IdentityClient) error,
+) error {
+	if creator, known := proxy.creator(identityID); known {
+		return call(creator)
+	}
+
+	var errs []error
+	succeeded := false
+	for _, client := range proxy.clients() {
+		if err := call(client); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		succeeded = true
+	}
+	switch {
+	case succeeded:
+		return nil
+	case len(errs) == 0:
+		return fmt.Errorf("no client to route identity %q to", identityID)
+	default:
+		return errors.Join(errs...)
+	}
+}
+
+// clients returns the clients of all rules and the fallback
+func (proxy *CommunicationIdentityClientProxy) clients() []IdentityClient {
+	var clients []IdentityClient
+	for _, rule := range proxy.rules {
+		if rule.Client != nil {
+			clients = append(clients, rule.Client)
+		}
+	}
+	if proxy.fallback != nil {
+		clients = append(clients, proxy.fallback)
+	}
+	return clients
+}
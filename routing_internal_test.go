@@ -0,0 +1,28 @@
+package communicationidentity
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRoutingProxyBoundsRememberedCreators(t *testing.T) {
+	proxy := NewRoutingProxy(nil, nil)
+	for i := range maxRoutedIdentities {
+		proxy.rememberCreator(fmt.Sprint(i), nil)
+	}
+	// using the oldest identity keeps it
+	if _, known := proxy.creator("0"); !known {
+		t.Fatal("expected the creator to be remembered")
+	}
+	proxy.rememberCreator("new", nil)
+
+	if len(proxy.creators) != maxRoutedIdentities || proxy.order.Len() != maxRoutedIdentities {
+		t.Errorf("expected %d creators, got %d", maxRoutedIdentities, len(proxy.creators))
+	}
+	if _, known := proxy.creator("1"); known {
+		t.Error("expected the least recently used creator to be dropped")
+	}
+	if _, known := proxy.creator("0"); !known {
+		t.Error("expected the recently used creator to be kept")
+	}
+}
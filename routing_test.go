@@ -0,0 +1,122 @@
+package communicationidentity_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+)
+
+// creates identities named after the resource, records deletes and revocations in calls
+type resourceClient struct {
+	ci.IdentityClient
+	resource string
+	calls    *[]string
+}
+
+func (client resourceClient) CreateCommunicationIdentity(
+	context.Context,
+	[]string,
+	*int32,
+	...ci.CallOption,
+) (ci.CommunicationIdentityAccessTokenResult, error) {
+	return ci.CommunicationIdentityAccessTokenResult{
+		Identity: ci.CommunicationIdentity{ID: client.resource + "-identity"},
+	}, nil
+}
+
+func (client resourceClient) DeleteCommunicationIdentity(
+	_ context.Context,
+	id string,
+	_ ...ci.CallOption,
+) error {
+	*client.calls = append(*client.calls, "delete "+client.resource+" "+id)
+	if id == "unknown" && client.resource != "voip" {
+		return errors.New("not found")
+	}
+	return nil
+}
+
+func (client resourceClient) RevokeAccessTokens(
+	_ context.Context,
+	id string,
+	_ ...ci.CallOption,
+) error {
+	*client.calls = append(*client.calls, "revoke "+client.resource+" "+id)
+	return nil
+}
+
+func newTestRoutingProxy(calls *[]string) *ci.CommunicationIdentityClientProxy {
+	return ci.NewRoutingProxy(
+		[]ci.RoutingRule{{
+			Matcher: func(scopes []ci.Scope) bool { return slices.Contains(scopes, ci.ScopeVoIP) },
+			Client:  resourceClient{resource: "voip", calls: calls},
+		}},
+		resourceClient{resource: "chat", calls: calls},
+	)
+}
+
+func TestRoutingProxyRoutesByScopes(t *testing.T) {
+	proxy := newTestRoutingProxy(new([]string))
+	ctx := context.Background()
+
+	for scopes, want := range map[string]string{
+		"voip": "voip-identity",
+		"chat": "chat-identity",
+	} {
+		result, err := proxy.CreateCommunicationIdentity(ctx, []string{scopes}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Identity.ID != want {
+			t.Errorf("expected %s scope to create %s, got %s", scopes, want, result.Identity.ID)
+		}
+	}
+}
+
+func TestRoutingProxyRoutesLifecycleToCreator(t *testing.T) {
+	var calls []string
+	proxy := newTestRoutingProxy(&calls)
+	ctx := context.Background()
+
+	if _, err := proxy.CreateCommunicationIdentity(ctx, []string{"voip"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := proxy.RevokeAccessTokens(ctx, "voip-identity"); err != nil {
+		t.Fatal(err)
+	}
+	if err := proxy.DeleteCommunicationIdentity(ctx, "voip-identity"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"revoke voip voip-identity", "delete voip voip-identity"}
+	if !slices.Equal(calls, want) {
+		t.Errorf("expected calls %v, got %v", want, calls)
+	}
+}
+
+func TestRoutingProxyBroadcastsUnknownIdentities(t *testing.T) {
+	var calls []string
+	proxy := newTestRoutingProxy(&calls)
+
+	if err := proxy.DeleteCommunicationIdentity(context.Background(), "unknown"); err != nil {
+		t.Fatalf("expected delete to succeed on one client, got: %v", err)
+	}
+	want := []string{"delete voip unknown", "delete chat unknown"}
+	if !slices.Equal(calls, want) {
+		t.Errorf("expected calls %v, got %v", want, calls)
+	}
+}
+
+func TestRoutingProxyWithoutFallback(t *testing.T) {
+	proxy := ci.NewRoutingProxy(nil, nil)
+	if _, err := proxy.CreateCommunicationIdentity(
+		context.Background(), []string{"chat"}, nil,
+	); err == nil {
+		t.Error("expected error without matching rule and fallback")
+	}
+	if _, err := proxy.TokenForTeamsUser(context.Background(), "oid", "msal"); err == nil {
+		t.Error("expected error for teams exchange without fallback")
+	}
+}
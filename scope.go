@@ -0,0 +1,96 @@
+package communicationidentity
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Scope of an ACS access token, see:
+// https://learn.microsoft.com/en-us/azure/communication-services/concepts/identity-model#access-tokens
+type Scope string
+
+const (
+	ScopeChat            Scope = "chat"
+	ScopeChatJoin        Scope = "chat.join"
+	ScopeChatJoinLimited Scope = "chat.join.limited"
+	ScopeVoIP            Scope = "voip"
+	ScopeVoIPJoin        Scope = "voip.join"
+)
+
+func scopeStrings(scopes []Scope) []string {
+	out := make([]string, len(scopes))
+	for i, scope := range scopes {
+		out[i] = string(scope)
+	}
+	return out
+}
+
+// scopeKey is an order and duplicate independent representation of a scope set
+func scopeKey(scopes []string) string {
+	sorted := slices.Clone(scopes)
+	slices.Sort(sorted)
+	return strings.Join(slices.Compact(sorted), ",")
+}
+
+// MaximumScopes returns all scopes supported by ACS
+func MaximumScopes() []Scope {
+	return []Scope{ScopeChat, ScopeChatJoin, ScopeChatJoinLimited, ScopeVoIP, ScopeVoIPJoin}
+}
+
+// ValidateScopes returns a [*ValidationError] for the first scope not supported by ACS, e.g. a
+// misspelled one. An empty list is valid.
+func ValidateScopes(scopes []string) error {
+	for i, scope := range scopes {
+		if !slices.Contains(MaximumScopes(), Scope(scope)) {
+			return &ValidationError{
+				Field: fmt.Sprintf("scopes[%d]", i),
+				Value: scope,
+				Err:   fmt.Errorf("unknown scope, supported are %v", MaximumScopes()),
+			}
+		}
+	}
+	return nil
+}
+
+// Set operations on scope lists, e.g. to compute the scopes available in a step of an
+// authorization flow. All results are free of duplicates, keep the order of first appearance and
+// are nil if empty. The zero value is ready to use.
+type ScopeMatrix struct{}
+
+// Intersection returns the scopes contained in both a and b
+func (ScopeMatrix) Intersection(a, b []Scope) []Scope {
+	return filterScopes(a, func(scope Scope) bool { return slices.Contains(b, scope) })
+}
+
+// Union returns the scopes contained in a or b
+func (ScopeMatrix) Union(a, b []Scope) []Scope {
+	return filterScopes(slices.Concat(a, b), func(Scope) bool { return true })
+}
+
+// Difference returns the scopes contained in a but not in b
+func (ScopeMatrix) Difference(a, b []Scope) []Scope {
+	return filterScopes(a, func(scope Scope) bool { return !slices.Contains(b, scope) })
+}
+
+// IsSubsetOf reports whether every scope of sub is contained in super, the empty set is a
+// subset of every set
+func (ScopeMatrix) IsSubsetOf(sub, super []Scope) bool {
+	for _, scope := range sub {
+		if !slices.Contains(super, scope) {
+			return false
+		}
+	}
+	return true
+}
+
+// scope lists are tiny, linear lookups beat allocating sets
+func filterScopes(scopes []Scope, keep func(Scope) bool) []Scope {
+	var out []Scope
+	for _, scope := range scopes {
+		if keep(scope) && !slices.Contains(out, scope) {
+			out = append(out, scope)
+		}
+	}
+	return out
+}
@@ -0,0 +1,162 @@
+package communicationidentity_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+)
+
+func TestScopeMatrix(t *testing.T) {
+	var matrix ci.ScopeMatrix
+	chat, voip, join := ci.ScopeChat, ci.ScopeVoIP, ci.ScopeChatJoin
+	set := func(scopes ...ci.Scope) []ci.Scope { return scopes }
+
+	cases := []struct {
+		name string
+		got  []ci.Scope
+		want []ci.Scope
+	}{
+		{"intersection", matrix.Intersection(set(chat, voip), set(voip, join)), set(voip)},
+		{"intersection nil", matrix.Intersection(nil, set(chat)), nil},
+		{"intersection disjoint", matrix.Intersection(set(chat), set(voip)), nil},
+		{"intersection duplicates", matrix.Intersection(set(chat, chat), set(chat)), set(chat)},
+		{"union", matrix.Union(set(chat), set(voip, chat)), set(chat, voip)},
+		{"union nil", matrix.Union(nil, nil), nil},
+		{"union empty", matrix.Union(set(), set(voip)), set(voip)},
+		{"union duplicates", matrix.Union(set(chat, chat), nil), set(chat)},
+		{"difference", matrix.Difference(set(chat, voip), set(voip)), set(chat)},
+		{"difference nil", matrix.Difference(set(chat), nil), set(chat)},
+		{"difference all", matrix.Difference(set(chat), set(chat)), nil},
+		{"difference duplicates", matrix.Difference(set(chat, chat, voip), set(voip)), set(chat)},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if !slices.Equal(c.got, c.want) {
+				t.Errorf("expected %v, got %v", c.want, c.got)
+			}
+		})
+	}
+}
+
+func TestScopeMatrixIsSubsetOf(t *testing.T) {
+	var matrix ci.ScopeMatrix
+	cases := []struct {
+		name       string
+		sub, super []ci.Scope
+		want       bool
+	}{
+		{"nil of nil", nil, nil, true},
+		{"empty of any", []ci.Scope{}, []ci.Scope{ci.ScopeChat}, true},
+		{"subset", []ci.Scope{ci.ScopeChat}, []ci.Scope{ci.ScopeChat, ci.ScopeVoIP}, true},
+		{"duplicates", []ci.Scope{ci.ScopeChat, ci.ScopeChat}, []ci.Scope{ci.ScopeChat}, true},
+		{"not subset", []ci.Scope{ci.ScopeVoIP}, []ci.Scope{ci.ScopeChat}, false},
+		{"of nil", []ci.Scope{ci.ScopeVoIP}, nil, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := matrix.IsSubsetOf(c.sub, c.super); got != c.want {
+				t.Errorf("expected %v, got %v", c.want, got)
+			}
+		})
+	}
+}
+
+func TestMaximumScopesIsSupersetOfAll(t *testing.T) {
+	var matrix ci.ScopeMatrix
+	all := ci.MaximumScopes()
+	if !matrix.IsSubsetOf([]ci.Scope{ci.ScopeChat, ci.ScopeVoIPJoin}, all) {
+		t.Errorf("expected %v to contain all documented scopes", all)
+	}
+}
+
+func TestValidateScopes(t *testing.T) {
+	tests := []struct {
+		scopes []string
+		field  string
+	}{
+		{nil, ""},
+		{[]string{"chat", "chat.join", "chat.join.limited", "voip", "voip.join"}, ""},
+		{[]string{"chat", "voice"}, "scopes[1]"},
+		{[]string{"Chat"}, "scopes[0]"},
+		{[]string{""}, "scopes[0]"},
+	}
+	for _, test := range tests {
+		err := ci.ValidateScopes(test.scopes)
+		if test.field == "" {
+			if err != nil {
+				t.Errorf("expected %v to be valid, got: %v", test.scopes, err)
+			}
+			continue
+		}
+		var validationErr *ci.ValidationError
+		if !errors.As(err, &validationErr) || validationErr.Field != test.field {
+			t.Errorf("expected a ValidationError for %s of %v, got: %v", test.field, test.scopes, err)
+		}
+	}
+}
+
+func TestUnknownScopesAreRejectedLocally(t *testing.T) {
+	var calls atomic.Int32
+	client := newTestClient(t, createIdentityHandler(&calls))
+	ctx := context.Background()
+
+	var validationErr *ci.ValidationError
+	_, err := client.CreateCommunicationIdentity(ctx, []string{"chat", "voice"}, nil)
+	if !errors.As(err, &validationErr) {
+		t.Errorf("expected a ValidationError from create, got: %v", err)
+	}
+	_, err = client.IssueAccessToken(ctx, testIdentityID, []string{"voice"}, nil)
+	if !errors.As(err, &validationErr) {
+		t.Errorf("expected a ValidationError from issue, got: %v", err)
+	}
+	if calls.Load() != 0 {
+		t.Errorf("expected no requests, got %d", calls.Load())
+	}
+}
+
+func TestEmptyScopesAreRejectedLocally(t *testing.T) {
+	var calls atomic.Int32
+	client := newTestClient(t, createIdentityHandler(&calls))
+	ctx := context.Background()
+
+	var validationErr *ci.ValidationError
+	_, err := client.CreateCommunicationIdentity(ctx, nil, nil)
+	if !errors.As(err, &validationErr) {
+		t.Errorf("expected a ValidationError from create, got: %v", err)
+	}
+	_, err = client.IssueAccessToken(ctx, testIdentityID, []string{}, nil)
+	if !errors.As(err, &validationErr) {
+		t.Errorf("expected a ValidationError from issue, got: %v", err)
+	}
+	if calls.Load() != 0 {
+		t.Errorf("expected no requests, got %d", calls.Load())
+	}
+}
+
+func TestInvalidIdentityIDIsNotServedFromCache(t *testing.T) {
+	cache := ci.NewInMemoryTokenCache(0)
+	client := newTestClient(
+		t,
+		createIdentityHandler(new(atomic.Int32)),
+		ci.WithTokenCache(cache),
+		ci.WithIdentityIDValidator(ci.DefaultIdentityIDValidator()),
+	)
+	cache.Set("cached", cachedResult("someone@example.com", time.Hour))
+
+	var validationErr *ci.ValidationError
+	_, err := client.IssueAccessToken(
+		context.Background(),
+		"someone@example.com",
+		[]string{"chat"},
+		nil,
+		ci.WithTokenCacheKey("cached"),
+	)
+	if !errors.As(err, &validationErr) || validationErr.Field != "identityID" {
+		t.Errorf("expected a ValidationError for the identity ID, got: %v", err)
+	}
+}
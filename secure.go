@@ -0,0 +1,109 @@
+package communicationidentity
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// SecureTokenBuffer holds a token in memory it owns, so it can be overwritten once the token is
+// no longer needed and does not linger in heap dumps, e.g. for FedRAMP High deployments.
+//
+// NOTE: Go strings can not be overwritten, the Token of copies returned by Get survives Zero.
+// Use Bytes to pass the token on without copying it into a string.
+type SecureTokenBuffer struct {
+	mu        sync.Mutex
+	token     []byte
+	expiresOn time.Time
+	zeroed    bool
+}
+
+// NewSecureTokenBuffer copies token into a new buffer, token itself is left untouched
+func NewSecureTokenBuffer(token CommunicationIdentityAccessToken) *SecureTokenBuffer {
+	return &SecureTokenBuffer{
+		token:     []byte(token.raw()),
+		expiresOn: token.ExpiresOn,
+	}
+}
+
+// Get returns a copy of the token, it panics after Zero
+func (buffer *SecureTokenBuffer) Get() CommunicationIdentityAccessToken {
+	buffer.mu.Lock()
+	defer buffer.mu.Unlock()
+	buffer.panicIfZeroedLocked()
+	return CommunicationIdentityAccessToken{
+		Token:     string(buffer.token),
+		ExpiresOn: buffer.expiresOn,
+	}
+}
+
+// Bytes returns the memory of the buffer holding the token, which reads as zero bytes after Zero.
+// The slice must not be modified, it panics after Zero.
+func (buffer *SecureTokenBuffer) Bytes() []byte {
+	buffer.mu.Lock()
+	defer buffer.mu.Unlock()
+	buffer.panicIfZeroedLocked()
+	return buffer.token
+}
+
+// Zero overwrites the token with zero bytes and clears ExpiresOn, zeroing twice is a no-op.
+// Fails for buffers not created by NewSecureTokenBuffer.
+func (buffer *SecureTokenBuffer) Zero() error {
+	buffer.mu.Lock()
+	defer buffer.mu.Unlock()
+	if buffer.zeroed {
+		return nil
+	}
+	if len(buffer.token) == 0 && buffer.expiresOn.IsZero() {
+		return fmt.Errorf("SecureTokenBuffer holds no token")
+	}
+	clear(buffer.token)
+	buffer.expiresOn = time.Time{}
+	buffer.zeroed = true
+	return nil
+}
+
+func (buffer *SecureTokenBuffer) panicIfZeroedLocked() {
+	if buffer.zeroed {
+		panic("communicationidentity: SecureTokenBuffer used after Zero")
+	}
+}
+
+// WithSecureTokenHandling returns all tokens of the client wrapped in a [SecureTokenBuffer]: the
+// Token field is empty and the token is only available through the Secure field, so callers can
+// zero it after use. Every call returns its own buffer. Tokens of the prefetch pool (see
+// PrefetchToken) are kept in buffers as well and zeroed when they are evicted, expire or are
+// flushed without being handed out.
+func WithSecureTokenHandling() ClientOption {
+	return func(options *clientOptions) error {
+		options.secureTokens = true
+		return nil
+	}
+}
+
+// secureToken wraps token in a buffer if the client handles tokens securely, tokens already held
+// by a buffer are returned as is
+func (client CommunicationIdentityClient) secureToken(
+	token CommunicationIdentityAccessToken,
+) CommunicationIdentityAccessToken {
+	if !client.options.secureTokens || token.Secure != nil || token.Token == "" {
+		return token
+	}
+	token.Secure = NewSecureTokenBuffer(token)
+	token.Token = ""
+	return token
+}
+
+func (client CommunicationIdentityClient) secureResult(
+	result CommunicationIdentityAccessTokenResult,
+) CommunicationIdentityAccessTokenResult {
+	result.AccessToken = client.secureToken(result.AccessToken)
+	return result
+}
+
+// zeroResult overwrites the buffer of a token result created with secureResult
+func zeroResult(result CommunicationIdentityAccessTokenResult) {
+	if result.AccessToken.Secure != nil {
+		_ = result.AccessToken.Secure.Zero()
+	}
+}
@@ -0,0 +1,57 @@
+package communicationidentity
+
+import (
+	"testing"
+	"time"
+)
+
+func newSecureTestResult(
+	client CommunicationIdentityClient,
+) CommunicationIdentityAccessTokenResult {
+	return client.secureResult(CommunicationIdentityAccessTokenResult{
+		AccessToken: CommunicationIdentityAccessToken{
+			Token:     "secret-token",
+			ExpiresOn: time.Now().Add(time.Hour),
+		},
+		Identity: CommunicationIdentity{ID: "identity"},
+	})
+}
+
+func TestSecureTokenHandlingZeroesFlushedTokens(t *testing.T) {
+	client, err := New(nil, "c2VjcmV0", "", WithSecureTokenHandling())
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := newSecureTestResult(client)
+	if result.AccessToken.Token != "" || result.AccessToken.Secure == nil {
+		t.Fatalf("expected the token to be held by a buffer, got %+v", result.AccessToken)
+	}
+	memory := result.AccessToken.Secure.Bytes()
+	client.state.prefetched.add(result.Identity.ID, "chat", result)
+
+	if err := client.FlushTokenCache(); err != nil {
+		t.Fatal(err)
+	}
+	if string(memory) != string(make([]byte, len("secret-token"))) {
+		t.Errorf("expected flushed token to be zeroed, got %q", memory)
+	}
+}
+
+func TestSecureTokenHandlingZeroesReplacedTokens(t *testing.T) {
+	client, err := New(nil, "c2VjcmV0", "", WithSecureTokenHandling())
+	if err != nil {
+		t.Fatal(err)
+	}
+	replaced := newSecureTestResult(client)
+	memory := replaced.AccessToken.Secure.Bytes()
+	client.state.prefetched.add(replaced.Identity.ID, "chat", replaced)
+	client.state.prefetched.add(replaced.Identity.ID, "chat", replaced)
+	if string(memory) != "secret-token" {
+		t.Fatalf("expected re-adding the same token to keep it, got %q", memory)
+	}
+
+	client.state.prefetched.add(replaced.Identity.ID, "chat", newSecureTestResult(client))
+	if string(memory) != string(make([]byte, len("secret-token"))) {
+		t.Errorf("expected replaced token to be zeroed, got %q", memory)
+	}
+}
@@ -0,0 +1,85 @@
+package communicationidentity_test
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+)
+
+func TestSecureTokenBuffer(t *testing.T) {
+	buffer := ci.NewSecureTokenBuffer(ci.CommunicationIdentityAccessToken{
+		Token:     "secret-token",
+		ExpiresOn: time.Now().Add(time.Hour),
+	})
+	token := buffer.Get()
+	if token.Token != "secret-token" {
+		t.Fatalf("unexpected token: %q", token.Token)
+	}
+	memory := buffer.Bytes()
+
+	if err := buffer.Zero(); err != nil {
+		t.Fatal(err)
+	}
+	if string(memory) != strings.Repeat("\x00", len("secret-token")) {
+		t.Errorf("expected token memory to be zeroed, got %q", memory)
+	}
+	if token.Token != "secret-token" {
+		t.Errorf("expected the copy returned by Get to be unaffected, got %q", token.Token)
+	}
+	if err := buffer.Zero(); err != nil {
+		t.Errorf("expected zeroing twice to be a no-op, got %v", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected Get to panic after Zero")
+		}
+	}()
+	buffer.Get()
+}
+
+func TestSecureTokenBufferZeroValue(t *testing.T) {
+	var buffer ci.SecureTokenBuffer
+	if err := buffer.Zero(); err == nil {
+		t.Error("expected zero value buffer to be rejected")
+	}
+}
+
+func TestCloneSecureSurvivesZero(t *testing.T) {
+	buffer := ci.NewSecureTokenBuffer(ci.CommunicationIdentityAccessToken{Token: "secret-token"})
+	token := ci.CommunicationIdentityAccessToken{Secure: buffer}
+	clone := token.Clone()
+	secureClone := token.CloneSecure()
+
+	if err := buffer.Zero(); err != nil {
+		t.Fatal(err)
+	}
+	if secureClone.Secure.Get().Token != "secret-token" {
+		t.Errorf("expected secure clone to keep the token, got %q", secureClone.Secure.Get().Token)
+	}
+	if clone.Secure != buffer {
+		t.Error("expected plain clone to share the zeroed buffer")
+	}
+}
+
+func TestWithSecureTokenHandling(t *testing.T) {
+	client := newTestClient(
+		t,
+		createIdentityHandler(new(atomic.Int32)),
+		ci.WithSecureTokenHandling(),
+	)
+	result, err := client.CreateCommunicationIdentity(context.Background(), []string{"chat"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.AccessToken.Token != "" || result.AccessToken.Secure == nil {
+		t.Fatalf("expected the token to be held by a buffer, got %+v", result.AccessToken)
+	}
+	if token := result.AccessToken.Secure.Get(); token.Token == "" || token.ExpiresOn.IsZero() {
+		t.Errorf("expected the issued token in the buffer, got %+v", token)
+	}
+}
@@ -0,0 +1,23 @@
+package communicationidentity
+
+const msSessionAffinityHeader = "X-MS-Session-Affinity"
+
+// WithStickySession sends sessionID as 'X-MS-Session-Affinity' header with every request, asking
+// ACS to route all calls of the client to the same backend. An empty sessionID is replaced by a
+// random UUID kept for the lifetime of the client, see [CommunicationIdentityClient.SessionID].
+//
+// NOTE: this is a hint only, ACS may ignore the header without notice
+func WithStickySession(sessionID string) ClientOption {
+	return func(options *clientOptions) error {
+		if sessionID == "" {
+			sessionID = newUUID()
+		}
+		options.sessionID = sessionID
+		return nil
+	}
+}
+
+// SessionID returns the session ID of [WithStickySession], empty without sticky session
+func (client CommunicationIdentityClient) SessionID() string {
+	return client.options.sessionID
+}
@@ -0,0 +1,46 @@
+package communicationidentity_test
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+)
+
+func TestWithStickySession(t *testing.T) {
+	var received []string
+	handler := createIdentityHandler(new(atomic.Int32))
+	recording := func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header.Get("X-MS-Session-Affinity"))
+		handler(w, r)
+	}
+
+	for name, sessionID := range map[string]string{"explicit": "session-1", "generated": ""} {
+		t.Run(name, func(t *testing.T) {
+			received = nil
+			client := newTestClient(t, recording, ci.WithStickySession(sessionID))
+			if sessionID != "" && client.SessionID() != sessionID {
+				t.Errorf("expected session ID %q, got %q", sessionID, client.SessionID())
+			}
+			for range 2 {
+				if _, err := client.CreateCommunicationIdentity(
+					context.Background(), []string{"chat"}, nil,
+				); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if client.SessionID() == "" || received[0] != client.SessionID() || received[1] != received[0] {
+				t.Errorf("expected session ID %q on every request, got %v", client.SessionID(), received)
+			}
+		})
+	}
+}
+
+func TestWithoutStickySession(t *testing.T) {
+	client := newTestClient(t, createIdentityHandler(new(atomic.Int32)))
+	if client.SessionID() != "" {
+		t.Errorf("expected no session ID, got %q", client.SessionID())
+	}
+}
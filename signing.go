@@ -0,0 +1,124 @@
+package communicationidentity
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"unicode/utf8"
+)
+
+// Computes the signature for the `Authorization` header of requests to ACS.
+// AlgorithmName is used as the scheme of the header, e.g. "HMAC-SHA256".
+type Signer interface {
+	Sign(stringToSign string, key []byte) (string, error)
+	AlgorithmName() string
+}
+
+// Built-in request signing algorithms, see [WithSigningAlgorithm]
+type SigningAlgorithm int
+
+const (
+	// the only algorithm currently accepted by ACS, used by default
+	SigningAlgorithmHMACSHA256 SigningAlgorithm = iota
+	// NOTE: not accepted by ACS as of API version "2025-06-30", reserved for future versions
+	SigningAlgorithmHMACSHA384
+)
+
+func (algorithm SigningAlgorithm) String() string {
+	switch algorithm {
+	case SigningAlgorithmHMACSHA256:
+		return "HMAC-SHA256"
+	case SigningAlgorithmHMACSHA384:
+		return "HMAC-SHA384"
+	default:
+		return fmt.Sprintf("SigningAlgorithm(%d)", int(algorithm))
+	}
+}
+
+func (algorithm SigningAlgorithm) signer() (Signer, error) {
+	switch algorithm {
+	case SigningAlgorithmHMACSHA256:
+		return hmacSigner{algorithm.String(), sha256.New}, nil
+	case SigningAlgorithmHMACSHA384:
+		return hmacSigner{algorithm.String(), sha512.New384}, nil
+	default:
+		return nil, fmt.Errorf("unknown signing algorithm: %v", algorithm)
+	}
+}
+
+type hmacSigner struct {
+	name    string
+	newHash func() hash.Hash
+}
+
+func (signer hmacSigner) AlgorithmName() string {
+	return signer.name
+}
+
+func (signer hmacSigner) Sign(stringToSign string, key []byte) (string, error) {
+	if !utf8.ValidString(stringToSign) {
+		return "", fmt.Errorf("string to sign is not valid utf-8")
+	}
+
+	mac := hmac.New(signer.newHash, key)
+	_, err := mac.Write([]byte(stringToSign))
+	if err != nil {
+		return "", fmt.Errorf("failed to write to MAC: %w", err)
+	}
+	macSum := mac.Sum(nil)
+
+	return base64.StdEncoding.EncodeToString(macSum), nil
+}
+
+// WithSigningAlgorithm selects one of the built-in request signing algorithms,
+// defaults to [SigningAlgorithmHMACSHA256]
+func WithSigningAlgorithm(a SigningAlgorithm) ClientOption {
+	return func(options *clientOptions) error {
+		signer, err := a.signer()
+		if err != nil {
+			return err
+		}
+		options.signer = signer
+		return nil
+	}
+}
+
+// WithSigner replaces the request signing with a custom implementation
+func WithSigner(s Signer) ClientOption {
+	return func(options *clientOptions) error {
+		if s == nil {
+			return fmt.Errorf("signer can not be nil")
+		}
+		options.signer = s
+		return nil
+	}
+}
+
+// SigningHeaderBuilder builds the authentication header of requests to ACS from
+// the signed request components, see [WithCustomSigningHeader]
+type SigningHeaderBuilder func(
+	method, pathAndQuery, date, host, contentHash string,
+) (headerName, headerValue string, err error)
+
+// WithCustomSigningHeader replaces the standard `Authorization` header with the
+// header returned by b, for ACS preview features that use a non-standard format.
+// The `x-ms-date` and `x-ms-content-sha256` headers are still set on the request.
+func WithCustomSigningHeader(b SigningHeaderBuilder) ClientOption {
+	return func(options *clientOptions) error {
+		if b == nil {
+			return fmt.Errorf("signing header builder can not be nil")
+		}
+		options.signingHeaderBuilder = b
+		return nil
+	}
+}
+
+func (client CommunicationIdentityClient) signer() Signer {
+	if client.options.signer == nil {
+		return hmacSigner{SigningAlgorithmHMACSHA256.String(), sha256.New}
+	}
+	return client.options.signer
+}
@@ -0,0 +1,155 @@
+package communicationidentity
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fixedTime is a TimeSource stopped at its value
+type fixedTime time.Time
+
+func (now fixedTime) Now() time.Time { return time.Time(now) }
+
+func (fixedTime) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+func TestBuildSignedRequestUsesSigner(t *testing.T) {
+	endpoint, _ := url.Parse("https://example.communication.azure.com")
+	for _, algorithm := range []SigningAlgorithm{
+		SigningAlgorithmHMACSHA256,
+		SigningAlgorithmHMACSHA384,
+	} {
+		t.Run(algorithm.String(), func(t *testing.T) {
+			client, err := New(endpoint, "c2VjcmV0", "", WithSigningAlgorithm(algorithm))
+			if err != nil {
+				t.Fatal(err)
+			}
+			request, err := client.buildSignedRequest(
+				context.Background(),
+				http.MethodPost,
+				client.buildEndpointURL(createCommunicationIdentityEndpoint, client.apiVersion()),
+				[]byte("{}"),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			authorization := request.Header.Get(msAuthHeader)
+			if !strings.HasPrefix(authorization, algorithm.String()+" ") {
+				t.Errorf("expected %s authorization scheme, got: %s", algorithm, authorization)
+			}
+		})
+	}
+}
+
+func TestBuildSignedDeleteRequest(t *testing.T) {
+	endpoint, _ := url.Parse("https://example.communication.azure.com")
+	now := time.Date(2025, 6, 30, 12, 0, 0, 0, time.UTC)
+	client, err := New(endpoint, "c2VjcmV0", "", WithTimeSource(fixedTime(now)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	identityURL := client.buildIdentityEndpointURL("identity-1", "", client.apiVersion())
+	ctx := context.Background()
+	request, err := client.buildSignedRequest(ctx, http.MethodDelete, identityURL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	date := request.Header.Get(msDateHeader)
+	if date != "Mon, 30 Jun 2025 12:00:00 GMT" {
+		t.Errorf("unexpected date header: %q", date)
+	}
+	emptyHash := sha256.Sum256(nil)
+	contentHash := base64.StdEncoding.EncodeToString(emptyHash[:])
+	if got := request.Header.Get(msContentHashHeader); got != contentHash {
+		t.Errorf("expected the hash of an empty body, got: %q", got)
+	}
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("DELETE\n/identities/identity-1?api-version=" + DefaultAPIVersion + "\n" +
+		date + ";" + endpoint.Host + ";" + contentHash))
+	authorization := "HMAC-SHA256 SignedHeaders=x-ms-date;host;x-ms-content-sha256&Signature=" +
+		base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	if got := request.Header.Get(msAuthHeader); got != authorization {
+		t.Errorf("expected authorization %q, got %q", authorization, got)
+	}
+
+	_, err = client.buildSignedRequest(ctx, http.MethodDelete, identityURL, []byte("{}"))
+	if err == nil {
+		t.Error("expected DELETE requests with a body to be rejected")
+	}
+}
+
+func TestHMACSHA256Signature(t *testing.T) {
+	signer, err := SigningAlgorithmHMACSHA256.signer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := []byte("secret")
+	signature, err := signer.Sign("POST\n/identities\n", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("POST\n/identities\n"))
+	if want := base64.StdEncoding.EncodeToString(mac.Sum(nil)); signature != want {
+		t.Errorf("expected signature %s, got %s", want, signature)
+	}
+}
+
+func TestWithSigningAlgorithmRejectsUnknown(t *testing.T) {
+	endpoint, _ := url.Parse("https://example.communication.azure.com")
+	if _, err := New(endpoint, "", "", WithSigningAlgorithm(SigningAlgorithm(42))); err == nil {
+		t.Error("expected error for unknown signing algorithm")
+	}
+}
+
+func TestWithCustomSigningHeader(t *testing.T) {
+	endpoint, _ := url.Parse("https://example.communication.azure.com")
+	var gotMethod, gotHost string
+	client, err := New(endpoint, "c2VjcmV0", "", WithCustomSigningHeader(
+		func(method, pathAndQuery, date, host, contentHash string) (string, string, error) {
+			gotMethod, gotHost = method, host
+			return "x-ms-preview-auth", "custom " + contentHash, nil
+		},
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	request, err := client.buildSignedRequest(
+		context.Background(),
+		http.MethodPost,
+		client.buildEndpointURL(createCommunicationIdentityEndpoint, client.apiVersion()),
+		[]byte("{}"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if request.Header.Get(msAuthHeader) != "" {
+		t.Errorf("expected no standard authorization header, got: %s", request.Header.Get(msAuthHeader))
+	}
+	contentHash := request.Header.Get(msContentHashHeader)
+	if got := request.Header.Get("x-ms-preview-auth"); got != "custom "+contentHash {
+		t.Errorf("unexpected custom header: %q", got)
+	}
+	if gotMethod != "POST" || gotHost != endpoint.Host {
+		t.Errorf("unexpected builder arguments: %s %s", gotMethod, gotHost)
+	}
+	if request.Header.Get(msDateHeader) == "" {
+		t.Error("expected date header to be set")
+	}
+}
+
+func TestWithCustomSigningHeaderRejectsNil(t *testing.T) {
+	endpoint, _ := url.Parse("https://example.communication.azure.com")
+	if _, err := New(endpoint, "", "", WithCustomSigningHeader(nil)); err == nil {
+		t.Error("expected error for nil signing header builder")
+	}
+}
@@ -0,0 +1,69 @@
+package communicationidentity
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Metrics of the 'Teams user' token exchanges sent to ACS, exchanges answered by a
+// TeamsUserExchangeCache are not counted
+type TeamsExchangeStats struct {
+	TotalExchanges      int64
+	SuccessfulExchanges int64
+	// average over all exchanges including failed ones, zero without exchanges
+	AverageLatencyMs float64
+	// zero without exchanges
+	LastExchangeAt time.Time
+}
+
+// Aggregated metrics of a client and all of its copies
+type ClientStats struct {
+	TeamsExchanges TeamsExchangeStats
+	// number of tokens currently held for CreateCommunicationIdentity, see PrefetchToken
+	PrefetchedTokens int
+}
+
+type teamsExchangeCounters struct {
+	total          atomic.Int64
+	successful     atomic.Int64
+	totalLatencyNs atomic.Int64
+	lastUnixNano   atomic.Int64
+}
+
+func (counters *teamsExchangeCounters) record(start, end time.Time, success bool) {
+	counters.total.Add(1)
+	if success {
+		counters.successful.Add(1)
+	}
+	counters.totalLatencyNs.Add(int64(end.Sub(start)))
+	counters.lastUnixNano.Store(end.UnixNano())
+}
+
+// counters are read independently and may be slightly inconsistent during concurrent exchanges
+func (counters *teamsExchangeCounters) snapshot() TeamsExchangeStats {
+	stats := TeamsExchangeStats{
+		TotalExchanges:      counters.total.Load(),
+		SuccessfulExchanges: counters.successful.Load(),
+	}
+	if stats.TotalExchanges > 0 {
+		latency := time.Duration(counters.totalLatencyNs.Load() / stats.TotalExchanges)
+		stats.AverageLatencyMs = float64(latency) / float64(time.Millisecond)
+	}
+	if last := counters.lastUnixNano.Load(); last != 0 {
+		stats.LastExchangeAt = time.Unix(0, last)
+	}
+	return stats
+}
+
+// TeamsExchangeStats returns the metrics of the 'Teams user' token exchanges
+func (client CommunicationIdentityClient) TeamsExchangeStats() TeamsExchangeStats {
+	return client.state.teamsExchanges.snapshot()
+}
+
+// Stats returns the aggregated metrics of the client
+func (client CommunicationIdentityClient) Stats() ClientStats {
+	return ClientStats{
+		TeamsExchanges:   client.TeamsExchangeStats(),
+		PrefetchedTokens: client.state.prefetched.len(),
+	}
+}
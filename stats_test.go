@@ -0,0 +1,75 @@
+package communicationidentity_test
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+)
+
+func TestTeamsExchangeStats(t *testing.T) {
+	var apiVersion string
+	handler := teamsTokenHandler(&apiVersion)
+	fail := false
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			errorHandler(http.StatusForbidden, "", `{"error":{"code":"Forbidden"}}`)(w, r)
+			return
+		}
+		handler(w, r)
+	})
+	ctx := context.Background()
+
+	if _, err := client.TokenForTeamsUser(ctx, "oid", "msal-token"); err != nil {
+		t.Fatal(err)
+	}
+	fail = true
+	if _, err := client.TokenForTeamsUser(ctx, "oid", "msal-token"); err == nil {
+		t.Fatal("expected exchange to fail")
+	}
+
+	stats := client.Stats().TeamsExchanges
+	if stats.TotalExchanges != 2 || stats.SuccessfulExchanges != 1 {
+		t.Errorf("unexpected counts: %+v", stats)
+	}
+	if stats.LastExchangeAt.IsZero() || time.Since(stats.LastExchangeAt) > time.Minute {
+		t.Errorf("unexpected last exchange time: %v", stats.LastExchangeAt)
+	}
+}
+
+func TestClientStatsPrefetchedTokens(t *testing.T) {
+	client := newTestClient(t, createIdentityHandler(new(atomic.Int32)))
+	if err := client.PrefetchToken(context.Background(), []ci.Scope{ci.ScopeChat}, 2); err != nil {
+		t.Fatal(err)
+	}
+	stats := client.Stats()
+	if stats.PrefetchedTokens != 2 || stats.TeamsExchanges.TotalExchanges != 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+// Both benchmarks run against a local test server and only compare the client side overhead,
+// latency against ACS itself has to be measured with TeamsExchangeStats in production.
+func BenchmarkTokenForTeamsUser(b *testing.B) {
+	var apiVersion string
+	client := newTestClient(b, teamsTokenHandler(&apiVersion))
+	ctx := context.Background()
+	for b.Loop() {
+		if _, err := client.TokenForTeamsUser(ctx, "oid", "msal-token"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCreateCommunicationIdentity(b *testing.B) {
+	client := newTestClient(b, createIdentityHandler(new(atomic.Int32)))
+	ctx := context.Background()
+	for b.Loop() {
+		if _, err := client.CreateCommunicationIdentity(ctx, []string{"chat"}, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
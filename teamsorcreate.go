@@ -0,0 +1,38 @@
+package communicationidentity
+
+import (
+	"context"
+	"fmt"
+)
+
+// Options of [CommunicationIdentityClient.TokenForTeamsUserOrCreate]
+type TokenForTeamsUserOrCreateOptions struct {
+	// exchanges the token of this Teams user if set, UserOID is required then
+	MSALToken string
+	UserOID   string
+	// scopes of a newly created identity, ignored for Teams users
+	Scopes []Scope
+}
+
+// TokenForTeamsUserOrCreate exchanges the MSAL token of a Teams user if one is given, otherwise
+// it creates a new ACS identity with a token. Teams user results have an empty identity.
+func (client CommunicationIdentityClient) TokenForTeamsUserOrCreate(
+	ctx context.Context,
+	options TokenForTeamsUserOrCreateOptions,
+	opts ...CallOption,
+) (CommunicationIdentityAccessTokenResult, error) {
+	if options.MSALToken == "" {
+		return client.CreateCommunicationIdentity(ctx, scopeStrings(options.Scopes), nil, opts...)
+	}
+	if options.UserOID == "" {
+		return CommunicationIdentityAccessTokenResult{}, &ValidationError{
+			Field: "UserOID",
+			Err:   fmt.Errorf("user object id is required to exchange a Teams token"),
+		}
+	}
+	token, err := client.TokenForTeamsUser(ctx, options.UserOID, options.MSALToken, opts...)
+	if err != nil {
+		return CommunicationIdentityAccessTokenResult{}, err
+	}
+	return CommunicationIdentityAccessTokenResult{AccessToken: token}, nil
+}
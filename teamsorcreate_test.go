@@ -0,0 +1,59 @@
+package communicationidentity_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+)
+
+func TestTokenForTeamsUserOrCreate(t *testing.T) {
+	var apiVersion string
+	teams := teamsTokenHandler(&apiVersion)
+	create := createIdentityHandler(new(atomic.Int32))
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/identities" {
+			create(w, r)
+			return
+		}
+		teams(w, r)
+	})
+	ctx := context.Background()
+
+	created, err := client.TokenForTeamsUserOrCreate(ctx, ci.TokenForTeamsUserOrCreateOptions{
+		Scopes: []ci.Scope{ci.ScopeChat},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.Identity.ID != "identity-1" {
+		t.Errorf("expected a created identity, got %+v", created)
+	}
+
+	exchanged, err := client.TokenForTeamsUserOrCreate(ctx, ci.TokenForTeamsUserOrCreateOptions{
+		MSALToken: "msal-token",
+		UserOID:   "oid",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exchanged.AccessToken.Token != "acs-token" || exchanged.Identity.ID != "" {
+		t.Errorf("expected an exchanged token without identity, got %+v", exchanged)
+	}
+}
+
+func TestTokenForTeamsUserOrCreateRequiresUserOID(t *testing.T) {
+	var apiVersion string
+	client := newTestClient(t, teamsTokenHandler(&apiVersion))
+	_, err := client.TokenForTeamsUserOrCreate(
+		context.Background(),
+		ci.TokenForTeamsUserOrCreateOptions{MSALToken: "msal-token"},
+	)
+	var validationErr *ci.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Errorf("expected ValidationError, got %v", err)
+	}
+}
@@ -0,0 +1,145 @@
+package communicationidentity_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+)
+
+func teamsTokenHandler(apiVersion *string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		*apiVersion = r.URL.Query().Get("api-version")
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(ci.CommunicationIdentityAccessToken{
+			Token:     "acs-token",
+			ExpiresOn: time.Now().Add(time.Hour).UTC(),
+		})
+	}
+}
+
+func TestTokenForTeamsUserWithADALUsesStableAPIVersion(t *testing.T) {
+	var apiVersion string
+	client := newTestClient(t, teamsTokenHandler(&apiVersion))
+
+	//nolint:staticcheck // deprecated on purpose, still needs to work
+	token, err := client.TokenForTeamsUserWithADAL(context.Background(), "oid", "adal-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Token != "acs-token" {
+		t.Errorf("unexpected token: %+v", token)
+	}
+	if apiVersion != "2022-06-01" {
+		t.Errorf("expected api-version 2022-06-01, got %q", apiVersion)
+	}
+
+	if _, err := client.TokenForTeamsUser(context.Background(), "oid", "msal-token"); err != nil {
+		t.Fatal(err)
+	}
+	if apiVersion != "2025-06-30" {
+		t.Errorf("expected api-version 2025-06-30 for MSAL tokens, got %q", apiVersion)
+	}
+}
+
+func TestTeamsUserExchangeCache(t *testing.T) {
+	var apiVersion string
+	calls := 0
+	handler := teamsTokenHandler(&apiVersion)
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		handler(w, r)
+	}, ci.WithTeamsUserExchangeCache(ci.NewInMemoryTeamsUserExchangeCache(10)))
+	ctx := context.Background()
+
+	for range 3 {
+		if _, err := client.TokenForTeamsUser(ctx, "oid-1", "msal-token"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected a single exchange for the same user, got %d", calls)
+	}
+
+	if _, err := client.TokenForTeamsUser(ctx, "oid-2", "msal-token"); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Errorf("expected a new exchange for another user, got %d", calls)
+	}
+
+	// knowing the OID is not enough, ACS has to validate another Teams token
+	if _, err := client.TokenForTeamsUser(ctx, "oid-1", "bogus-token"); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 {
+		t.Errorf("expected a new exchange for another Teams token, got %d", calls)
+	}
+}
+
+func TestTeamsUserExchangeCacheSkipsExpiredTokens(t *testing.T) {
+	cache := ci.NewInMemoryTeamsUserExchangeCache(1)
+	expired := ci.CommunicationIdentityAccessToken{ExpiresOn: time.Now().Add(-time.Second)}
+	cache.Set("app", "oid", "teams", expired)
+	if _, found := cache.Get("app", "oid", "teams"); found {
+		t.Error("expected expired token not to be returned")
+	}
+
+	valid := ci.CommunicationIdentityAccessToken{ExpiresOn: time.Now().Add(time.Hour)}
+	cache.Set("app", "oid-1", "teams", valid)
+	cache.Set("app", "oid-2", "teams", valid)
+	if _, found := cache.Get("app", "oid-1", "teams"); found {
+		t.Error("expected oldest token to be evicted from full cache")
+	}
+	if _, found := cache.Get("app", "oid-2", "teams"); !found {
+		t.Error("expected newest token to be cached")
+	}
+	for name, key := range map[string][3]string{
+		"other app":         {"other-app", "oid-2", "teams"},
+		"other Teams token": {"app", "oid-2", "other-teams"},
+	} {
+		if _, found := cache.Get(key[0], key[1], key[2]); found {
+			t.Errorf("%s: expected no token", name)
+		}
+	}
+}
+
+func TestTokenForTeamsUserStream(t *testing.T) {
+	const body = `{"token":"acs-token","expiresOn":"2025-06-30T12:00:00Z"}`
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	})
+
+	var out bytes.Buffer
+	err := client.TokenForTeamsUserStream(context.Background(), "oid", "msal", &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != body {
+		t.Errorf("expected response body to be copied unmodified, got %q", out.String())
+	}
+}
+
+func TestTokenForTeamsUserStreamError(t *testing.T) {
+	client := newTestClient(t, errorHandler(
+		http.StatusUnauthorized,
+		"",
+		`{"error":{"code":"InvalidAccessToken","message":"token is invalid"}}`,
+	))
+
+	var out bytes.Buffer
+	err := client.TokenForTeamsUserStream(context.Background(), "oid", "msal", &out)
+	var identityErr *ci.CommunicationIdentityError
+	if !errors.As(err, &identityErr) || identityErr.ACSError.Code != "InvalidAccessToken" {
+		t.Fatalf("expected CommunicationIdentityError with ACS code, got %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("expected nothing written on error, got %q", out.String())
+	}
+}
@@ -0,0 +1,296 @@
+package communicationidentity
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Claims of an ACS access token (JWT). Timestamps are zero if the claim is not present.
+type CommunicationTokenClaims struct {
+	// the communication identity the token was issued for, without the "8:" prefix
+	SkypeID string
+	// comma separated scopes, e.g. "chat,voip"
+	Scopes           string
+	ResourceID       string
+	ResourceLocation string
+	Region           string
+	Audience         []string
+	IssuedAt         time.Time
+	NotBefore        time.Time
+	ExpiresAt        time.Time
+	// full decoded payload, including claims without a dedicated field
+	Raw map[string]any
+}
+
+type jwtHeader struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ"`
+}
+
+type communicationTokenPayload struct {
+	SkypeID          string          `json:"skypeid"`
+	Scopes           string          `json:"acsScope"`
+	ResourceID       string          `json:"resourceId"`
+	ResourceLocation string          `json:"resourceLocation"`
+	Region           string          `json:"rgn"`
+	Audience         json.RawMessage `json:"aud"`
+	IssuedAt         *int64          `json:"iat"`
+	NotBefore        *int64          `json:"nbf"`
+	ExpiresAt        *int64          `json:"exp"`
+}
+
+type parsedToken struct {
+	header       jwtHeader
+	claims       CommunicationTokenClaims
+	signedPart   string
+	rawSignature string
+}
+
+func parseToken(raw string) (parsedToken, error) {
+	segments := strings.Split(raw, ".")
+	if len(segments) != 3 {
+		return parsedToken{}, fmt.Errorf(
+			"token is not a JWT, expected 3 segments but found %d",
+			len(segments),
+		)
+	}
+	decodeSegment := func(name string, segment string, target any) error {
+		decoded, err := base64.RawURLEncoding.DecodeString(segment)
+		if err != nil {
+			return fmt.Errorf("token %s is not valid base64url: %w", name, err)
+		}
+		if err := json.Unmarshal(decoded, target); err != nil {
+			return fmt.Errorf("token %s is not valid JSON: %w", name, err)
+		}
+		return nil
+	}
+
+	var header jwtHeader
+	if err := decodeSegment("header", segments[0], &header); err != nil {
+		return parsedToken{}, err
+	}
+	var payload communicationTokenPayload
+	if err := decodeSegment("payload", segments[1], &payload); err != nil {
+		return parsedToken{}, err
+	}
+	var rawClaims map[string]any
+	if err := decodeSegment("payload", segments[1], &rawClaims); err != nil {
+		return parsedToken{}, err
+	}
+
+	audience, err := parseAudience(payload.Audience)
+	if err != nil {
+		return parsedToken{}, err
+	}
+	unixTime := func(seconds *int64) time.Time {
+		if seconds == nil {
+			return time.Time{}
+		}
+		return time.Unix(*seconds, 0).UTC()
+	}
+
+	return parsedToken{
+		header: header,
+		claims: CommunicationTokenClaims{
+			SkypeID:          payload.SkypeID,
+			Scopes:           payload.Scopes,
+			ResourceID:       payload.ResourceID,
+			ResourceLocation: payload.ResourceLocation,
+			Region:           payload.Region,
+			Audience:         audience,
+			IssuedAt:         unixTime(payload.IssuedAt),
+			NotBefore:        unixTime(payload.NotBefore),
+			ExpiresAt:        unixTime(payload.ExpiresAt),
+			Raw:              rawClaims,
+		},
+		signedPart:   segments[0] + "." + segments[1],
+		rawSignature: segments[2],
+	}, nil
+}
+
+// the "aud" claim may be a single string or an array of strings
+func parseAudience(raw json.RawMessage) ([]string, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var single string
+	if err := json.Unmarshal(raw, &single); err == nil {
+		return []string{single}, nil
+	}
+	var multiple []string
+	if err := json.Unmarshal(raw, &multiple); err != nil {
+		return nil, fmt.Errorf("token audience claim is neither a string nor a list of strings")
+	}
+	return multiple, nil
+}
+
+// ParseCommunicationAccessToken decodes the claims of an ACS access token.
+//
+// NOTE: does NOT verify the token in any way, use [ValidateToken] for tokens from untrusted sources
+func ParseCommunicationAccessToken(raw string) (CommunicationTokenClaims, error) {
+	token, err := parseToken(raw)
+	if err != nil {
+		return CommunicationTokenClaims{}, err
+	}
+	return token.claims, nil
+}
+
+// Checks performed by [ValidateToken]
+type TokenValidationOptions struct {
+	// if set, the token must contain this value in its "aud" claim
+	ExpectedAudience string
+	// tolerated clock difference when checking "exp" and "nbf"
+	ClockSkewTolerance time.Duration
+	// verify the HMAC signature of "HS256" tokens with AccountKey, other algorithms are rejected.
+	// Only structural and expiry checks are performed if false.
+	VerifySignature bool
+	// decoded ACS access key, required if VerifySignature is set
+	AccountKey []byte
+}
+
+// ValidateToken checks structure, expiry and optionally audience and signature of a raw ACS
+// access token without a round-trip to ACS and returns its claims on success.
+func ValidateToken(raw string, opts TokenValidationOptions) (CommunicationTokenClaims, error) {
+	token, err := parseToken(raw)
+	if err != nil {
+		return CommunicationTokenClaims{}, err
+	}
+	claims := token.claims
+
+	now := time.Now()
+	if claims.ExpiresAt.IsZero() {
+		return CommunicationTokenClaims{}, fmt.Errorf("token has no expiration claim")
+	}
+	if now.After(claims.ExpiresAt.Add(opts.ClockSkewTolerance)) {
+		return CommunicationTokenClaims{}, fmt.Errorf("token expired at %v", claims.ExpiresAt)
+	}
+	if !claims.NotBefore.IsZero() && now.Before(claims.NotBefore.Add(-opts.ClockSkewTolerance)) {
+		return CommunicationTokenClaims{}, fmt.Errorf(
+			"token is not valid before %v",
+			claims.NotBefore,
+		)
+	}
+
+	if opts.ExpectedAudience != "" {
+		found := false
+		for _, audience := range claims.Audience {
+			if audience == opts.ExpectedAudience {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return CommunicationTokenClaims{}, fmt.Errorf(
+				"token audience %v does not contain %q",
+				claims.Audience,
+				opts.ExpectedAudience,
+			)
+		}
+	}
+
+	if opts.VerifySignature {
+		if err := token.verifyHMACSignature(opts.AccountKey); err != nil {
+			return CommunicationTokenClaims{}, fmt.Errorf(
+				"token signature verification failed: %w",
+				err,
+			)
+		}
+	}
+
+	return claims, nil
+}
+
+func (token parsedToken) verifyHMACSignature(key []byte) error {
+	if token.header.Algorithm != "HS256" {
+		return fmt.Errorf("unsupported signing algorithm %q", token.header.Algorithm)
+	}
+	if len(key) == 0 {
+		return fmt.Errorf("no account key given")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(token.rawSignature)
+	if err != nil {
+		return fmt.Errorf("signature is not valid base64url: %w", err)
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(token.signedPart))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return fmt.Errorf("signature does not match")
+	}
+	return nil
+}
+
+// CommunicationIdentityAccessTokenFromJWT reconstructs a [CommunicationIdentityAccessToken] from
+// a raw ACS token, with ExpiresOn taken from its "exp" claim.
+//
+// NOTE: does NOT verify the token, see [ValidateToken]
+func CommunicationIdentityAccessTokenFromJWT(raw string) (CommunicationIdentityAccessToken, error) {
+	claims, err := ParseCommunicationAccessToken(raw)
+	if err != nil {
+		return CommunicationIdentityAccessToken{}, err
+	}
+	if claims.ExpiresAt.IsZero() {
+		return CommunicationIdentityAccessToken{}, fmt.Errorf("token has no expiration claim")
+	}
+	return CommunicationIdentityAccessToken{Token: raw, ExpiresOn: claims.ExpiresAt}, nil
+}
+
+// TokenExpiryCountdown reports how much of the lifetime of t is left, based on its "iat"
+// (issued at) claim and ExpiresOn: percentUsed is 0 right after issuance and 1 at expiry.
+// Returns an error if the token has no "iat" claim or is already expired.
+func TokenExpiryCountdown(
+	t CommunicationIdentityAccessToken,
+) (remaining time.Duration, percentUsed float64, err error) {
+	claims, err := ParseCommunicationAccessToken(t.raw())
+	if err != nil {
+		return 0, 0, err
+	}
+	if claims.IssuedAt.IsZero() {
+		return 0, 0, fmt.Errorf("token has no issued at claim")
+	}
+	total := t.ExpiresOn.Sub(claims.IssuedAt)
+	if total <= 0 {
+		return 0, 0, fmt.Errorf(
+			"token expiry %v is not after its issuance %v",
+			t.ExpiresOn,
+			claims.IssuedAt,
+		)
+	}
+	remaining = time.Until(t.ExpiresOn)
+	if remaining < 0 {
+		return remaining, 1, fmt.Errorf("token expired at %v", t.ExpiresOn)
+	}
+	return remaining, 1 - float64(remaining)/float64(total), nil
+}
+
+// CreateCommunicationIdentityWithClaims creates an identity like
+// [CommunicationIdentityClient.CreateCommunicationIdentity] and parses the claims of the issued
+// token without another request. If only parsing fails, the created identity is still returned
+// along with the error, since it exists in ACS either way.
+//
+// NOTE: does NOT verify the token, see [ValidateToken]
+func (client CommunicationIdentityClient) CreateCommunicationIdentityWithClaims(
+	ctx context.Context,
+	scopes []Scope,
+	expiry *int32,
+	opts ...CallOption,
+) (CommunicationIdentityAccessTokenResult, CommunicationTokenClaims, error) {
+	result, err := client.CreateCommunicationIdentity(ctx, scopeStrings(scopes), expiry, opts...)
+	if err != nil {
+		return CommunicationIdentityAccessTokenResult{}, CommunicationTokenClaims{}, err
+	}
+	claims, err := ParseCommunicationAccessToken(result.AccessToken.raw())
+	if err != nil {
+		return result, CommunicationTokenClaims{}, fmt.Errorf(
+			"failed to parse token of identity %s: %w",
+			result.Identity.ID,
+			err,
+		)
+	}
+	return result, claims, nil
+}
@@ -0,0 +1,260 @@
+package communicationidentity_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+)
+
+func buildTestToken(t *testing.T, alg string, claims map[string]any, key []byte) string {
+	t.Helper()
+	encode := func(v any) string {
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(encoded)
+	}
+	signedPart := encode(map[string]string{"alg": alg, "typ": "JWT"}) + "." + encode(claims)
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(signedPart))
+	return signedPart + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestParseCommunicationAccessToken(t *testing.T) {
+	expiresAt := time.Date(2025, 6, 30, 12, 0, 0, 0, time.UTC)
+	raw := buildTestToken(t, "HS256", map[string]any{
+		"skypeid":  "acs:resource_user",
+		"acsScope": "chat,voip",
+		"exp":      expiresAt.Unix(),
+		"rgn":      "emea",
+	}, []byte("key"))
+
+	claims, err := ci.ParseCommunicationAccessToken(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.SkypeID != "acs:resource_user" || claims.Scopes != "chat,voip" {
+		t.Errorf("unexpected claims: %+v", claims)
+	}
+	if !claims.ExpiresAt.Equal(expiresAt) {
+		t.Errorf("expected expiry %v, got %v", expiresAt, claims.ExpiresAt)
+	}
+	if claims.Raw["rgn"] != "emea" {
+		t.Errorf("expected raw claims to contain region, got %v", claims.Raw)
+	}
+}
+
+func TestValidateToken(t *testing.T) {
+	key := []byte("key")
+	valid := map[string]any{"exp": time.Now().Add(time.Hour).Unix(), "aud": "my-service"}
+	expired := map[string]any{"exp": time.Now().Add(-time.Minute).Unix()}
+
+	cases := []struct {
+		name  string
+		raw   string
+		opts  ci.TokenValidationOptions
+		valid bool
+	}{
+		{"valid", buildTestToken(t, "HS256", valid, key), ci.TokenValidationOptions{}, true},
+		{"malformed", "not-a-jwt", ci.TokenValidationOptions{}, false},
+		{"expired", buildTestToken(t, "HS256", expired, key), ci.TokenValidationOptions{}, false},
+		{
+			"expired within skew",
+			buildTestToken(t, "HS256", expired, key),
+			ci.TokenValidationOptions{ClockSkewTolerance: 5 * time.Minute},
+			true,
+		},
+		{
+			"missing exp",
+			buildTestToken(t, "HS256", map[string]any{}, key),
+			ci.TokenValidationOptions{},
+			false,
+		},
+		{
+			"audience match",
+			buildTestToken(t, "HS256", valid, key),
+			ci.TokenValidationOptions{ExpectedAudience: "my-service"},
+			true,
+		},
+		{
+			"audience mismatch",
+			buildTestToken(t, "HS256", valid, key),
+			ci.TokenValidationOptions{ExpectedAudience: "other-service"},
+			false,
+		},
+		{
+			"signature valid",
+			buildTestToken(t, "HS256", valid, key),
+			ci.TokenValidationOptions{VerifySignature: true, AccountKey: key},
+			true,
+		},
+		{
+			"signature invalid",
+			buildTestToken(t, "HS256", valid, []byte("other-key")),
+			ci.TokenValidationOptions{VerifySignature: true, AccountKey: key},
+			false,
+		},
+		{
+			"unsupported algorithm",
+			buildTestToken(t, "RS256", valid, key),
+			ci.TokenValidationOptions{VerifySignature: true, AccountKey: key},
+			false,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := ci.ValidateToken(c.raw, c.opts)
+			if c.valid && err != nil {
+				t.Errorf("expected token to be valid, got: %v", err)
+			}
+			if !c.valid && err == nil {
+				t.Error("expected token to be rejected")
+			}
+		})
+	}
+}
+
+func TestCommunicationIdentityAccessTokenFromJWT(t *testing.T) {
+	expiresAt := time.Date(2025, 6, 30, 12, 0, 0, 0, time.UTC)
+	raw := buildTestToken(t, "HS256", map[string]any{"exp": expiresAt.Unix()}, nil)
+
+	token, err := ci.CommunicationIdentityAccessTokenFromJWT(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Token != raw || !token.ExpiresOn.Equal(expiresAt) {
+		t.Errorf("unexpected token: %+v", token)
+	}
+
+	for name, raw := range map[string]string{
+		"malformed":   "a.b",
+		"missing exp": buildTestToken(t, "HS256", map[string]any{"iat": 1}, nil),
+	} {
+		if _, err := ci.CommunicationIdentityAccessTokenFromJWT(raw); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestTokenExpiryCountdown(t *testing.T) {
+	issuedAt := time.Now().Add(-45 * time.Minute)
+	expiresOn := issuedAt.Add(time.Hour)
+	raw := buildTestToken(t, "HS256", map[string]any{
+		"iat": issuedAt.Unix(),
+		"exp": expiresOn.Unix(),
+	}, nil)
+
+	remaining, percentUsed, err := ci.TokenExpiryCountdown(
+		ci.CommunicationIdentityAccessToken{Token: raw, ExpiresOn: expiresOn},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if remaining < 14*time.Minute || remaining > 15*time.Minute {
+		t.Errorf("expected about 15 minutes remaining, got %v", remaining)
+	}
+	if percentUsed < 0.74 || percentUsed > 0.76 {
+		t.Errorf("expected about 75%% used, got %v", percentUsed)
+	}
+}
+
+func TestTokenExpiryCountdownErrors(t *testing.T) {
+	expired := time.Now().Add(-time.Minute)
+	cases := map[string]ci.CommunicationIdentityAccessToken{
+		"missing iat": {
+			Token:     buildTestToken(t, "HS256", map[string]any{"exp": 1}, nil),
+			ExpiresOn: time.Now().Add(time.Hour),
+		},
+		"expired": {
+			Token: buildTestToken(t, "HS256", map[string]any{
+				"iat": expired.Add(-time.Hour).Unix(),
+			}, nil),
+			ExpiresOn: expired,
+		},
+		"malformed": {Token: "not-a-jwt", ExpiresOn: time.Now().Add(time.Hour)},
+	}
+	for name, token := range cases {
+		if _, _, err := ci.TokenExpiryCountdown(token); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestCreateCommunicationIdentityWithClaims(t *testing.T) {
+	raw := buildTestToken(t, "HS256", map[string]any{
+		"skypeid":  "acs:resource_user",
+		"acsScope": "chat",
+		"exp":      time.Now().Add(time.Hour).Unix(),
+	}, []byte("key"))
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(ci.CommunicationIdentityAccessTokenResult{
+			AccessToken: ci.CommunicationIdentityAccessToken{Token: raw},
+			Identity:    ci.CommunicationIdentity{ID: "8:acs:resource_user"},
+		})
+	})
+
+	result, claims, err := client.CreateCommunicationIdentityWithClaims(
+		context.Background(),
+		[]ci.Scope{ci.ScopeChat},
+		nil,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.AccessToken.Token != raw {
+		t.Errorf("unexpected token: %q", result.AccessToken.Token)
+	}
+	if claims.SkypeID != "acs:resource_user" || claims.Scopes != "chat" {
+		t.Errorf("unexpected claims: %+v", claims)
+	}
+}
+
+func TestCreateCommunicationIdentityWithClaimsKeepsIdentityOnParseError(t *testing.T) {
+	client := newTestClient(t, createIdentityHandler(new(atomic.Int32)))
+	result, _, err := client.CreateCommunicationIdentityWithClaims(
+		context.Background(),
+		[]ci.Scope{ci.ScopeChat},
+		nil,
+	)
+	if err == nil {
+		t.Fatal("expected error for a token that is no JWT")
+	}
+	if result.Identity.ID != "identity-1" {
+		t.Errorf("expected the created identity to be returned, got %+v", result)
+	}
+}
+
+func TestAccessTokenExpiry(t *testing.T) {
+	valid := ci.CommunicationIdentityAccessToken{ExpiresOn: time.Now().Add(10 * time.Minute)}
+	expired := ci.CommunicationIdentityAccessToken{
+		ExpiresOn: time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	if valid.IsExpired() || !expired.IsExpired() {
+		t.Errorf("expected only the past token to be expired")
+	}
+	if remaining := valid.TimeUntilExpiry(); remaining <= 9*time.Minute ||
+		remaining > 10*time.Minute {
+		t.Errorf("expected about 10 minutes until expiry, got %v", remaining)
+	}
+	if remaining := expired.TimeUntilExpiry(); remaining >= 0 {
+		t.Errorf("expected a negative duration for an expired token, got %v", remaining)
+	}
+	if valid.IsExpiredWithThreshold(5 * time.Minute) {
+		t.Error("expected the token not to expire within 5 minutes")
+	}
+	if !valid.IsExpiredWithThreshold(15 * time.Minute) {
+		t.Error("expected the token to expire within 15 minutes")
+	}
+}
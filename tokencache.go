@@ -0,0 +1,151 @@
+package communicationidentity
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TokenCache stores issued tokens so repeated calls for the same logical user are served without
+// creating a new identity, see [WithTokenCache]. Implementations must be safe for concurrent use
+// and must not return expired tokens.
+type TokenCache interface {
+	Get(key string) (CommunicationIdentityAccessTokenResult, bool)
+	Set(key string, result CommunicationIdentityAccessTokenResult)
+	// Clear drops all cached tokens, see [CommunicationIdentityClient.FlushTokenCache]
+	Clear()
+}
+
+// WithTokenCache serves [CommunicationIdentityClient.CreateCommunicationIdentity] and
+// [CommunicationIdentityClient.IssueAccessToken] from cache while the cached token is valid.
+// Tokens are cached under the key passed with [WithTokenCacheKey], or else under a key derived
+// from the identity ID and the scopes. Creations without [WithTokenCacheKey] are only cached for
+// later token issuances of the created identity, as no identity ID is known beforehand.
+//
+// NOTE: cached tokens are returned regardless of the requested expiry.
+func WithTokenCache(cache TokenCache) ClientOption {
+	return func(options *clientOptions) error {
+		if cache == nil {
+			return fmt.Errorf("token cache can not be nil")
+		}
+		options.tokenCache = cache
+		return nil
+	}
+}
+
+// WithTokenCacheKey looks up and caches the token of the call under key in the cache of
+// [WithTokenCache], e.g. the ID of the application user the identity is created for. Use
+// different keys for different scopes.
+func WithTokenCacheKey(key string) CallOption {
+	return func(options *callOptions) {
+		options.tokenCacheKey = key
+	}
+}
+
+// cacheKey returns the key of a call for identityID, the one of the caller if set
+func (options callOptions) cacheKey(identityID string, scopes []string) string {
+	if options.tokenCacheKey != "" {
+		return options.tokenCacheKey
+	}
+	return identityID + "?" + scopeKey(scopes)
+}
+
+// InMemoryTokenCache is a [TokenCache] backed by a sync.Map, entries expire with the access token
+// they hold. Reads do not block each other, writes of new keys are serialized.
+type InMemoryTokenCache struct {
+	now        func() time.Time
+	maxEntries int
+	entries    sync.Map
+	// guards count and eviction, keeps concurrent Sets from exceeding maxEntries
+	mu    sync.Mutex
+	count atomic.Int64
+}
+
+// NewInMemoryTokenCache creates a cache holding at most maxEntries tokens, a non-positive
+// maxEntries means unbounded. Once full, expired entries are dropped first and then the one
+// whose token expires soonest.
+func NewInMemoryTokenCache(maxEntries int) *InMemoryTokenCache {
+	return &InMemoryTokenCache{now: time.Now, maxEntries: max(maxEntries, 0)}
+}
+
+// Get returns the cached result for key unless its token has expired
+func (cache *InMemoryTokenCache) Get(key string) (CommunicationIdentityAccessTokenResult, bool) {
+	value, found := cache.entries.Load(key)
+	if !found {
+		return CommunicationIdentityAccessTokenResult{}, false
+	}
+	result := value.(CommunicationIdentityAccessTokenResult)
+	if !cache.valid(result) {
+		cache.mu.Lock()
+		cache.deleteLocked(key, value)
+		cache.mu.Unlock()
+		return CommunicationIdentityAccessTokenResult{}, false
+	}
+	return result, true
+}
+
+// Set caches result under key until its token expires, expired results are not cached
+func (cache *InMemoryTokenCache) Set(key string, result CommunicationIdentityAccessTokenResult) {
+	if !cache.valid(result) {
+		return
+	}
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if _, replaced := cache.entries.Swap(key, result); replaced {
+		return
+	}
+	cache.count.Add(1)
+	if cache.maxEntries > 0 && cache.count.Load() > int64(cache.maxEntries) {
+		cache.evictLocked(key)
+	}
+}
+
+// Clear drops all cached tokens
+func (cache *InMemoryTokenCache) Clear() {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	cache.entries.Range(func(key, value any) bool {
+		cache.deleteLocked(key, value)
+		return true
+	})
+}
+
+// Len counts held entries including expired ones not dropped yet
+func (cache *InMemoryTokenCache) Len() int {
+	return int(cache.count.Load())
+}
+
+func (cache *InMemoryTokenCache) valid(result CommunicationIdentityAccessTokenResult) bool {
+	return cache.now().Before(result.AccessToken.ExpiresOn)
+}
+
+// evictLocked drops all expired entries, or the entry expiring soonest other than keep if none
+// has expired
+func (cache *InMemoryTokenCache) evictLocked(keep string) {
+	var victim, victimValue any
+	var victimExpiry time.Time
+	dropped := false
+	cache.entries.Range(func(key, value any) bool {
+		result := value.(CommunicationIdentityAccessTokenResult)
+		switch {
+		case !cache.valid(result):
+			dropped = cache.deleteLocked(key, value) || dropped
+		case key != keep && (victim == nil || result.AccessToken.ExpiresOn.Before(victimExpiry)):
+			victim, victimValue, victimExpiry = key, value, result.AccessToken.ExpiresOn
+		}
+		return true
+	})
+	if !dropped && victim != nil {
+		cache.deleteLocked(victim, victimValue)
+	}
+}
+
+// deleteLocked removes key if it still holds value, a concurrent Set may have replaced it
+func (cache *InMemoryTokenCache) deleteLocked(key, value any) bool {
+	if !cache.entries.CompareAndDelete(key, value) {
+		return false
+	}
+	cache.count.Add(-1)
+	return true
+}
@@ -0,0 +1,162 @@
+package communicationidentity_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+)
+
+func cachedResult(id string, expiresIn time.Duration) ci.CommunicationIdentityAccessTokenResult {
+	return ci.CommunicationIdentityAccessTokenResult{
+		AccessToken: ci.CommunicationIdentityAccessToken{
+			Token:     "token-" + id,
+			ExpiresOn: time.Now().Add(expiresIn),
+		},
+		Identity: ci.CommunicationIdentity{ID: id},
+	}
+}
+
+func TestTokenCacheServesCreationsWithCacheKey(t *testing.T) {
+	var calls atomic.Int32
+	cache := ci.NewInMemoryTokenCache(0)
+	client := newTestClient(t, createIdentityHandler(&calls), ci.WithTokenCache(cache))
+	ctx := context.Background()
+
+	first, err := client.CreateCommunicationIdentity(
+		ctx, []string{"chat"}, nil, ci.WithTokenCacheKey("user-1"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := client.CreateCommunicationIdentity(
+		ctx, []string{"chat"}, nil, ci.WithTokenCacheKey("user-1"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Identity.ID != first.Identity.ID || second.AccessToken.Token != first.AccessToken.Token {
+		t.Errorf("expected the cached identity %+v, got %+v", first, second)
+	}
+	if _, err := client.CreateCommunicationIdentity(
+		ctx, []string{"chat"}, nil, ci.WithTokenCacheKey("user-2"),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CreateCommunicationIdentity(ctx, []string{"chat"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("expected 3 requests for 2 keys and a creation without key, got %d", got)
+	}
+}
+
+func TestTokenCacheServesIssuancesByIdentityID(t *testing.T) {
+	var calls atomic.Int32
+	var path string
+	create, issue := createIdentityHandler(&calls), issueTokenHandler(&path)
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, ":issueAccessToken") {
+			create(w, r)
+			return
+		}
+		calls.Add(1)
+		issue(w, r)
+	}, ci.WithTokenCache(ci.NewInMemoryTokenCache(0)))
+	ctx := context.Background()
+
+	created, err := client.CreateCommunicationIdentity(ctx, []string{"chat", "voip"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := client.IssueAccessToken(ctx, created.Identity.ID, []string{"voip", "chat"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Token != created.AccessToken.Token {
+		t.Errorf("expected the cached token %q, got %q", created.AccessToken.Token, token.Token)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected the issuance to be served from cache, got %d requests", calls.Load())
+	}
+
+	if _, err := client.IssueAccessToken(ctx, created.Identity.ID, []string{"chat"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("expected a request for other scopes, got %d requests", calls.Load())
+	}
+}
+
+func TestWithTokenCacheRejectsNil(t *testing.T) {
+	if _, err := ci.New(nil, testAccessKey, "", ci.WithTokenCache(nil)); err == nil {
+		t.Error("expected an error for a nil token cache")
+	}
+}
+
+func TestInMemoryTokenCacheDropsExpiredTokens(t *testing.T) {
+	cache := ci.NewInMemoryTokenCache(0)
+	cache.Set("expired", cachedResult("expired", -time.Second))
+	if _, found := cache.Get("expired"); found || cache.Len() != 0 {
+		t.Errorf("expected expired tokens not to be cached, got %d entries", cache.Len())
+	}
+
+	cache.Set("valid", cachedResult("valid", time.Hour))
+	result, found := cache.Get("valid")
+	if !found || result.Identity.ID != "valid" {
+		t.Errorf("expected the valid token, got %+v, %v", result, found)
+	}
+	if _, found := cache.Get("unknown"); found {
+		t.Error("expected no token for an unknown key")
+	}
+}
+
+func TestInMemoryTokenCacheEvictsSoonestExpiringToken(t *testing.T) {
+	cache := ci.NewInMemoryTokenCache(2)
+	cache.Set("late", cachedResult("late", 2*time.Hour))
+	cache.Set("soon", cachedResult("soon", time.Hour))
+	cache.Set("latest", cachedResult("latest", 3*time.Hour))
+
+	if cache.Len() != 2 {
+		t.Errorf("expected 2 entries, got %d", cache.Len())
+	}
+	if _, found := cache.Get("soon"); found {
+		t.Error("expected the token expiring soonest to be evicted")
+	}
+	for _, key := range []string{"late", "latest"} {
+		if _, found := cache.Get(key); !found {
+			t.Errorf("expected %q to be cached", key)
+		}
+	}
+
+	cache.Set("late", cachedResult("late", 4*time.Hour))
+	if cache.Len() != 2 {
+		t.Errorf("expected replacing a token not to evict, got %d entries", cache.Len())
+	}
+}
+
+func TestInMemoryTokenCacheConcurrentUse(t *testing.T) {
+	cache := ci.NewInMemoryTokenCache(8)
+	var wg sync.WaitGroup
+	for worker := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 100 {
+				key := fmt.Sprintf("key-%d", (worker*100+i)%16)
+				cache.Set(key, cachedResult(key, time.Hour))
+				cache.Get(key)
+			}
+		}()
+	}
+	wg.Wait()
+	if cache.Len() > 8 {
+		t.Errorf("expected at most 8 entries, got %d", cache.Len())
+	}
+}
@@ -0,0 +1,183 @@
+package communicationidentity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ScopedTokenPool keeps tokens of new identities with the same scopes ready to hand out, e.g.
+// for guest users joining a call. The pool is refilled to its size in the background whenever
+// it drops below its low-water mark, see [NewScopedTokenPool].
+type ScopedTokenPool struct {
+	client   CommunicationIdentityClient
+	ctx      context.Context
+	scopes   []string
+	lowWater int
+
+	mu   sync.Mutex
+	cond *sync.Cond
+	// ring buffer of pooled tokens, count entries starting at head
+	tokens    []CommunicationIdentityAccessToken
+	head      int
+	count     int
+	refilling bool
+	// error of the last refill attempt, nil once a token was issued
+	refillErr error
+}
+
+// NewScopedTokenPool creates a pool of up to size tokens with scopes, each for a new identity,
+// and starts filling it. Once fewer than lowWater tokens are left the pool is refilled, refills
+// stop when ctx is done.
+func NewScopedTokenPool(
+	ctx context.Context,
+	client CommunicationIdentityClient,
+	scopes []Scope,
+	size int,
+	lowWater int,
+) (*ScopedTokenPool, error) {
+	if err := validateScopes("scopes", scopes); err != nil {
+		return nil, err
+	}
+	if size < 1 || lowWater < 0 || lowWater > size {
+		return nil, &ValidationError{
+			Field: "lowWater",
+			Value: fmt.Sprint(lowWater),
+			Err:   fmt.Errorf("size must be positive and lowWater between 0 and size %d", size),
+		}
+	}
+	pool := &ScopedTokenPool{
+		client:   client,
+		ctx:      ctx,
+		scopes:   scopeStrings(scopes),
+		lowWater: lowWater,
+		tokens:   make([]CommunicationIdentityAccessToken, size),
+	}
+	pool.cond = sync.NewCond(&pool.mu)
+	context.AfterFunc(ctx, pool.wakeAll)
+
+	pool.mu.Lock()
+	pool.startRefillLocked()
+	pool.mu.Unlock()
+	return pool, nil
+}
+
+// Get takes a token from the pool. If the pool is empty, Get blocks until a refill or
+// [ScopedTokenPool.Put] provides a token, or until ctx is done. Get never issues tokens itself:
+// failed refills are retried every 10 seconds in the background, and the error of the last
+// failed refill is returned together with the context error.
+func (pool *ScopedTokenPool) Get(ctx context.Context) (CommunicationIdentityAccessToken, error) {
+	stop := context.AfterFunc(ctx, pool.wakeAll)
+	defer stop()
+
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	for {
+		if err := ctx.Err(); err != nil {
+			return CommunicationIdentityAccessToken{}, errors.Join(err, pool.refillErr)
+		}
+		pool.dropExpiredLocked()
+		if pool.count > 0 {
+			token := pool.tokens[pool.head]
+			pool.tokens[pool.head] = CommunicationIdentityAccessToken{}
+			pool.head = (pool.head + 1) % len(pool.tokens)
+			pool.count--
+			if pool.count < pool.lowWater {
+				pool.startRefillLocked()
+			}
+			return token, nil
+		}
+		pool.cond.Wait()
+	}
+}
+
+// Put returns token to the pool, expired tokens and tokens exceeding the size are discarded
+func (pool *ScopedTokenPool) Put(token CommunicationIdentityAccessToken) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	pool.putLocked(token)
+}
+
+// Len returns the number of pooled tokens
+func (pool *ScopedTokenPool) Len() int {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	return pool.count
+}
+
+func (pool *ScopedTokenPool) putLocked(token CommunicationIdentityAccessToken) bool {
+	if pool.count == len(pool.tokens) || !pool.valid(token) {
+		return false
+	}
+	pool.tokens[(pool.head+pool.count)%len(pool.tokens)] = token
+	pool.count++
+	pool.cond.Signal()
+	return true
+}
+
+func (pool *ScopedTokenPool) valid(token CommunicationIdentityAccessToken) bool {
+	return pool.client.timeSource().Now().Before(token.ExpiresOn)
+}
+
+// dropExpiredLocked discards expired tokens at the head, tokens are pooled in issuance order
+func (pool *ScopedTokenPool) dropExpiredLocked() {
+	for pool.count > 0 && !pool.valid(pool.tokens[pool.head]) {
+		if secure := pool.tokens[pool.head].Secure; secure != nil {
+			_ = secure.Zero()
+		}
+		pool.tokens[pool.head] = CommunicationIdentityAccessToken{}
+		pool.head = (pool.head + 1) % len(pool.tokens)
+		pool.count--
+	}
+}
+
+func (pool *ScopedTokenPool) startRefillLocked() {
+	if pool.refilling || pool.ctx.Err() != nil {
+		return
+	}
+	pool.refilling = true
+	go pool.refill()
+}
+
+// refill issues tokens until the pool is full, failures are retried every 10 seconds until the
+// context of the pool is done
+func (pool *ScopedTokenPool) refill() {
+	defer func() {
+		pool.mu.Lock()
+		pool.refilling = false
+		pool.cond.Broadcast()
+		pool.mu.Unlock()
+	}()
+	for {
+		pool.mu.Lock()
+		full := pool.count == len(pool.tokens)
+		pool.mu.Unlock()
+		if full {
+			return
+		}
+
+		result, err := pool.client.CreateCommunicationIdentity(pool.ctx, pool.scopes, nil)
+		pool.mu.Lock()
+		if err == nil && !pool.putLocked(result.AccessToken) && pool.count < len(pool.tokens) {
+			err = fmt.Errorf("ACS issued a token that is already expired")
+		}
+		pool.refillErr = err
+		pool.mu.Unlock()
+		if err == nil {
+			continue
+		}
+
+		select {
+		case <-pool.ctx.Done():
+			return
+		case <-pool.client.timeSource().After(autoRefreshRetryInterval):
+		}
+	}
+}
+
+func (pool *ScopedTokenPool) wakeAll() {
+	pool.mu.Lock()
+	pool.cond.Broadcast()
+	pool.mu.Unlock()
+}
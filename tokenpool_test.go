@@ -0,0 +1,156 @@
+package communicationidentity_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+)
+
+func waitForPoolLen(t *testing.T, pool *ci.ScopedTokenPool, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for pool.Len() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d pooled tokens, got %d", n, pool.Len())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestScopedTokenPoolRefillsBelowLowWater(t *testing.T) {
+	var calls atomic.Int32
+	client := newTestClient(t, createIdentityHandler(&calls))
+	pool, err := ci.NewScopedTokenPool(context.Background(), client, []ci.Scope{ci.ScopeChat}, 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitForPoolLen(t, pool, 3)
+
+	for range 2 {
+		token, err := pool.Get(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token.Token == "" {
+			t.Error("expected a pooled token")
+		}
+	}
+	waitForPoolLen(t, pool, 3)
+	if calls.Load() != 5 {
+		t.Errorf("expected 3 initial and 2 refill requests, got %d", calls.Load())
+	}
+}
+
+func TestScopedTokenPoolPut(t *testing.T) {
+	client := newTestClient(t, createIdentityHandler(new(atomic.Int32)))
+	pool, err := ci.NewScopedTokenPool(context.Background(), client, []ci.Scope{ci.ScopeChat}, 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitForPoolLen(t, pool, 2)
+
+	token, err := pool.Get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool.Put(ci.CommunicationIdentityAccessToken{Token: "expired", ExpiresOn: time.Now()})
+	if pool.Len() != 1 {
+		t.Errorf("expected expired token to be discarded, got %d pooled tokens", pool.Len())
+	}
+	pool.Put(token)
+	pool.Put(token)
+	if pool.Len() != 2 {
+		t.Errorf("expected pool to stay at its size, got %d pooled tokens", pool.Len())
+	}
+}
+
+func TestScopedTokenPoolGetReportsRefillError(t *testing.T) {
+	client := newTestClient(t, errorHandler(
+		http.StatusForbidden,
+		"request-1",
+		`{"error":{"code":"Forbidden","message":"no access"}}`,
+	))
+	poolCtx, stopPool := context.WithCancel(context.Background())
+	t.Cleanup(stopPool)
+	pool, err := ci.NewScopedTokenPool(poolCtx, client, []ci.Scope{ci.ScopeChat}, 2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = pool.Get(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected Get to block until the deadline, got: %v", err)
+	}
+	var identityErr *ci.CommunicationIdentityError
+	if !errors.As(err, &identityErr) || identityErr.StatusCode != http.StatusForbidden {
+		t.Errorf("expected the refill error to be reported, got: %v", err)
+	}
+}
+
+func TestScopedTokenPoolGetBlocksUntilPut(t *testing.T) {
+	release := make(chan struct{})
+	handler := createIdentityHandler(new(atomic.Int32))
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+			handler(w, r)
+		case <-r.Context().Done():
+		}
+	})
+	// registered after the server, so the handler is released before the server is closed
+	t.Cleanup(func() { close(release) })
+	poolCtx, stopPool := context.WithCancel(context.Background())
+	t.Cleanup(stopPool)
+	pool, err := ci.NewScopedTokenPool(poolCtx, client, []ci.Scope{ci.ScopeChat}, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := pool.Get(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded while the refill is in progress, got: %v", err)
+	}
+
+	put := ci.CommunicationIdentityAccessToken{Token: "put", ExpiresOn: time.Now().Add(time.Hour)}
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		pool.Put(put)
+	}()
+	token, err := pool.Get(context.Background())
+	if err != nil || token.Token != "put" {
+		t.Errorf("expected Get to return the token put meanwhile, got: %+v, %v", token, err)
+	}
+}
+
+func TestNewScopedTokenPoolValidation(t *testing.T) {
+	client := newTestClient(t, createIdentityHandler(new(atomic.Int32)))
+	for name, build := range map[string]func() error{
+		"no scopes": func() error {
+			_, err := ci.NewScopedTokenPool(context.Background(), client, nil, 1, 0)
+			return err
+		},
+		"zero size": func() error {
+			_, err := ci.NewScopedTokenPool(context.Background(), client, ci.MaximumScopes(), 0, 0)
+			return err
+		},
+		"low water above size": func() error {
+			_, err := ci.NewScopedTokenPool(context.Background(), client, ci.MaximumScopes(), 1, 2)
+			return err
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var validationErr *ci.ValidationError
+			if err := build(); !errors.As(err, &validationErr) {
+				t.Errorf("expected ValidationError, got: %v", err)
+			}
+		})
+	}
+}
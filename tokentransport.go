@@ -0,0 +1,44 @@
+package communicationidentity
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// ACSTokenTransport authenticates requests to ACS backends like ACS Chat with a communication
+// access token, analogous to the transport of golang.org/x/oauth2. This is unrelated to the HMAC
+// authentication the client uses to obtain tokens.
+type ACSTokenTransport struct {
+	// defaults to http.DefaultTransport
+	Inner http.RoundTripper
+	// called before every request, e.g. a closure around a cached token
+	TokenSource func() (CommunicationIdentityAccessToken, error)
+}
+
+// RoundTrip sends request with an 'Authorization: Bearer' header of the current token,
+// the original request is not modified
+func (transport *ACSTokenTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	closeBody := func() {
+		if request.Body != nil {
+			_ = request.Body.Close()
+		}
+	}
+	if transport.TokenSource == nil {
+		closeBody()
+		return nil, fmt.Errorf("ACSTokenTransport has no TokenSource")
+	}
+	token, err := transport.TokenSource()
+	if err != nil {
+		closeBody()
+		return nil, fmt.Errorf("failed to obtain ACS token: %w", err)
+	}
+
+	authenticated := request.Clone(request.Context())
+	authenticated.Header.Set(msAuthHeader, "Bearer "+token.raw())
+
+	inner := transport.Inner
+	if inner == nil {
+		inner = http.DefaultTransport
+	}
+	return inner.RoundTrip(authenticated)
+}
@@ -0,0 +1,50 @@
+package communicationidentity_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+)
+
+func TestACSTokenTransport(t *testing.T) {
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+	}))
+	t.Cleanup(server.Close)
+
+	client := &http.Client{Transport: &ci.ACSTokenTransport{
+		TokenSource: func() (ci.CommunicationIdentityAccessToken, error) {
+			return ci.CommunicationIdentityAccessToken{Token: "acs-token"}, nil
+		},
+	}}
+	request, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	response, err := client.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = response.Body.Close()
+
+	if authorization != "Bearer acs-token" {
+		t.Errorf("unexpected Authorization header: %q", authorization)
+	}
+	if request.Header.Get("Authorization") != "" {
+		t.Error("expected the original request to be left unmodified")
+	}
+}
+
+func TestACSTokenTransportTokenSourceError(t *testing.T) {
+	errNoToken := errors.New("no token")
+	transport := &ci.ACSTokenTransport{
+		TokenSource: func() (ci.CommunicationIdentityAccessToken, error) {
+			return ci.CommunicationIdentityAccessToken{}, errNoToken
+		},
+	}
+	request, _ := http.NewRequest(http.MethodGet, "http://example.invalid", nil)
+	if _, err := transport.RoundTrip(request); !errors.Is(err, errNoToken) {
+		t.Errorf("expected token source error, got %v", err)
+	}
+}
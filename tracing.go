@@ -0,0 +1,76 @@
+package communicationidentity
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// name of the tracer and meter of the client
+const instrumentationName = "github.com/jls-ch/azure-communication-identity-go"
+
+// WithTracerProvider traces every ACS operation with a span of tp named
+// "communicationidentity.<operation>", e.g. "communicationidentity.CreateCommunicationIdentity",
+// as a child of the span in the context of the call. Spans carry the `http.method`, `http.url`
+// (scheme and host only) and `http.status_code` of the last request sent, failed operations are
+// marked with `error`. Without this option the global provider of [otel.GetTracerProvider] is
+// used, which discards spans unless the application sets one.
+func WithTracerProvider(tp trace.TracerProvider) ClientOption {
+	return func(options *clientOptions) error {
+		if tp == nil {
+			return fmt.Errorf("tracer provider can not be nil")
+		}
+		options.tracerProvider = tp
+		return nil
+	}
+}
+
+func (client CommunicationIdentityClient) tracer() trace.Tracer {
+	provider := client.options.tracerProvider
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	return provider.Tracer(instrumentationName)
+}
+
+// startSpan starts the span of the ACS operation method
+func (client CommunicationIdentityClient) startSpan(
+	ctx context.Context,
+	method string,
+) (context.Context, trace.Span) {
+	return client.tracer().Start(ctx, "communicationidentity."+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+	)
+}
+
+// endSpan ends the span of an ACS operation, marking it as failed if err is set
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		span.SetAttributes(attribute.Bool("error", true))
+	}
+	span.End()
+}
+
+// traceRequest adds the attributes of request and its response to the span of the operation
+func traceRequest(request *http.Request, response *http.Response) {
+	span := trace.SpanFromContext(request.Context())
+	if !span.IsRecording() {
+		return
+	}
+	host := url.URL{Scheme: request.URL.Scheme, Host: request.URL.Host}
+	span.SetAttributes(
+		attribute.String("http.method", request.Method),
+		attribute.String("http.url", host.String()),
+	)
+	if response != nil {
+		span.SetAttributes(attribute.Int("http.status_code", response.StatusCode))
+	}
+}
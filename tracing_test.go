@@ -0,0 +1,87 @@
+package communicationidentity_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+)
+
+func spanAttributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, attr := range span.Attributes() {
+		attrs[attr.Key] = attr.Value
+	}
+	return attrs
+}
+
+func TestWithTracerProvider(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	client := newTestClient(t, createIdentityHandler(new(atomic.Int32)),
+		ci.WithTracerProvider(provider),
+	)
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "parent")
+	result, err := client.CreateCommunicationIdentity(ctx, []string{"chat"}, nil)
+	parent.End()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 || spans[0].Name() != "communicationidentity.CreateCommunicationIdentity" {
+		t.Fatalf("expected the operation span and its parent, got %v", spans)
+	}
+	span := spans[0]
+	if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Error("expected the span to be a child of the span in the context")
+	}
+	attrs := spanAttributes(span)
+	endpoint, _ := url.Parse(attrs["http.url"].AsString())
+	if attrs["http.method"].AsString() != http.MethodPost ||
+		attrs["http.status_code"].AsInt64() != http.StatusCreated ||
+		endpoint == nil || endpoint.Host == "" || endpoint.Path != "" || endpoint.RawQuery != "" {
+		t.Errorf("unexpected span attributes %v", attrs)
+	}
+	if _, found := attrs["error"]; found || span.Status().Code == codes.Error {
+		t.Errorf("expected a successful span for identity %s", result.Identity.ID)
+	}
+}
+
+func TestWithTracerProviderMarksFailures(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	client := newTestClient(t, errorHandler(
+		http.StatusNotFound,
+		"",
+		`{"error":{"code":"IdentityNotFound","message":"unknown"}}`,
+	), ci.WithTracerProvider(provider))
+
+	if err := client.DeleteCommunicationIdentity(context.Background(), testIdentityID); err == nil {
+		t.Fatal("expected the 404 to be returned")
+	}
+	spans := recorder.Ended()
+	if len(spans) != 1 || spans[0].Name() != "communicationidentity.DeleteCommunicationIdentity" {
+		t.Fatalf("expected the span of the delete, got %v", spans)
+	}
+	attrs := spanAttributes(spans[0])
+	if !attrs["error"].AsBool() || attrs["http.status_code"].AsInt64() != http.StatusNotFound ||
+		spans[0].Status().Code != codes.Error {
+		t.Errorf("expected the span to be marked as failed, got %v", attrs)
+	}
+}
+
+func TestWithTracerProviderRejectsNil(t *testing.T) {
+	if _, err := ci.New(nil, testAccessKey, "", ci.WithTracerProvider(nil)); err == nil {
+		t.Error("expected a nil tracer provider to be rejected")
+	}
+}
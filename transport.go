@@ -0,0 +1,46 @@
+package communicationidentity
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// WithBaseTransport sets the transport all requests are sent through, defaults to the transport
+// of the client set with [WithHTTPClient] or [http.DefaultTransport]. Retries, rate limiting and
+// signing run in the client on top of the base transport, so t is never wrapped or replaced.
+func WithBaseTransport(t http.RoundTripper) ClientOption {
+	return func(options *clientOptions) error {
+		if t == nil {
+			return fmt.Errorf("base transport can not be nil")
+		}
+		options.baseTransport = t
+		return nil
+	}
+}
+
+// WithHTTPClient sends all requests through c instead of [http.DefaultClient], e.g. to configure
+// timeouts or TLS. A transport set with [WithBaseTransport] replaces the transport of c in a copy
+// of c, c itself is never modified.
+func WithHTTPClient(c *http.Client) ClientOption {
+	return func(options *clientOptions) error {
+		if c == nil {
+			return fmt.Errorf("http client can not be nil")
+		}
+		options.customHTTPClient = c
+		return nil
+	}
+}
+
+// buildHTTPClient assembles the HTTP client from the transport related options
+func (options *clientOptions) buildHTTPClient() *http.Client {
+	client := options.customHTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if options.baseTransport == nil {
+		return client
+	}
+	layered := *client
+	layered.Transport = options.baseTransport
+	return &layered
+}
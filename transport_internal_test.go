@@ -0,0 +1,67 @@
+package communicationidentity
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+)
+
+type layerTransport struct {
+	name  string
+	inner http.RoundTripper
+	calls *[]string
+}
+
+func (transport layerTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	*transport.calls = append(*transport.calls, transport.name)
+	if transport.inner == nil {
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	}
+	return transport.inner.RoundTrip(request)
+}
+
+func TestWithHTTPClientIsUsedAsIs(t *testing.T) {
+	var calls []string
+	custom := &http.Client{Transport: layerTransport{"custom", nil, &calls}}
+	options, err := applyClientOptions([]ClientOption{WithHTTPClient(custom)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if options.httpClient != custom {
+		t.Error("expected custom client to be used as is without a base transport")
+	}
+}
+
+func TestWithBaseTransportReplacesHTTPClientTransport(t *testing.T) {
+	var calls []string
+	custom := &http.Client{Transport: layerTransport{"custom", nil, &calls}, Timeout: time.Minute}
+	options, err := applyClientOptions([]ClientOption{
+		WithHTTPClient(custom),
+		WithBaseTransport(layerTransport{"base", nil, &calls}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := options.httpClient
+	if client == custom || client.Timeout != time.Minute {
+		t.Errorf("expected a copy of the custom client, got: %+v", client)
+	}
+	if transport, _ := custom.Transport.(layerTransport); transport.name != "custom" {
+		t.Errorf("expected custom client to be unchanged, got transport %T", custom.Transport)
+	}
+	request, _ := http.NewRequest(http.MethodGet, "https://example.com", nil)
+	if _, err := client.Do(request); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"base"}; !slices.Equal(calls, want) {
+		t.Errorf("expected transports %v, got %v", want, calls)
+	}
+}
+
+func TestWithHTTPClientRejectsNil(t *testing.T) {
+	if _, err := applyClientOptions([]ClientOption{WithHTTPClient(nil)}); err == nil {
+		t.Error("expected error for nil http client")
+	}
+}
@@ -0,0 +1,136 @@
+package communicationidentity_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	ci "github.com/jls-ch/azure-communication-identity-go"
+)
+
+type countingTransport struct {
+	inner http.RoundTripper
+	calls atomic.Int32
+}
+
+func (transport *countingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	transport.calls.Add(1)
+	return transport.inner.RoundTrip(request)
+}
+
+func TestWithBaseTransport(t *testing.T) {
+	transport := &countingTransport{inner: http.DefaultTransport}
+	client := newTestClient(
+		t,
+		createIdentityHandler(new(atomic.Int32)),
+		ci.WithBaseTransport(transport),
+	)
+
+	if _, err := client.CreateCommunicationIdentity(
+		context.Background(), []string{"chat"}, nil,
+	); err != nil {
+		t.Fatal(err)
+	}
+	if transport.calls.Load() != 1 {
+		t.Errorf("expected request through base transport, got %d calls", transport.calls.Load())
+	}
+}
+
+func TestWithHTTPClient(t *testing.T) {
+	transport := &countingTransport{inner: http.DefaultTransport}
+	client := newTestClient(
+		t,
+		createIdentityHandler(new(atomic.Int32)),
+		ci.WithHTTPClient(&http.Client{Transport: transport}),
+	)
+
+	if _, err := client.CreateCommunicationIdentity(
+		context.Background(), []string{"chat"}, nil,
+	); err != nil {
+		t.Fatal(err)
+	}
+	if transport.calls.Load() != 1 {
+		t.Errorf("expected request through custom client, got %d calls", transport.calls.Load())
+	}
+}
+
+var errClose = errors.New("close failed")
+
+// failingCloseBody fails to close after closing the wrapped body
+type failingCloseBody struct{ io.ReadCloser }
+
+func (body failingCloseBody) Close() error {
+	_ = body.ReadCloser.Close()
+	return errClose
+}
+
+// failingCloseTransport answers with bodies that fail to close
+type failingCloseTransport struct{}
+
+func (failingCloseTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	response, err := http.DefaultTransport.RoundTrip(request)
+	if err == nil {
+		response.Body = failingCloseBody{response.Body}
+	}
+	return response, err
+}
+
+func TestResponseBodyCloseErrorsAreReturned(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(
+		t,
+		createIdentityHandler(new(atomic.Int32)),
+		ci.WithBaseTransport(failingCloseTransport{}),
+	)
+	_, err := client.CreateCommunicationIdentity(ctx, []string{"chat"}, nil)
+	if !errors.Is(err, errClose) {
+		t.Errorf("expected the close error of a successful call, got: %v", err)
+	}
+
+	client = newTestClient(
+		t,
+		errorHandler(http.StatusNotFound, "", `{"error":{"code":"IdentityNotFound"}}`),
+		ci.WithBaseTransport(failingCloseTransport{}),
+	)
+	err = client.DeleteCommunicationIdentity(ctx, testIdentityID)
+	if !errors.Is(err, errClose) || !errors.Is(err, ci.ErrIdentityNotFound) {
+		t.Errorf("expected the close error joined to the ACS error, got: %v", err)
+	}
+}
+
+func TestConnectionIsReusedAfterErrorResponse(t *testing.T) {
+	var connections atomic.Int32
+	body := `{"error":{"code":"InvalidScope","message":"bad scope"}}` + strings.Repeat(" ", 900<<10)
+	failing := errorHandler(http.StatusBadRequest, "", body)
+	server := httptest.NewUnstartedServer(failing)
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			connections.Add(1)
+		}
+	}
+	server.Start()
+	t.Cleanup(server.Close)
+	endpoint, _ := url.Parse(server.URL)
+	client, err := ci.New(endpoint, testAccessKey, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for range 2 {
+		_, err := client.CreateCommunicationIdentity(context.Background(), []string{"chat"}, nil)
+		var statusErr *ci.StatusError
+		if !errors.As(err, &statusErr) || statusErr.HTTPStatus != http.StatusBadRequest {
+			t.Fatalf("expected status 400, got: %v", err)
+		}
+	}
+	if connections.Load() != 1 {
+		t.Errorf("expected the connection to be reused, got %d connections", connections.Load())
+	}
+}
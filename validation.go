@@ -0,0 +1,110 @@
+package communicationidentity
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Checks a single identity ID, a non-nil error marks the ID as invalid
+type IdentityIDValidator func(id string) error
+
+const acsIdentityPrefix = "8:acs:"
+
+// DefaultIdentityIDValidator validates the format of identities created by ACS:
+// `8:acs:<resource-id>_<user-id>` where both IDs are UUIDs.
+//
+// Catches the common mistake of passing tenant IDs, user OIDs or email addresses instead of
+// an identity ID.
+func DefaultIdentityIDValidator() IdentityIDValidator {
+	return func(id string) error {
+		rest, found := strings.CutPrefix(id, acsIdentityPrefix)
+		if !found {
+			return fmt.Errorf("identity ID must start with %q", acsIdentityPrefix)
+		}
+		resourceID, userID, found := strings.Cut(rest, "_")
+		if !found {
+			return fmt.Errorf(
+				"identity ID must have the form '%s<resource-id>_<user-id>'",
+				acsIdentityPrefix,
+			)
+		}
+		if !isUUID(resourceID) {
+			return fmt.Errorf("resource part %q of identity ID is not a UUID", resourceID)
+		}
+		if !isUUID(userID) {
+			return fmt.Errorf("user part %q of identity ID is not a UUID", userID)
+		}
+		return nil
+	}
+}
+
+// isUUID checks for the canonical textual UUID representation (8-4-4-4-12 hex digits)
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i, r := range s {
+		switch i {
+		case 8, 13, 18, 23:
+			if r != '-' {
+				return false
+			}
+		default:
+			isHex := (r >= '0' && r <= '9') || (r >= 'a' && r <= 'f') || (r >= 'A' && r <= 'F')
+			if !isHex {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Returned when an argument is rejected locally, before any request is sent to ACS
+type ValidationError struct {
+	Field string
+	Value string
+	Err   error
+}
+
+func (err *ValidationError) Error() string {
+	if err.Err == nil {
+		return fmt.Sprintf("invalid value %q for %s", err.Value, err.Field)
+	}
+	return fmt.Sprintf("invalid value %q for %s: %v", err.Value, err.Field, err.Err)
+}
+
+func (err *ValidationError) Unwrap() error {
+	return err.Err
+}
+
+// validateScopes rejects an empty list of scopes for field
+func validateScopes[S ~string](field string, scopes []S) error {
+	if len(scopes) == 0 {
+		return &ValidationError{Field: field, Err: fmt.Errorf("at least one scope is required")}
+	}
+	return nil
+}
+
+// validateTokenRequest checks the scopes and expiry of a token request before the token cache is
+// consulted, at least one scope supported by ACS is required
+func validateTokenRequest(scopes []string, expireInMinutes *int32) error {
+	if err := validateScopes("scopes", scopes); err != nil {
+		return err
+	}
+	if err := ValidateScopes(scopes); err != nil {
+		return err
+	}
+	return validateExpireInMinutes(expireInMinutes)
+}
+
+// validateIdentityID runs the configured [IdentityIDValidator], if any.
+// Every method accepting an identity ID must call this before dispatching a request.
+func (client CommunicationIdentityClient) validateIdentityID(id string) error {
+	if client.options.identityIDValidator == nil {
+		return nil
+	}
+	if err := client.options.identityIDValidator(id); err != nil {
+		return &ValidationError{Field: "identityID", Value: id, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package communicationidentity
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+)
+
+func TestDefaultIdentityIDValidator(t *testing.T) {
+	validate := DefaultIdentityIDValidator()
+	cases := []struct {
+		name  string
+		id    string
+		valid bool
+	}{
+		{"acs identity", "8:acs:b6aada1f-0b1d-47ac-866f-91aae00a1d01_00000005-4ad5-d0b4-6a0b-343a0d00ab6c", true},
+		{"tenant id", "b6aada1f-0b1d-47ac-866f-91aae00a1d01", false},
+		{"email", "someone@example.com", false},
+		{"missing user part", "8:acs:b6aada1f-0b1d-47ac-866f-91aae00a1d01", false},
+		{"non uuid user part", "8:acs:b6aada1f-0b1d-47ac-866f-91aae00a1d01_user", false},
+		{"empty", "", false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := validate(c.id)
+			if c.valid && err != nil {
+				t.Errorf("expected %q to be valid, got: %v", c.id, err)
+			}
+			if !c.valid && err == nil {
+				t.Errorf("expected %q to be rejected", c.id)
+			}
+		})
+	}
+}
+
+func TestValidateIdentityIDReturnsValidationError(t *testing.T) {
+	endpoint, _ := url.Parse("https://example.communication.azure.com")
+	client, err := New(endpoint, "", "", WithIdentityIDValidator(DefaultIdentityIDValidator()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = client.validateIdentityID("someone@example.com")
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError, got: %v", err)
+	}
+	if validationErr.Field != "identityID" || validationErr.Value != "someone@example.com" {
+		t.Errorf("unexpected validation error content: %+v", validationErr)
+	}
+}
+
+func TestNewRejectsNilOption(t *testing.T) {
+	endpoint, _ := url.Parse("https://example.communication.azure.com")
+	if _, err := New(endpoint, "", "", nil); err == nil {
+		t.Fatal("expected error for nil option")
+	}
+}